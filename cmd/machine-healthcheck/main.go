@@ -69,8 +69,14 @@ func main() {
 		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
 	)
 
+	workers := controller.DefaultWorkerOptions()
+	workers.AddFlags(flag.CommandLine)
+
 	klog.InitFlags(nil)
 	flag.Parse()
+	if err := workers.Validate(); err != nil {
+		klog.Fatal(err)
+	}
 	printVersion()
 
 	// Get a config to talk to the apiserver
@@ -112,7 +118,10 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr, opts, machinehealthcheck.Add); err != nil {
+	addMachineHealthCheck := func(mgr manager.Manager, opts manager.Options) error {
+		return machinehealthcheck.AddWithWorkers(mgr, opts, workers)
+	}
+	if err := controller.AddToManager(mgr, opts, addMachineHealthCheck); err != nil {
 		klog.Fatal(err)
 	}
 
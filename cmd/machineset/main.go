@@ -86,7 +86,13 @@ func main() {
 		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
 	)
 
+	workers := controller.DefaultWorkerOptions()
+	workers.AddFlags(flag.CommandLine)
+
 	flag.Parse()
+	if err := workers.Validate(); err != nil {
+		log.Fatal(err)
+	}
 	if *watchNamespace != "" {
 		log.Printf("Watching cluster-api objects only in namespace %q for reconciliation.", *watchNamespace)
 	}
@@ -140,6 +146,9 @@ func main() {
 		log.Fatal(err)
 	}
 
+	machineHealthCheckDefaulter := v1beta1.NewMachineHealthCheckDefaulter()
+	machineHealthCheckValidator := v1beta1.NewMachineHealthCheckValidator()
+
 	if *webhookEnabled {
 		mgr.GetWebhookServer().Port = *webhookPort
 		mgr.GetWebhookServer().CertDir = *webhookCertdir
@@ -147,6 +156,8 @@ func main() {
 		mgr.GetWebhookServer().Register(v1beta1.DefaultMachineValidatingHookPath, &webhook.Admission{Handler: machineValidator})
 		mgr.GetWebhookServer().Register(v1beta1.DefaultMachineSetMutatingHookPath, &webhook.Admission{Handler: machineSetDefaulter})
 		mgr.GetWebhookServer().Register(v1beta1.DefaultMachineSetValidatingHookPath, &webhook.Admission{Handler: machineSetValidator})
+		mgr.GetWebhookServer().Register(v1beta1.DefaultMachineHealthCheckMutatingHookPath, &webhook.Admission{Handler: machineHealthCheckDefaulter})
+		mgr.GetWebhookServer().Register(v1beta1.DefaultMachineHealthCheckValidatingHookPath, &webhook.Admission{Handler: machineHealthCheckValidator})
 	}
 
 	log.Printf("Registering Components.")
@@ -157,7 +168,10 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr, opts, machineset.Add); err != nil {
+	addMachineSet := func(mgr manager.Manager, opts manager.Options) error {
+		return machineset.AddWithWorkers(mgr, opts, workers)
+	}
+	if err := controller.AddToManager(mgr, opts, addMachineSet); err != nil {
 		log.Fatal(err)
 	}
 
@@ -2,12 +2,15 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"runtime"
+	"strings"
 	"time"
 
 	mapiv1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	"github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/controller/nodelink"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
 	sdkVersion "github.com/operator-framework/operator-sdk/version"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -55,9 +58,27 @@ func main() {
 		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
 	)
 
+	metricsAddress := flag.String(
+		"metrics-bind-address",
+		metrics.DefaultNodeLinkMetricsAddress,
+		"Address for hosting metrics",
+	)
+
+	nodeMatchers := flag.String(
+		"node-matchers",
+		strings.Join(nodelink.DefaultNodeMatchers, ","),
+		fmt.Sprintf("Comma separated list of matchers used in priority order to link machines and nodes. One or more of: %s.", strings.Join(nodelink.NodeMatcherNames(), ", ")),
+	)
+
+	workers := controller.DefaultWorkerOptions()
+	workers.AddFlags(flag.CommandLine)
+
 	klog.InitFlags(nil)
 	flag.Set("logtostderr", "true")
 	flag.Parse()
+	if err := workers.Validate(); err != nil {
+		klog.Fatal(err)
+	}
 
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
@@ -66,8 +87,7 @@ func main() {
 	}
 
 	opts := manager.Options{
-		// Disable metrics serving
-		MetricsBindAddress:      "0",
+		MetricsBindAddress:      *metricsAddress,
 		LeaderElection:          *leaderElect,
 		LeaderElectionNamespace: *leaderElectResourceNamespace,
 		LeaderElectionID:        "cluster-api-provider-nodelink-leader",
@@ -94,10 +114,15 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr, opts, nodelink.Add); err != nil {
+	addNodeLink := func(mgr manager.Manager, opts manager.Options) error {
+		return nodelink.AddWithNodeMatchers(mgr, opts, strings.Split(*nodeMatchers, ","), workers)
+	}
+	if err := controller.AddToManager(mgr, opts, addNodeLink); err != nil {
 		klog.Fatal(err)
 	}
 
+	metrics.InitializeNodeLinkMetrics()
+
 	klog.Info("Starting the Cmd.")
 
 	// Start the Cmd
@@ -9,10 +9,13 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
 	vsphereapis "github.com/openshift/machine-api-operator/pkg/apis/vsphereprovider"
+	"github.com/openshift/machine-api-operator/pkg/controller"
 	capimachine "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	machine "github.com/openshift/machine-api-operator/pkg/controller/vsphere"
+	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/version"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -68,6 +71,9 @@ func main() {
 		":9440",
 		"The address for health checking.",
 	)
+	workers := controller.DefaultWorkerOptions()
+	workers.AddFlags(flag.CommandLine)
+
 	flag.Parse()
 
 	if printVersion {
@@ -75,6 +81,10 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := workers.Validate(); err != nil {
+		klog.Fatal(err)
+	}
+
 	cfg := config.GetConfigOrDie()
 	syncPeriod := 10 * time.Minute
 
@@ -102,16 +112,17 @@ func main() {
 		klog.Fatalf("Failed to set up overall controller manager: %v", err)
 	}
 
-	// Create a taskIDCache for create task IDs in case they are lost due to
-	// network error or stale cache.
-	taskIDCache := make(map[string]string)
+	ctx := signals.SetupSignalHandler()
+	eventRecorder := mgr.GetEventRecorderFor("vspherecontroller")
 
 	// Initialize machine actuator.
 	machineActuator := machine.NewActuator(machine.ActuatorParams{
-		Client:        mgr.GetClient(),
-		APIReader:     mgr.GetAPIReader(),
-		EventRecorder: mgr.GetEventRecorderFor("vspherecontroller"),
-		TaskIDCache:   taskIDCache,
+		Client:         mgr.GetClient(),
+		APIReader:      mgr.GetAPIReader(),
+		EventRecorder:  eventRecorder,
+		KubeClient:     kubernetes.NewForConfigOrDie(cfg),
+		TaskWatcher:    session.NewTaskWatcher(ctx),
+		VMEventWatcher: session.NewVMEventWatcher(ctx, eventRecorder),
 	})
 
 	if err := configv1.AddToScheme(mgr.GetScheme()); err != nil {
@@ -126,7 +137,9 @@ func main() {
 		klog.Fatal(err)
 	}
 
-	capimachine.AddWithActuator(mgr, machineActuator)
+	if err := capimachine.AddWithActuatorAndWorkers(mgr, machineActuator, workers); err != nil {
+		klog.Fatal(err)
+	}
 
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
@@ -136,7 +149,7 @@ func main() {
 		klog.Fatal(err)
 	}
 
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		klog.Fatalf("Failed to run manager: %v", err)
 	}
 }
@@ -19,6 +19,7 @@ require (
 	github.com/stretchr/testify v1.6.1
 	github.com/vmware/govmomi v0.22.2
 	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
+	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
 	gopkg.in/gcfg.v1 v1.2.3
 	k8s.io/api v0.20.0
 	k8s.io/apimachinery v0.20.0
@@ -39,5 +39,7 @@ echo "Copying and patching generated CRDs"
 annotate_crd $dir/src/github.com/openshift/machine-api-operator/config/crds/machine.openshift.io_machinehealthchecks.yaml install/0000_30_machine-api-operator_07_machinehealthcheck.crd.yaml
 annotate_crd $dir/src/github.com/openshift/machine-api-operator/config/crds/machine.openshift.io_machinesets.yaml install/0000_30_machine-api-operator_03_machineset.crd.yaml
 annotate_crd $dir/src/github.com/openshift/machine-api-operator/config/crds/machine.openshift.io_machines.yaml install/0000_30_machine-api-operator_02_machine.crd.yaml
+annotate_crd $dir/src/github.com/openshift/machine-api-operator/config/crds/machine.openshift.io_ipaddresspools.yaml install/0000_30_machine-api-operator_04_ipaddresspool.crd.yaml
+annotate_crd $dir/src/github.com/openshift/machine-api-operator/config/crds/machine.openshift.io_ipaddressclaims.yaml install/0000_30_machine-api-operator_05_ipaddressclaim.crd.yaml
 
 rm -rf $dir
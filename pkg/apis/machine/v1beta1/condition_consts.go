@@ -23,7 +23,39 @@ const (
 	// allowed to remediate any Machines or whether it is blocked from remediating any further.
 	RemediationAllowedCondition ConditionType = "RemediationAllowed"
 
-	// TooManyUnhealthy is the reason used when too many Machines are unhealthy and the MachineHealthCheck is blocked
-	// from making any further remediations.
+	// TooManyUnhealthy is the reason used when too many Machines are unhealthy, or the number of unhealthy Machines
+	// is above the unhealthyRange, and the MachineHealthCheck is blocked from making any further remediations.
 	TooManyUnhealthyReason = "TooManyUnhealthy"
+
+	// TooFewUnhealthyReason is the reason used when the number of unhealthy Machines is below the unhealthyRange,
+	// and the unhealthy Machines are not remediated until more Machines are unhealthy.
+	TooFewUnhealthyReason = "TooFewUnhealthy"
+
+	// OutsideRemediationWindowReason is the reason used when unhealthy Machines are not remediated because none of
+	// the remediation windows of the MachineHealthCheck is currently open.
+	OutsideRemediationWindowReason = "OutsideRemediationWindow"
+
+	// NoOverlapCondition is set on MachineHealthChecks to show whether any of the Machines they select are also
+	// selected by another MachineHealthCheck.
+	NoOverlapCondition ConditionType = "NoOverlap"
+
+	// OverlappingMachineHealthChecksReason is the reason used when at least one Machine selected by the
+	// MachineHealthCheck is also selected by another MachineHealthCheck.
+	OverlappingMachineHealthChecksReason = "OverlappingMachineHealthChecks"
+)
+
+// Conditions and condition Reasons for the Machine object
+
+const (
+	// NodeLinkedCondition is set on Machines by the nodelink controller to show whether the Machine could be
+	// linked to exactly one Node.
+	NodeLinkedCondition ConditionType = "NodeLinked"
+
+	// AmbiguousNodeMatchReason is the reason used when more than one Node matches the Machine
+	// for the highest priority node matcher that found any candidate.
+	AmbiguousNodeMatchReason = "AmbiguousNodeMatch"
+
+	// NodeConflictReason is the reason used when more than one Machine claims the same Node, either because
+	// they all match the Node or because another Machine already references the Node.
+	NodeConflictReason = "NodeConflict"
 )
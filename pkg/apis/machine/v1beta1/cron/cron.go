@@ -0,0 +1,185 @@
+// Package cron parses standard five field cron expressions
+// ("minute hour day-of-month month day-of-week") and computes their activation times.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next activation of schedules
+// which can never match, such as "0 0 30 2 *"
+const maxSearchYears = 5
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// daysRestricted is true when neither day-of-month nor day-of-week starts with "*".
+	// In that case a day matches when either of them matches, as in crontab(5).
+	daysRestricted bool
+}
+
+type bounds struct {
+	name     string
+	min, max uint
+}
+
+var (
+	minuteBounds     = bounds{"minute", 0, 59}
+	hourBounds       = bounds{"hour", 0, 23}
+	dayOfMonthBounds = bounds{"day of month", 1, 31}
+	monthBounds      = bounds{"month", 1, 12}
+	// 7 is accepted as an alias for Sunday
+	dayOfWeekBounds = bounds{"day of week", 0, 7}
+)
+
+// Parse parses a five field cron expression.
+// Each field accepts "*", single values, ranges ("1-5"), steps ("*/15", "1-30/2")
+// and comma separated lists of those.
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d: %q", len(fields), spec)
+	}
+
+	var err error
+	s := &Schedule{}
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dayOfMonth, err = parseField(fields[2], dayOfMonthBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dayOfWeek, err = parseField(fields[4], dayOfWeekBounds); err != nil {
+		return nil, err
+	}
+
+	// fold Sunday as 7 into Sunday as 0
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek |= 1
+	}
+	s.daysRestricted = !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parsePart(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+func parsePart(part string, b bounds) (uint64, error) {
+	rangeAndStep := strings.Split(part, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("invalid %s %q: too many slashes", b.name, part)
+	}
+
+	start, end := b.min, b.max
+	if rangeAndStep[0] != "*" {
+		startAndEnd := strings.Split(rangeAndStep[0], "-")
+		if len(startAndEnd) > 2 {
+			return 0, fmt.Errorf("invalid %s %q: too many hyphens", b.name, part)
+		}
+
+		var err error
+		if start, err = parseValue(startAndEnd[0], b); err != nil {
+			return 0, err
+		}
+		end = start
+		if len(startAndEnd) == 2 {
+			if end, err = parseValue(startAndEnd[1], b); err != nil {
+				return 0, err
+			}
+		} else if len(rangeAndStep) == 2 {
+			// "5/10" means every 10 starting at 5
+			end = b.max
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid %s %q: start of range is after its end", b.name, part)
+		}
+	}
+
+	step := uint(1)
+	if len(rangeAndStep) == 2 {
+		s, err := strconv.ParseUint(rangeAndStep[1], 10, 8)
+		if err != nil || s == 0 {
+			return 0, fmt.Errorf("invalid %s %q: step must be a positive integer", b.name, part)
+		}
+		step = uint(s)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (uint, error) {
+	v, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: not a number", b.name, value)
+	}
+	if uint(v) < b.min || uint(v) > b.max {
+		return 0, fmt.Errorf("invalid %s %d: must be between %d and %d", b.name, v, b.min, b.max)
+	}
+	return uint(v), nil
+}
+
+// Next returns the first activation time of the schedule strictly after t,
+// in the location of t. It returns the zero time if the schedule never activates.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.daysRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
@@ -0,0 +1,123 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		spec          string
+		expectedError string
+	}{
+		{spec: "* * * * *"},
+		{spec: "0 2 * * 6"},
+		{spec: "*/15 0-6,22-23 1 */2 0,7"},
+		{spec: "5/10 * * * 1-5"},
+		{spec: "* * * *", expectedError: "expected 5 fields, found 4: \"* * * *\""},
+		{spec: "60 * * * *", expectedError: "invalid minute 60: must be between 0 and 59"},
+		{spec: "* 5-1 * * *", expectedError: "invalid hour \"5-1\": start of range is after its end"},
+		{spec: "* * 0 * *", expectedError: "invalid day of month 0: must be between 1 and 31"},
+		{spec: "* * * jan *", expectedError: "invalid month \"jan\": not a number"},
+		{spec: "* * * * */0", expectedError: "invalid day of week \"*/0\": step must be a positive integer"},
+		{spec: "1-2-3 * * * *", expectedError: "invalid minute \"1-2-3\": too many hyphens"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			_, err := Parse(tc.spec)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("Expected error: %q, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone database not available: %v", err)
+	}
+
+	// Wednesday
+	from := time.Date(2021, time.January, 13, 10, 30, 15, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "every minute",
+			spec:     "* * * * *",
+			from:     from,
+			expected: time.Date(2021, time.January, 13, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name:     "strictly after the given time",
+			spec:     "31 10 * * *",
+			from:     time.Date(2021, time.January, 13, 10, 31, 0, 0, time.UTC),
+			expected: time.Date(2021, time.January, 14, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name:     "every 15 minutes",
+			spec:     "*/15 * * * *",
+			from:     from,
+			expected: time.Date(2021, time.January, 13, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name:     "saturdays at 2am",
+			spec:     "0 2 * * 6",
+			from:     from,
+			expected: time.Date(2021, time.January, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "sundays as 7",
+			spec:     "0 2 * * 7",
+			from:     from,
+			expected: time.Date(2021, time.January, 17, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			spec:     "0 0 1 * 5",
+			from:     from,
+			expected: time.Date(2021, time.January, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "next year",
+			spec:     "0 0 1 1 *",
+			from:     from,
+			expected: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "in the location of the given time",
+			spec:     "0 2 * * *",
+			from:     from.In(newYork),
+			expected: time.Date(2021, time.January, 14, 2, 0, 0, 0, newYork),
+		},
+		{
+			name: "never",
+			spec: "0 0 30 2 *",
+			from: from,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if next := schedule.Next(tc.from); !next.Equal(tc.expected) {
+				t.Errorf("Expected: %v, got: %v", tc.expected, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// IPAddressPoolLabel is the label set on IPAddressClaims with the name of their pool
+	IPAddressPoolLabel = "machine.openshift.io/ip-address-pool"
+	// IPAddressClaimMachineLabel is the label set on IPAddressClaims with the name of the Machine claiming the address
+	IPAddressClaimMachineLabel = "machine.openshift.io/ip-address-claim-machine"
+	// IPAddressClaimDeviceLabel is the label set on IPAddressClaims with the index of the network device
+	// of the Machine the address is assigned to
+	IPAddressClaimDeviceLabel = "machine.openshift.io/ip-address-claim-device"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IPAddressPool is a pool of static IP addresses claimed by the network devices of Machines,
+// so that the replicas of a MachineSet get distinct addresses from a single template.
+// +kubebuilder:resource:shortName=ippool;ippools
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Prefix",type="integer",JSONPath=".spec.prefix",description="Prefix length of the network of the addresses"
+// +kubebuilder:printcolumn:name="Gateway",type="string",JSONPath=".spec.gateway",description="Gateway of the network of the addresses"
+type IPAddressPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the addresses of the pool
+	Spec IPAddressPoolSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IPAddressPoolList contains a list of IPAddressPool
+type IPAddressPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddressPool `json:"items"`
+}
+
+// IPAddressPoolSpec defines the addresses of an IPAddressPool and the configuration of their network
+type IPAddressPoolSpec struct {
+	// Addresses are the addresses of the pool, as single addresses (192.168.1.10),
+	// ranges (192.168.1.10-192.168.1.20) or CIDRs (192.168.1.0/28).
+	// The network and broadcast addresses of IPv4 CIDRs are not allocated.
+	// All the addresses must belong to the same IP family.
+	// +kubebuilder:validation:MinItems=1
+	Addresses []string `json:"addresses"`
+
+	// Prefix is the prefix length of the network of the addresses, e.g. 24.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	Prefix int32 `json:"prefix"`
+
+	// Gateway is the gateway of the network of the addresses.
+	// It is never allocated.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// Nameservers is a list of IPv4 or IPv6 addresses of DNS servers.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// SearchDomains is a list of DNS search domains.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IPAddressClaim records the allocation of an address of an IPAddressPool.
+// Claims are owned by the Machine the address is assigned to, and named after
+// the pool and the address so that an address can't be allocated twice.
+// +kubebuilder:resource:shortName=ipclaim;ipclaims
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Pool",type="string",JSONPath=".spec.pool.name",description="Pool the address is allocated from"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description="Allocated address"
+// +kubebuilder:printcolumn:name="Machine",type="string",JSONPath=".metadata.labels['machine\\.openshift\\.io/ip-address-claim-machine']",description="Machine the address is assigned to"
+type IPAddressClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of the allocated address
+	Spec IPAddressClaimSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IPAddressClaimList contains a list of IPAddressClaim
+type IPAddressClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddressClaim `json:"items"`
+}
+
+// IPAddressClaimSpec defines the address allocated by an IPAddressClaim
+type IPAddressClaimSpec struct {
+	// Pool is the IPAddressPool in the namespace of the claim the address is allocated from.
+	Pool corev1.LocalObjectReference `json:"pool"`
+
+	// Address is the allocated address, without its prefix length.
+	Address string `json:"address"`
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -51,6 +52,10 @@ const (
 // +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".status.nodeRef.name",description="Node associated with machine",priority=1
 // +kubebuilder:printcolumn:name="ProviderID",type="string",JSONPath=".spec.providerID",description="Provider ID of machine created in cloud provider",priority=1
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".metadata.annotations['machine\\.openshift\\.io/instance-state']",description="State of instance",priority=1
+// +kubebuilder:printcolumn:name="Kubelet",type="string",JSONPath=".status.nodeInfo.kubeletVersion",description="Kubelet version of the node",priority=1
+// +kubebuilder:printcolumn:name="Arch",type="string",JSONPath=".status.nodeInfo.architecture",description="Architecture of the node",priority=1
+// +kubebuilder:printcolumn:name="CPU",type="string",JSONPath=".status.nodeInfo.allocatableCPU",description="Allocatable CPU of the node",priority=1
+// +kubebuilder:printcolumn:name="Memory",type="string",JSONPath=".status.nodeInfo.allocatableMemory",description="Allocatable memory of the node",priority=1
 type Machine struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -59,6 +64,14 @@ type Machine struct {
 	Status MachineStatus `json:"status,omitempty"`
 }
 
+func (m *Machine) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+func (m *Machine) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // MachineSpec defines the desired state of Machine
 type MachineSpec struct {
 	// ObjectMeta will autopopulate the Node created. Use this to
@@ -165,6 +178,41 @@ type MachineStatus struct {
 	// One of: Failed, Provisioning, Provisioned, Running, Deleting
 	// +optional
 	Phase *string `json:"phase,omitempty"`
+
+	// Conditions defines the current state of the Machine
+	Conditions Conditions `json:"conditions,omitempty"`
+
+	// NodeInfo is a summary of the Node referenced by NodeRef, as last observed by the nodelink controller.
+	// +optional
+	NodeInfo *MachineNodeInfo `json:"nodeInfo,omitempty"`
+}
+
+// MachineNodeInfo describes the Node backing a Machine.
+type MachineNodeInfo struct {
+	// KubeletVersion is the version of the kubelet running on the Node.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
+	// OSImage is the OS image reported by the Node, e.g. Red Hat Enterprise Linux CoreOS.
+	// +optional
+	OSImage string `json:"osImage,omitempty"`
+
+	// Architecture is the CPU architecture reported by the Node, e.g. amd64.
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// AllocatableCPU is the amount of CPU of the Node available for scheduling.
+	// +optional
+	AllocatableCPU *resource.Quantity `json:"allocatableCPU,omitempty"`
+
+	// AllocatableMemory is the amount of memory of the Node available for scheduling.
+	// +optional
+	AllocatableMemory *resource.Quantity `json:"allocatableMemory,omitempty"`
+
+	// ReadyLastTransitionTime is the last time the Ready condition of the Node changed its status.
+	// The heartbeat time of the condition is not reported, so that the Machine is not updated on every heartbeat.
+	// +optional
+	ReadyLastTransitionTime *metav1.Time `json:"readyLastTransitionTime,omitempty"`
 }
 
 // LastOperation represents the detail of the last performed operation on the MachineObject.
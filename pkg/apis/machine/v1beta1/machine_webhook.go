@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -19,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
@@ -69,6 +71,9 @@ const (
 	DefaultMachineSetMutatingHookPath   = "/mutate-machine-openshift-io-v1beta1-machineset"
 	DefaultMachineSetValidatingHookPath = "/validate-machine-openshift-io-v1beta1-machineset"
 
+	DefaultMachineHealthCheckMutatingHookPath   = "/mutate-machine-openshift-io-v1beta1-machinehealthcheck"
+	DefaultMachineHealthCheckValidatingHookPath = "/validate-machine-openshift-io-v1beta1-machinehealthcheck"
+
 	defaultWebhookConfigurationName = "machine-api"
 	defaultWebhookServiceName       = "machine-api-operator-webhook"
 	defaultWebhookServiceNamespace  = "openshift-machine-api"
@@ -105,6 +110,8 @@ const (
 	minVSphereMemoryMiB = 2048
 	// https://docs.openshift.com/container-platform/4.1/installing/installing_vsphere/installing-vsphere.html#minimum-resource-requirements_installing-vsphere
 	minVSphereDiskGiB = 120
+	// maxVSphereSCSIUnits is the number of units of a wide SCSI controller
+	maxVSphereSCSIUnits = 16
 )
 
 var (
@@ -321,7 +328,7 @@ func getMachineDefaulterOperation(platformStatus *osconfigv1.PlatformStatus) mac
 	}
 }
 
-// NewValidatingWebhookConfiguration creates a validation webhook configuration with configured Machine and MachineSet webhooks
+// NewValidatingWebhookConfiguration creates a validation webhook configuration with configured Machine, MachineSet and MachineHealthCheck webhooks
 func NewValidatingWebhookConfiguration() *admissionregistrationv1.ValidatingWebhookConfiguration {
 	validatingWebhookConfiguration := &admissionregistrationv1.ValidatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -333,6 +340,7 @@ func NewValidatingWebhookConfiguration() *admissionregistrationv1.ValidatingWebh
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			MachineValidatingWebhook(),
 			MachineSetValidatingWebhook(),
+			MachineHealthCheckValidatingWebhook(),
 		},
 	}
 
@@ -406,7 +414,39 @@ func MachineSetValidatingWebhook() admissionregistrationv1.ValidatingWebhook {
 	}
 }
 
-// NewMutatingWebhookConfiguration creates a mutating webhook configuration with configured Machine and MachineSet webhooks
+// MachineHealthCheckValidatingWebhook returns validating webhooks for machineHealthCheck to populate the configuration
+func MachineHealthCheckValidatingWebhook() admissionregistrationv1.ValidatingWebhook {
+	machineHealthCheckServiceReference := admissionregistrationv1.ServiceReference{
+		Namespace: defaultWebhookServiceNamespace,
+		Name:      defaultWebhookServiceName,
+		Path:      pointer.StringPtr(DefaultMachineHealthCheckValidatingHookPath),
+		Port:      pointer.Int32Ptr(defaultWebhookServicePort),
+	}
+	return admissionregistrationv1.ValidatingWebhook{
+		AdmissionReviewVersions: []string{"v1beta1"},
+		Name:                    "validation.machinehealthcheck.machine.openshift.io",
+		FailurePolicy:           &webhookFailurePolicy,
+		SideEffects:             &webhookSideEffects,
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &machineHealthCheckServiceReference,
+		},
+		Rules: []admissionregistrationv1.RuleWithOperations{
+			{
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{machine.GroupName},
+					APIVersions: []string{SchemeGroupVersion.Version},
+					Resources:   []string{"machinehealthchecks"},
+				},
+				Operations: []admissionregistrationv1.OperationType{
+					admissionregistrationv1.Create,
+					admissionregistrationv1.Update,
+				},
+			},
+		},
+	}
+}
+
+// NewMutatingWebhookConfiguration creates a mutating webhook configuration with configured Machine, MachineSet and MachineHealthCheck webhooks
 func NewMutatingWebhookConfiguration() *admissionregistrationv1.MutatingWebhookConfiguration {
 	mutatingWebhookConfiguration := &admissionregistrationv1.MutatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -418,6 +458,7 @@ func NewMutatingWebhookConfiguration() *admissionregistrationv1.MutatingWebhookC
 		Webhooks: []admissionregistrationv1.MutatingWebhook{
 			MachineMutatingWebhook(),
 			MachineSetMutatingWebhook(),
+			MachineHealthCheckMutatingWebhook(),
 		},
 	}
 
@@ -489,6 +530,37 @@ func MachineSetMutatingWebhook() admissionregistrationv1.MutatingWebhook {
 	}
 }
 
+// MachineHealthCheckMutatingWebhook returns mutating webhook for machineHealthCheck to apply in configuration
+func MachineHealthCheckMutatingWebhook() admissionregistrationv1.MutatingWebhook {
+	machineHealthCheckServiceReference := admissionregistrationv1.ServiceReference{
+		Namespace: defaultWebhookServiceNamespace,
+		Name:      defaultWebhookServiceName,
+		Path:      pointer.StringPtr(DefaultMachineHealthCheckMutatingHookPath),
+		Port:      pointer.Int32Ptr(defaultWebhookServicePort),
+	}
+	return admissionregistrationv1.MutatingWebhook{
+		AdmissionReviewVersions: []string{"v1beta1"},
+		Name:                    "default.machinehealthcheck.machine.openshift.io",
+		FailurePolicy:           &webhookFailurePolicy,
+		SideEffects:             &webhookSideEffects,
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &machineHealthCheckServiceReference,
+		},
+		Rules: []admissionregistrationv1.RuleWithOperations{
+			{
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{machine.GroupName},
+					APIVersions: []string{SchemeGroupVersion.Version},
+					Resources:   []string{"machinehealthchecks"},
+				},
+				Operations: []admissionregistrationv1.OperationType{
+					admissionregistrationv1.Create,
+				},
+			},
+		},
+	}
+}
+
 // Handle handles HTTP requests for admission webhook servers.
 func (h *machineValidatorHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
 	m := &Machine{}
@@ -1080,7 +1152,14 @@ func validateVSphere(m *Machine, config *admissionConfig) (bool, []string, utile
 		return false, warnings, utilerrors.NewAggregate(errs)
 	}
 
-	if providerSpec.Template == "" {
+	if providerSpec.ContentLibraryItem != nil {
+		if providerSpec.Template != "" {
+			errs = append(errs, field.Forbidden(field.NewPath("providerSpec", "template"), "template and contentLibraryItem are mutually exclusive"))
+		}
+		if providerSpec.ContentLibraryItem.Item == "" {
+			errs = append(errs, field.Required(field.NewPath("providerSpec", "contentLibraryItem", "item"), "item must be provided"))
+		}
+	} else if providerSpec.Template == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "template"), "template must be provided"))
 	}
 
@@ -1089,6 +1168,8 @@ func validateVSphere(m *Machine, config *admissionConfig) (bool, []string, utile
 	errs = append(errs, workspaceErrors...)
 
 	errs = append(errs, validateVSphereNetwork(providerSpec.Network, field.NewPath("providerSpec", "network"))...)
+	errs = append(errs, validateVSphereDataDisks(providerSpec.DataDisks, field.NewPath("providerSpec", "dataDisks"))...)
+	errs = append(errs, validateVSphereTags(providerSpec.Tags, field.NewPath("providerSpec", "tags"))...)
 
 	if providerSpec.NumCPUs < minVSphereCPU {
 		warnings = append(warnings, fmt.Sprintf("providerSpec.numCPUs: %d is missing or less than the minimum value (%d): nodes may not boot correctly", providerSpec.NumCPUs, minVSphereCPU))
@@ -1100,6 +1181,13 @@ func validateVSphere(m *Machine, config *admissionConfig) (bool, []string, utile
 		warnings = append(warnings, fmt.Sprintf("providerSpec.diskGiB: %d is missing or less than the recommended minimum (%d): nodes may fail to start if disk size is too low", providerSpec.DiskGiB, minVSphereDiskGiB))
 	}
 
+	switch providerSpec.ResizePolicy {
+	case "", vsphere.NoResize, vsphere.HotAddResize, vsphere.RestartResize:
+	default:
+		errs = append(errs, field.NotSupported(field.NewPath("providerSpec", "resizePolicy"), providerSpec.ResizePolicy,
+			[]string{string(vsphere.NoResize), string(vsphere.HotAddResize), string(vsphere.RestartResize)}))
+	}
+
 	if providerSpec.UserDataSecret == nil {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret"), "userDataSecret must be provided"))
 	} else {
@@ -1144,6 +1232,9 @@ func validateVSphereWorkspace(workspace *vsphere.Workspace, parentPath *field.Pa
 			errs = append(errs, field.Invalid(parentPath.Child("folder"), workspace.Folder, errMsg))
 		}
 	}
+	if workspace.Datastore != "" && workspace.DatastoreCluster != "" {
+		errs = append(errs, field.Forbidden(parentPath.Child("datastoreCluster"), "datastore and datastoreCluster are mutually exclusive"))
+	}
 
 	return warnings, errs
 }
@@ -1159,6 +1250,117 @@ func validateVSphereNetwork(network vsphere.NetworkSpec, parentPath *field.Path)
 		if spec.NetworkName == "" {
 			errs = append(errs, field.Required(fldPath.Child("networkName"), "networkName must be provided"))
 		}
+		errs = append(errs, validateVSphereNetworkDeviceStaticIP(spec, fldPath)...)
+	}
+
+	return errs
+}
+
+func validateVSphereNetworkDeviceStaticIP(spec vsphere.NetworkDeviceSpec, fldPath *field.Path) []error {
+	var errs []error
+	if spec.IPAddressPool != nil {
+		if spec.IPAddressPool.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("ipAddressPool", "name"), "name must be provided"))
+		}
+		if len(spec.IPAddrs) > 0 {
+			errs = append(errs, field.Forbidden(fldPath.Child("ipAddrs"), "ipAddrs and ipAddressPool are mutually exclusive"))
+		}
+	}
+
+	// The family of the address claimed from a pool is only known once it is allocated
+	hasPool := spec.IPAddressPool != nil
+	var hasIPv4, hasIPv6 bool
+	for i, addr := range spec.IPAddrs {
+		ip, _, err := net.ParseCIDR(addr)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("ipAddrs").Index(i), addr, "ipAddrs must be IPv4 or IPv6 addresses in CIDR notation"))
+			continue
+		}
+		if ip.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+
+	if spec.Gateway4 != "" {
+		if ip := net.ParseIP(spec.Gateway4); ip == nil || ip.To4() == nil {
+			errs = append(errs, field.Invalid(fldPath.Child("gateway4"), spec.Gateway4, "gateway4 must be an IPv4 address"))
+		} else if !hasIPv4 && !hasPool {
+			errs = append(errs, field.Invalid(fldPath.Child("gateway4"), spec.Gateway4, "gateway4 requires an IPv4 address in ipAddrs"))
+		}
+	}
+	if spec.Gateway6 != "" {
+		if ip := net.ParseIP(spec.Gateway6); ip == nil || ip.To4() != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("gateway6"), spec.Gateway6, "gateway6 must be an IPv6 address"))
+		} else if !hasIPv6 && !hasPool {
+			errs = append(errs, field.Invalid(fldPath.Child("gateway6"), spec.Gateway6, "gateway6 requires an IPv6 address in ipAddrs"))
+		}
+	}
+
+	for i, nameserver := range spec.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			errs = append(errs, field.Invalid(fldPath.Child("nameservers").Index(i), nameserver, "nameservers must be IPv4 or IPv6 addresses"))
+		}
+	}
+	for i, domain := range spec.SearchDomains {
+		if msgs := validation.IsDNS1123Subdomain(domain); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("searchDomains").Index(i), domain, strings.Join(msgs, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func validateVSphereDataDisks(dataDisks []vsphere.VSphereDisk, parentPath *field.Path) []error {
+	var errs []error
+	names := sets.NewString()
+	for i, disk := range dataDisks {
+		fldPath := parentPath.Index(i)
+		if disk.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("name"), "name must be provided"))
+		} else if names.Has(disk.Name) {
+			errs = append(errs, field.Duplicate(fldPath.Child("name"), disk.Name))
+		}
+		names.Insert(disk.Name)
+
+		if disk.SizeGiB <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("sizeGiB"), disk.SizeGiB, "sizeGiB must be greater than 0"))
+		}
+
+		switch disk.ProvisioningType {
+		case "", vsphere.ThinProvisioned, vsphere.ThickProvisioned, vsphere.EagerlyZeroedProvisioned:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("provisioningType"), disk.ProvisioningType,
+				[]string{string(vsphere.ThinProvisioned), string(vsphere.ThickProvisioned), string(vsphere.EagerlyZeroedProvisioned)}))
+		}
+
+		if disk.ControllerBusNumber != nil && *disk.ControllerBusNumber < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("controllerBusNumber"), *disk.ControllerBusNumber, "controllerBusNumber must not be negative"))
+		}
+		if disk.UnitNumber != nil && (*disk.UnitNumber < 0 || *disk.UnitNumber >= maxVSphereSCSIUnits) {
+			errs = append(errs, field.Invalid(fldPath.Child("unitNumber"), *disk.UnitNumber, fmt.Sprintf("unitNumber must be between 0 and %d", maxVSphereSCSIUnits-1)))
+		}
+	}
+
+	return errs
+}
+
+func validateVSphereTags(tags []vsphere.VSphereTag, parentPath *field.Path) []error {
+	var errs []error
+	seen := map[vsphere.VSphereTag]bool{}
+	for i, tag := range tags {
+		fldPath := parentPath.Index(i)
+		if tag.Category == "" {
+			errs = append(errs, field.Required(fldPath.Child("category"), "category must be provided"))
+		}
+		if tag.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("name"), "name must be provided"))
+		}
+		if seen[tag] {
+			errs = append(errs, field.Duplicate(fldPath, fmt.Sprintf("%s/%s", tag.Category, tag.Name)))
+		}
+		seen[tag] = true
 	}
 
 	return errs
@@ -2038,6 +2038,19 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.workspace.folder: Invalid value: \"/foo/vm/folder\": folder must be absolute path: expected prefix \"/datacenter/vm/\"",
 		},
+		{
+			testCase: "with a workspace datastore and datastore cluster",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Workspace = &vsphere.Workspace{
+					Server:           "server",
+					Datacenter:       "datacenter",
+					Datastore:        "datastore",
+					DatastoreCluster: "datastore-cluster",
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.workspace.datastoreCluster: Forbidden: datastore and datastoreCluster are mutually exclusive",
+		},
 		{
 			testCase: "with no network devices provided",
 			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
@@ -2063,6 +2076,120 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.network.devices[1].networkName: Required value: networkName must be provided",
 		},
+		{
+			testCase: "with a valid static IP configuration",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network = vsphere.NetworkSpec{
+					Devices: []vsphere.NetworkDeviceSpec{
+						{
+							NetworkName:   "networkName",
+							IPAddrs:       []string{"192.168.1.10/24", "fd00::10/64"},
+							Gateway4:      "192.168.1.1",
+							Gateway6:      "fd00::1",
+							Nameservers:   []string{"192.168.1.2", "fd00::2"},
+							SearchDomains: []string{"example.com"},
+						},
+					},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with tags",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Tags = []vsphere.VSphereTag{
+					{Category: "chargeback", Name: "team-a"},
+					{Category: "chargeback", Name: "project-b"},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a tag without category and name",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Tags = []vsphere.VSphereTag{{}}
+			},
+			expectedOk:    false,
+			expectedError: "[providerSpec.tags[0].category: Required value: category must be provided, providerSpec.tags[0].name: Required value: name must be provided]",
+		},
+		{
+			testCase: "with a duplicate tag",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Tags = []vsphere.VSphereTag{
+					{Category: "chargeback", Name: "team-a"},
+					{Category: "chargeback", Name: "team-a"},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.tags[1]: Duplicate value: \"chargeback/team-a\"",
+		},
+		{
+			testCase: "with an IP address pool",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddressPool = &corev1.LocalObjectReference{Name: "pool"}
+				p.Network.Devices[0].Gateway4 = "192.168.1.1"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an IP address pool and static IP addresses",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddressPool = &corev1.LocalObjectReference{Name: "pool"}
+				p.Network.Devices[0].IPAddrs = []string{"192.168.1.10/24"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].ipAddrs: Forbidden: ipAddrs and ipAddressPool are mutually exclusive",
+		},
+		{
+			testCase: "with no IP address pool name provided",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddressPool = &corev1.LocalObjectReference{}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].ipAddressPool.name: Required value: name must be provided",
+		},
+		{
+			testCase: "with a static IP address without prefix length",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddrs = []string{"192.168.1.10"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].ipAddrs[0]: Invalid value: \"192.168.1.10\": ipAddrs must be IPv4 or IPv6 addresses in CIDR notation",
+		},
+		{
+			testCase: "with an IPv4 gateway without IPv4 address",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddrs = []string{"fd00::10/64"}
+				p.Network.Devices[0].Gateway4 = "192.168.1.1"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].gateway4: Invalid value: \"192.168.1.1\": gateway4 requires an IPv4 address in ipAddrs",
+		},
+		{
+			testCase: "with an IPv6 gateway which is not IPv6",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].IPAddrs = []string{"fd00::10/64"}
+				p.Network.Devices[0].Gateway6 = "192.168.1.1"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].gateway6: Invalid value: \"192.168.1.1\": gateway6 must be an IPv6 address",
+		},
+		{
+			testCase: "with an invalid nameserver",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].Nameservers = []string{"dns.example.com"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].nameservers[0]: Invalid value: \"dns.example.com\": nameservers must be IPv4 or IPv6 addresses",
+		},
+		{
+			testCase: "with an invalid search domain",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Network.Devices[0].SearchDomains = []string{"Example_com"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.network.devices[0].searchDomains[0]: Invalid value: \"Example_com\": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+		},
 		{
 			testCase: "with too few CPUs provided",
 			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
@@ -2159,6 +2286,102 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			expectedOk:       true,
 			expectedWarnings: []string{"providerSpec.diskGiB: 0 is missing or less than the recommended minimum (120): nodes may fail to start if disk size is too low"},
 		},
+		{
+			testCase: "with valid data disks",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{
+					{
+						Name:    "containers",
+						SizeGiB: 100,
+					},
+					{
+						Name:                "logs",
+						SizeGiB:             10,
+						Datastore:           "datastore",
+						ProvisioningType:    vsphere.EagerlyZeroedProvisioned,
+						ControllerBusNumber: pointer.Int32Ptr(0),
+						UnitNumber:          pointer.Int32Ptr(2),
+					},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with no data disk name provided",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{{SizeGiB: 10}}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks[0].name: Required value: name must be provided",
+		},
+		{
+			testCase: "with duplicated data disk names",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{
+					{Name: "logs", SizeGiB: 10},
+					{Name: "logs", SizeGiB: 10},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks[1].name: Duplicate value: \"logs\"",
+		},
+		{
+			testCase: "with no data disk size provided",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{{Name: "logs"}}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks[0].sizeGiB: Invalid value: 0: sizeGiB must be greater than 0",
+		},
+		{
+			testCase: "with an invalid data disk provisioning type",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{{Name: "logs", SizeGiB: 10, ProvisioningType: "lazy"}}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks[0].provisioningType: Unsupported value: \"lazy\": supported values: \"thin\", \"thick\", \"eagerlyZeroed\"",
+		},
+		{
+			testCase: "with a data disk unit number out of range",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.DataDisks = []vsphere.VSphereDisk{{Name: "logs", SizeGiB: 10, UnitNumber: pointer.Int32Ptr(16)}}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks[0].unitNumber: Invalid value: 16: unitNumber must be between 0 and 15",
+		},
+		{
+			testCase: "with an unsupported resize policy",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.ResizePolicy = "Always"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.resizePolicy: Unsupported value: \"Always\": supported values: \"None\", \"HotAdd\", \"Restart\"",
+		},
+		{
+			testCase: "with a content library item instead of a template",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Template = ""
+				p.ContentLibraryItem = &vsphere.ContentLibraryItem{Library: "library", Item: "rhcos"}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with both a template and a content library item",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.ContentLibraryItem = &vsphere.ContentLibraryItem{Item: "rhcos"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.template: Forbidden: template and contentLibraryItem are mutually exclusive",
+		},
+		{
+			testCase: "with no content library item name provided",
+			modifySpec: func(p *vsphere.VSphereMachineProviderSpec) {
+				p.Template = ""
+				p.ContentLibraryItem = &vsphere.ContentLibraryItem{Library: "library"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.contentLibraryItem.item: Required value: item must be provided",
+		},
 	}
 
 	secret := &corev1.Secret{
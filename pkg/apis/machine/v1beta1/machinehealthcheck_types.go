@@ -9,6 +9,27 @@ import (
 // RemediationStrategyType contains remediation strategy type
 type RemediationStrategyType string
 
+// UnownedMachineRemediationType defines how machines without a controller owner are remediated
+type UnownedMachineRemediationType string
+
+// UnhealthyNodeMetadataType is the kind of node metadata an UnhealthyNodeMetadata refers to
+type UnhealthyNodeMetadataType string
+
+const (
+	// UnownedMachineRemediationSkip skips remediation of machines without a controller owner
+	UnownedMachineRemediationSkip UnownedMachineRemediationType = "Skip"
+	// UnownedMachineRemediationReplace remediates machines without a controller owner by
+	// creating a replacement machine and deleting the unhealthy one once the replacement is running
+	UnownedMachineRemediationReplace UnownedMachineRemediationType = "Replace"
+)
+
+const (
+	// UnhealthyNodeLabel matches a label of the node
+	UnhealthyNodeLabel UnhealthyNodeMetadataType = "Label"
+	// UnhealthyNodeAnnotation matches an annotation of the node
+	UnhealthyNodeAnnotation UnhealthyNodeMetadataType = "Annotation"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -56,20 +77,49 @@ type MachineHealthCheckSpec struct {
 	// UnhealthyConditions contains a list of the conditions that determine
 	// whether a node is considered unhealthy.  The conditions are combined in a
 	// logical OR, i.e. if any of the conditions is met, the node is unhealthy.
-	//
-	// +kubebuilder:validation:MinItems=1
-	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions"`
+	// At least one unhealthy condition, taint, node metadata or probe must be set.
+	// +optional
+	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions,omitempty"`
+
+	// UnhealthyTaints contains a list of node taints that determine whether a
+	// node is considered unhealthy. They are combined with the unhealthy conditions
+	// in a logical OR, i.e. if any of them is met, the node is unhealthy.
+	// +optional
+	UnhealthyTaints []UnhealthyTaint `json:"unhealthyTaints,omitempty"`
+
+	// UnhealthyNodeMetadata contains a list of node labels and annotations
+	// whose presence marks a node as unhealthy. They are combined with the
+	// unhealthy conditions in a logical OR.
+	// +optional
+	UnhealthyNodeMetadata []UnhealthyNodeMetadata `json:"unhealthyNodeMetadata,omitempty"`
+
+	// UnhealthyProbes contains a list of probe pods, for example run by a DaemonSet,
+	// which report the health of the node they run on through their Ready condition.
+	// They are combined with the unhealthy conditions in a logical OR.
+	// +optional
+	UnhealthyProbes []UnhealthyProbe `json:"unhealthyProbes,omitempty"`
 
 	// Any farther remediation is only allowed if at most "MaxUnhealthy" machines selected by
 	// "selector" are not healthy.
 	// Expects either a postive integer value or a percentage value.
 	// Percentage values must be positive whole numbers and are capped at 100%.
 	// Both 0 and 0% are valid and will block all remediation.
-	// +kubebuilder:default:="100%"
+	// Mutually exclusive with "UnhealthyRange". Defaults to 100% when
+	// "UnhealthyRange" is not set.
 	// +kubebuilder:validation:Pattern="^((100|[0-9]{1,2})%|[0-9]+)$"
 	// +kubebuilder:validation:Type:=string
+	// +optional
 	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
 
+	// Any farther remediation is only allowed if the number of machines selected by
+	// "selector" which are not healthy is within the inclusive range of "UnhealthyRange".
+	// Eg. "[1-3]" means that remediation is only allowed when at least 1 and
+	// at most 3 machines are not healthy.
+	// Mutually exclusive with "MaxUnhealthy".
+	// +kubebuilder:validation:Pattern="^\\[[0-9]+-[0-9]+\\]$"
+	// +optional
+	UnhealthyRange string `json:"unhealthyRange,omitempty"`
+
 	// Machines older than this duration without a node will be considered to have
 	// failed and will be remediated.
 	// Expects an unsigned duration string of decimal numbers each with optional
@@ -80,6 +130,52 @@ type MachineHealthCheckSpec struct {
 	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
 	// +kubebuilder:validation:Type:=string
 	NodeStartupTimeout metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+
+	// Priority is used to decide which MachineHealthCheck remediates a Machine
+	// when the selectors of several MachineHealthChecks match it.
+	// Only the MachineHealthChecks with the highest priority are allowed to
+	// remediate such a Machine, the others skip it.
+	// MachineHealthChecks with equal priorities do not restrict each other.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// UnownedMachineRemediation defines how unhealthy machines without a controller
+	// owner, such as control plane machines, are remediated.
+	// "Skip" leaves them untouched. "Replace" creates a new machine from the spec of
+	// the unhealthy one and deletes the unhealthy machine once the new one is running.
+	// Defaults to "Skip".
+	// +kubebuilder:validation:Enum=Skip;Replace
+	// +optional
+	UnownedMachineRemediation UnownedMachineRemediationType `json:"unownedMachineRemediation,omitempty"`
+
+	// RemediationWindows restricts remediation to recurring windows of time.
+	// Unhealthy machines detected outside of them are reported and only
+	// remediated once the next window opens.
+	// When empty, remediation is allowed at any time.
+	// +optional
+	RemediationWindows []RemediationWindow `json:"remediationWindows,omitempty"`
+}
+
+// RemediationWindow is a recurring window of time during which remediation is allowed
+type RemediationWindow struct {
+	// Schedule is a cron expression with the five fields
+	// "minute hour day-of-month month day-of-week" marking the start of the window,
+	// eg "0 2 * * 6" for every Saturday at 2am.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open once it started.
+	// Expects an unsigned duration string of decimal numbers each with optional
+	// fraction and a unit suffix, eg "300ms", "1.5h" or "2h45m".
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the name of the IANA time zone the schedule is evaluated in,
+	// eg "Europe/Berlin". Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // UnhealthyCondition represents a Node condition type and value with a timeout
@@ -102,6 +198,64 @@ type UnhealthyCondition struct {
 	Timeout metav1.Duration `json:"timeout"`
 }
 
+// UnhealthyTaint represents a Node taint with a timeout specified as a duration.
+// When a matching taint has been present on a node for at least the timeout
+// value, the node is considered unhealthy.
+type UnhealthyTaint struct {
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Value of the taint to match. An empty value matches any value.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// +kubebuilder:validation:Enum=NoSchedule;PreferNoSchedule;NoExecute
+	Effect corev1.TaintEffect `json:"effect"`
+
+	// The time a taint was added is only known for NoExecute taints, which
+	// carry a timeAdded timestamp. Any other taint marks the node unhealthy
+	// as soon as it is present, regardless of the timeout.
+	// Expects an unsigned duration string of decimal numbers each with optional
+	// fraction and a unit suffix, eg "300ms", "1.5h" or "2h45m".
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+// UnhealthyNodeMetadata represents a Node label or annotation. As soon as it is
+// present on a node, the node is considered unhealthy.
+type UnhealthyNodeMetadata struct {
+	// +kubebuilder:validation:Enum=Label;Annotation
+	Type UnhealthyNodeMetadataType `json:"type"`
+
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Value of the label or annotation to match. An empty value matches any value.
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// UnhealthyProbe selects probe pods and a timeout specified as a duration.
+// When the probe pod scheduled to a node has not been Ready for at least the
+// timeout value, the node is considered unhealthy.
+type UnhealthyProbe struct {
+	// Namespace the probe pods run in.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Label selector to match the probe pods.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// Expects an unsigned duration string of decimal numbers each with optional
+	// fraction and a unit suffix, eg "300ms", "1.5h" or "2h45m".
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +kubebuilder:validation:Type:=string
+	Timeout metav1.Duration `json:"timeout"`
+}
+
 // MachineHealthCheckStatus defines the observed state of MachineHealthCheck
 type MachineHealthCheckStatus struct {
 	// total number of machines counted by this machine health check
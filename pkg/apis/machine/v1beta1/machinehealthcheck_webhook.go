@@ -0,0 +1,391 @@
+package v1beta1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1/cron"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MachineHealthCheck Defaults
+	defaultMachineHealthCheckMaxUnhealthy       = "100%"
+	defaultMachineHealthCheckNodeStartupTimeout = 10 * time.Minute
+	// Anything shorter than this gives a machine no realistic chance to boot and join the cluster
+	minMachineHealthCheckNodeStartupTimeout = 30 * time.Second
+
+	remediationStrategyAnnotation = "machine.openshift.io/remediation-strategy"
+	remediationStrategyExternal   = RemediationStrategyType("external-baremetal")
+)
+
+// machineHealthCheckValidatorHandler validates MachineHealthCheck API resources.
+// implements type Handler interface.
+// https://godoc.org/github.com/kubernetes-sigs/controller-runtime/pkg/webhook/admission#Handler
+type machineHealthCheckValidatorHandler struct {
+	*admissionHandler
+}
+
+// machineHealthCheckDefaulterHandler defaults MachineHealthCheck API resources.
+// implements type Handler interface.
+// https://godoc.org/github.com/kubernetes-sigs/controller-runtime/pkg/webhook/admission#Handler
+type machineHealthCheckDefaulterHandler struct {
+	*admissionHandler
+}
+
+// NewMachineHealthCheckValidator returns a new machineHealthCheckValidatorHandler.
+func NewMachineHealthCheckValidator() *machineHealthCheckValidatorHandler {
+	return &machineHealthCheckValidatorHandler{
+		admissionHandler: &admissionHandler{
+			admissionConfig: &admissionConfig{},
+		},
+	}
+}
+
+// NewMachineHealthCheckDefaulter returns a new machineHealthCheckDefaulterHandler.
+func NewMachineHealthCheckDefaulter() *machineHealthCheckDefaulterHandler {
+	return &machineHealthCheckDefaulterHandler{
+		admissionHandler: &admissionHandler{
+			admissionConfig: &admissionConfig{},
+		},
+	}
+}
+
+// Handle handles HTTP requests for admission webhook servers.
+func (h *machineHealthCheckValidatorHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	mhc := &MachineHealthCheck{}
+
+	if err := h.decoder.Decode(req, mhc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	klog.V(3).Infof("Validate webhook called for MachineHealthCheck: %s", mhc.GetName())
+
+	// The old object is only set on updates
+	var oldMHC *MachineHealthCheck
+	if len(req.OldObject.Raw) > 0 {
+		oldMHC = &MachineHealthCheck{}
+		if err := h.decoder.DecodeRaw(req.OldObject, oldMHC); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	ok, warnings, errs := validateMachineHealthCheck(mhc, oldMHC)
+	if !ok {
+		return admission.Denied(errs.Error()).WithWarnings(warnings...)
+	}
+
+	return admission.Allowed("MachineHealthCheck valid").WithWarnings(warnings...)
+}
+
+// Handle handles HTTP requests for admission webhook servers.
+func (h *machineHealthCheckDefaulterHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	mhc := &MachineHealthCheck{}
+
+	if err := h.decoder.Decode(req, mhc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	klog.V(3).Infof("Mutate webhook called for MachineHealthCheck: %s", mhc.GetName())
+
+	ok, warnings, errs := defaultMachineHealthCheck(mhc)
+	if !ok {
+		return admission.Denied(errs.Error()).WithWarnings(warnings...)
+	}
+
+	marshaledMachineHealthCheck, err := json.Marshal(mhc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err).WithWarnings(warnings...)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledMachineHealthCheck).WithWarnings(warnings...)
+}
+
+func defaultMachineHealthCheck(mhc *MachineHealthCheck) (bool, []string, utilerrors.Aggregate) {
+	klog.V(3).Infof("Defaulting MachineHealthCheck spec")
+
+	// maxUnhealthy and unhealthyRange are mutually exclusive
+	if mhc.Spec.MaxUnhealthy == nil && mhc.Spec.UnhealthyRange == "" {
+		maxUnhealthy := intstr.FromString(defaultMachineHealthCheckMaxUnhealthy)
+		mhc.Spec.MaxUnhealthy = &maxUnhealthy
+	}
+
+	if mhc.Spec.NodeStartupTimeout.Duration == 0 {
+		mhc.Spec.NodeStartupTimeout = metav1.Duration{Duration: defaultMachineHealthCheckNodeStartupTimeout}
+	}
+
+	return true, []string{}, nil
+}
+
+// validateMachineHealthCheck validates the MachineHealthCheck. On updates, oldMHC is the
+// MachineHealthCheck being updated, and nil otherwise.
+func validateMachineHealthCheck(mhc, oldMHC *MachineHealthCheck) (bool, []string, utilerrors.Aggregate) {
+	klog.V(3).Infof("Validating MachineHealthCheck spec")
+
+	var errs []error
+	var warnings []string
+	specPath := field.NewPath("spec")
+
+	// MachineHealthChecks with an empty selector or a short node startup timeout were
+	// accepted before, so they can still be updated as long as they already were invalid
+	ratchet := func(validate func(*MachineHealthCheck) []error) {
+		newErrs := validate(mhc)
+		if len(newErrs) == 0 {
+			return
+		}
+		if oldMHC == nil || len(validate(oldMHC)) == 0 {
+			errs = append(errs, newErrs...)
+			return
+		}
+		for _, err := range newErrs {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	ratchet(func(m *MachineHealthCheck) []error {
+		return validateMachineHealthCheckSelector(m.Spec.Selector, specPath.Child("selector"))
+	})
+	if len(mhc.Spec.UnhealthyConditions) == 0 && len(mhc.Spec.UnhealthyTaints) == 0 &&
+		len(mhc.Spec.UnhealthyNodeMetadata) == 0 && len(mhc.Spec.UnhealthyProbes) == 0 {
+		errs = append(errs, field.Required(specPath.Child("unhealthyConditions"),
+			"at least 1 unhealthy condition, taint, node metadata or probe must be provided"))
+	}
+	errs = append(errs, validateMachineHealthCheckUnhealthyConditions(mhc.Spec.UnhealthyConditions, specPath.Child("unhealthyConditions"))...)
+	errs = append(errs, validateMachineHealthCheckUnhealthyTaints(mhc.Spec.UnhealthyTaints, specPath.Child("unhealthyTaints"))...)
+	errs = append(errs, validateMachineHealthCheckUnhealthyNodeMetadata(mhc.Spec.UnhealthyNodeMetadata, specPath.Child("unhealthyNodeMetadata"))...)
+	errs = append(errs, validateMachineHealthCheckUnhealthyProbes(mhc.Spec.UnhealthyProbes, specPath.Child("unhealthyProbes"))...)
+	errs = append(errs, validateMachineHealthCheckRemediationWindows(mhc.Spec.RemediationWindows, specPath.Child("remediationWindows"))...)
+
+	if mhc.Spec.MaxUnhealthy != nil {
+		if err := validateMachineHealthCheckMaxUnhealthy(mhc.Spec.MaxUnhealthy, specPath.Child("maxUnhealthy")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if mhc.Spec.UnhealthyRange != "" {
+		if mhc.Spec.MaxUnhealthy != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("unhealthyRange"), "unhealthyRange and maxUnhealthy are mutually exclusive"))
+		}
+		if err := validateMachineHealthCheckUnhealthyRange(mhc.Spec.UnhealthyRange, specPath.Child("unhealthyRange")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch mhc.Spec.UnownedMachineRemediation {
+	case "", UnownedMachineRemediationSkip, UnownedMachineRemediationReplace:
+	default:
+		errs = append(errs, field.NotSupported(specPath.Child("unownedMachineRemediation"), mhc.Spec.UnownedMachineRemediation,
+			[]string{string(UnownedMachineRemediationSkip), string(UnownedMachineRemediationReplace)}))
+	}
+
+	ratchet(func(m *MachineHealthCheck) []error {
+		return validateMachineHealthCheckNodeStartupTimeout(m.Spec.NodeStartupTimeout, specPath.Child("nodeStartupTimeout"))
+	})
+
+	if strategy, ok := mhc.Annotations[remediationStrategyAnnotation]; ok {
+		if RemediationStrategyType(strategy) != remediationStrategyExternal {
+			errs = append(errs, field.NotSupported(field.NewPath("metadata", "annotations").Key(remediationStrategyAnnotation),
+				strategy, []string{string(remediationStrategyExternal)}))
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, warnings, utilerrors.NewAggregate(errs)
+	}
+	return true, warnings, nil
+}
+
+func validateMachineHealthCheckSelector(selector metav1.LabelSelector, parentPath *field.Path) []error {
+	s, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return []error{field.Invalid(parentPath, selector, fmt.Sprintf("invalid label selector: %v", err))}
+	}
+
+	if s.Empty() {
+		return []error{field.Required(parentPath, "selector must not be empty: an empty selector matches all machines, including control plane machines")}
+	}
+
+	return []error{}
+}
+
+func validateMachineHealthCheckNodeStartupTimeout(nodeStartupTimeout metav1.Duration, parentPath *field.Path) []error {
+	if nodeStartupTimeout.Duration < minMachineHealthCheckNodeStartupTimeout {
+		return []error{field.Invalid(parentPath, nodeStartupTimeout.Duration.String(),
+			fmt.Sprintf("must be at least %v", minMachineHealthCheckNodeStartupTimeout))}
+	}
+
+	return []error{}
+}
+
+func validateMachineHealthCheckUnhealthyConditions(unhealthyConditions []UnhealthyCondition, parentPath *field.Path) []error {
+	var errs []error
+	validStatuses := []string{string(corev1.ConditionTrue), string(corev1.ConditionFalse), string(corev1.ConditionUnknown)}
+	for i, c := range unhealthyConditions {
+		fldPath := parentPath.Index(i)
+
+		if c.Type == "" {
+			errs = append(errs, field.Required(fldPath.Child("type"), "type must be provided"))
+		}
+
+		switch c.Status {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("status"), c.Status, validStatuses))
+		}
+
+		if c.Timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("timeout"), c.Timeout.Duration.String(), "timeout must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+func validateMachineHealthCheckUnhealthyTaints(unhealthyTaints []UnhealthyTaint, parentPath *field.Path) []error {
+	var errs []error
+	validEffects := []string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}
+	for i, t := range unhealthyTaints {
+		fldPath := parentPath.Index(i)
+
+		if t.Key == "" {
+			errs = append(errs, field.Required(fldPath.Child("key"), "key must be provided"))
+		}
+
+		switch t.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("effect"), t.Effect, validEffects))
+		}
+
+		if t.Timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("timeout"), t.Timeout.Duration.String(), "timeout must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+func validateMachineHealthCheckUnhealthyNodeMetadata(unhealthyNodeMetadata []UnhealthyNodeMetadata, parentPath *field.Path) []error {
+	var errs []error
+	validTypes := []string{string(UnhealthyNodeLabel), string(UnhealthyNodeAnnotation)}
+	for i, m := range unhealthyNodeMetadata {
+		fldPath := parentPath.Index(i)
+
+		switch m.Type {
+		case UnhealthyNodeLabel, UnhealthyNodeAnnotation:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("type"), m.Type, validTypes))
+		}
+
+		if m.Key == "" {
+			errs = append(errs, field.Required(fldPath.Child("key"), "key must be provided"))
+		}
+	}
+
+	return errs
+}
+
+func validateMachineHealthCheckUnhealthyProbes(unhealthyProbes []UnhealthyProbe, parentPath *field.Path) []error {
+	var errs []error
+	for i, p := range unhealthyProbes {
+		fldPath := parentPath.Index(i)
+
+		if p.Namespace == "" {
+			errs = append(errs, field.Required(fldPath.Child("namespace"), "namespace must be provided"))
+		}
+
+		s, err := metav1.LabelSelectorAsSelector(&p.Selector)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("selector"), p.Selector, fmt.Sprintf("invalid label selector: %v", err)))
+		} else if s.Empty() {
+			errs = append(errs, field.Required(fldPath.Child("selector"), "selector must not be empty: an empty selector matches all pods in the namespace"))
+		}
+
+		if p.Timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("timeout"), p.Timeout.Duration.String(), "timeout must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+func validateMachineHealthCheckRemediationWindows(remediationWindows []RemediationWindow, parentPath *field.Path) []error {
+	var errs []error
+	for i, w := range remediationWindows {
+		fldPath := parentPath.Index(i)
+
+		if _, err := cron.Parse(w.Schedule); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("schedule"), w.Schedule, fmt.Sprintf("invalid cron schedule: %v", err)))
+		}
+
+		if w.Duration.Duration <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("duration"), w.Duration.Duration.String(), "duration must be positive"))
+		}
+
+		if w.TimeZone != "" {
+			if _, err := time.LoadLocation(w.TimeZone); err != nil {
+				errs = append(errs, field.Invalid(fldPath.Child("timeZone"), w.TimeZone, fmt.Sprintf("unknown time zone: %v", err)))
+			}
+		}
+	}
+
+	return errs
+}
+
+func validateMachineHealthCheckUnhealthyRange(unhealthyRange string, fldPath *field.Path) error {
+	if !strings.HasPrefix(unhealthyRange, "[") || !strings.HasSuffix(unhealthyRange, "]") {
+		return field.Invalid(fldPath, unhealthyRange, "must be of the format [min-max]")
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(unhealthyRange, "["), "]"), "-")
+	if len(parts) != 2 {
+		return field.Invalid(fldPath, unhealthyRange, "must be of the format [min-max]")
+	}
+
+	min, minErr := strconv.Atoi(parts[0])
+	max, maxErr := strconv.Atoi(parts[1])
+	if minErr != nil || maxErr != nil || min < 0 {
+		return field.Invalid(fldPath, unhealthyRange, "must be of the format [min-max] with non-negative integers")
+	}
+	if max < min {
+		return field.Invalid(fldPath, unhealthyRange, "max must not be less than min")
+	}
+
+	return nil
+}
+
+func validateMachineHealthCheckMaxUnhealthy(maxUnhealthy *intstr.IntOrString, fldPath *field.Path) error {
+	switch maxUnhealthy.Type {
+	case intstr.Int:
+		if maxUnhealthy.IntVal < 0 {
+			return field.Invalid(fldPath, maxUnhealthy.IntVal, "must not be negative")
+		}
+	case intstr.String:
+		s := maxUnhealthy.StrVal
+		isPercent := strings.HasSuffix(s, "%")
+		value, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		if err != nil {
+			return field.Invalid(fldPath, s, "must be either an integer or a percentage")
+		}
+		if value < 0 {
+			return field.Invalid(fldPath, s, "must not be negative")
+		}
+		if isPercent && value > 100 {
+			return field.Invalid(fldPath, s, "percentage must not be greater than 100%")
+		}
+	default:
+		return field.Invalid(fldPath, maxUnhealthy.String(), "must be either an integer or a percentage")
+	}
+
+	return nil
+}
@@ -0,0 +1,477 @@
+package v1beta1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidateMachineHealthCheck(t *testing.T) {
+	testCases := []struct {
+		testCase         string
+		modifyMHC        func(*MachineHealthCheck)
+		expectedError    string
+		expectedOk       bool
+		expectedWarnings []string
+	}{
+		{
+			testCase:   "with a valid MachineHealthCheck",
+			expectedOk: true,
+		},
+		{
+			testCase: "with an empty selector",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.Selector = metav1.LabelSelector{}
+			},
+			expectedOk:    false,
+			expectedError: "spec.selector: Required value: selector must not be empty: an empty selector matches all machines, including control plane machines",
+		},
+		{
+			testCase: "with an invalid selector",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.Selector = metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "foo",
+							Operator: "Bogus",
+						},
+					},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "spec.selector: Invalid value: v1.LabelSelector{MatchLabels:map[string]string(nil), MatchExpressions:[]v1.LabelSelectorRequirement{v1.LabelSelectorRequirement{Key:\"foo\", Operator:\"Bogus\", Values:[]string(nil)}}}: invalid label selector: \"Bogus\" is not a valid pod selector operator",
+		},
+		{
+			testCase: "with no unhealthy conditions, taints, node metadata or probes",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyConditions = nil
+			},
+			expectedOk:    false,
+			expectedError: "spec.unhealthyConditions: Required value: at least 1 unhealthy condition, taint, node metadata or probe must be provided",
+		},
+		{
+			testCase: "with only unhealthy taints",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyConditions = nil
+				mhc.Spec.UnhealthyTaints = []UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an invalid unhealthy condition",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyConditions = []UnhealthyCondition{
+					{
+						Status:  "Maybe",
+						Timeout: metav1.Duration{Duration: -time.Minute},
+					},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "[spec.unhealthyConditions[0].type: Required value: type must be provided, spec.unhealthyConditions[0].status: Unsupported value: \"Maybe\": supported values: \"True\", \"False\", \"Unknown\", spec.unhealthyConditions[0].timeout: Invalid value: \"-1m0s\": timeout must not be negative]",
+		},
+		{
+			testCase: "with valid unhealthy taints, node metadata and probes",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyTaints = []UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+				mhc.Spec.UnhealthyNodeMetadata = []UnhealthyNodeMetadata{
+					{Type: UnhealthyNodeLabel, Key: "example.com/broken"},
+				}
+				mhc.Spec.UnhealthyProbes = []UnhealthyProbe{
+					{
+						Namespace: "probes",
+						Selector:  metav1.LabelSelector{MatchLabels: map[string]string{"app": "probe"}},
+						Timeout:   metav1.Duration{Duration: time.Minute},
+					},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an invalid unhealthy taint",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyTaints = []UnhealthyTaint{
+					{Effect: "Evict"},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "[spec.unhealthyTaints[0].key: Required value: key must be provided, spec.unhealthyTaints[0].effect: Unsupported value: \"Evict\": supported values: \"NoSchedule\", \"PreferNoSchedule\", \"NoExecute\"]",
+		},
+		{
+			testCase: "with invalid unhealthy node metadata",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyNodeMetadata = []UnhealthyNodeMetadata{
+					{Type: "Taint"},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "[spec.unhealthyNodeMetadata[0].type: Unsupported value: \"Taint\": supported values: \"Label\", \"Annotation\", spec.unhealthyNodeMetadata[0].key: Required value: key must be provided]",
+		},
+		{
+			testCase: "with an invalid unhealthy probe",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyProbes = []UnhealthyProbe{
+					{Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "[spec.unhealthyProbes[0].namespace: Required value: namespace must be provided, spec.unhealthyProbes[0].selector: Required value: selector must not be empty: an empty selector matches all pods in the namespace]",
+		},
+		{
+			testCase: "with a negative maxUnhealthy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				maxUnhealthy := intstr.FromInt(-1)
+				mhc.Spec.MaxUnhealthy = &maxUnhealthy
+			},
+			expectedOk:    false,
+			expectedError: "spec.maxUnhealthy: Invalid value: -1: must not be negative",
+		},
+		{
+			testCase: "with a negative percentage maxUnhealthy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				maxUnhealthy := intstr.FromString("-10%")
+				mhc.Spec.MaxUnhealthy = &maxUnhealthy
+			},
+			expectedOk:    false,
+			expectedError: "spec.maxUnhealthy: Invalid value: \"-10%\": must not be negative",
+		},
+		{
+			testCase: "with a percentage maxUnhealthy above 100%",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				maxUnhealthy := intstr.FromString("101%")
+				mhc.Spec.MaxUnhealthy = &maxUnhealthy
+			},
+			expectedOk:    false,
+			expectedError: "spec.maxUnhealthy: Invalid value: \"101%\": percentage must not be greater than 100%",
+		},
+		{
+			testCase: "with a malformed maxUnhealthy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				maxUnhealthy := intstr.FromString("ten")
+				mhc.Spec.MaxUnhealthy = &maxUnhealthy
+			},
+			expectedOk:    false,
+			expectedError: "spec.maxUnhealthy: Invalid value: \"ten\": must be either an integer or a percentage",
+		},
+		{
+			testCase: "with an unhealthyRange",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.MaxUnhealthy = nil
+				mhc.Spec.UnhealthyRange = "[1-3]"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with both unhealthyRange and maxUnhealthy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnhealthyRange = "[1-3]"
+			},
+			expectedOk:    false,
+			expectedError: "spec.unhealthyRange: Forbidden: unhealthyRange and maxUnhealthy are mutually exclusive",
+		},
+		{
+			testCase: "with a malformed unhealthyRange",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.MaxUnhealthy = nil
+				mhc.Spec.UnhealthyRange = "1-3"
+			},
+			expectedOk:    false,
+			expectedError: "spec.unhealthyRange: Invalid value: \"1-3\": must be of the format [min-max]",
+		},
+		{
+			testCase: "with an unhealthyRange with non integer bounds",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.MaxUnhealthy = nil
+				mhc.Spec.UnhealthyRange = "[a-3]"
+			},
+			expectedOk:    false,
+			expectedError: "spec.unhealthyRange: Invalid value: \"[a-3]\": must be of the format [min-max] with non-negative integers",
+		},
+		{
+			testCase: "with an inverted unhealthyRange",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.MaxUnhealthy = nil
+				mhc.Spec.UnhealthyRange = "[3-1]"
+			},
+			expectedOk:    false,
+			expectedError: "spec.unhealthyRange: Invalid value: \"[3-1]\": max must not be less than min",
+		},
+		{
+			testCase: "with a zero nodeStartupTimeout",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.NodeStartupTimeout = metav1.Duration{}
+			},
+			expectedOk:    false,
+			expectedError: "spec.nodeStartupTimeout: Invalid value: \"0s\": must be at least 30s",
+		},
+		{
+			testCase: "with replacement of unowned machines",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnownedMachineRemediation = UnownedMachineRemediationReplace
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an unknown unowned machine remediation",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.UnownedMachineRemediation = "Reboot"
+			},
+			expectedOk:    false,
+			expectedError: "spec.unownedMachineRemediation: Unsupported value: \"Reboot\": supported values: \"Skip\", \"Replace\"",
+		},
+		{
+			testCase: "with remediation windows",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.RemediationWindows = []RemediationWindow{
+					{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+					{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "UTC"},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an invalid remediation window",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.RemediationWindows = []RemediationWindow{
+					{Schedule: "0 25 * * *", TimeZone: "Nowhere/Special"},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "[spec.remediationWindows[0].schedule: Invalid value: \"0 25 * * *\": invalid cron schedule: invalid hour 25: must be between 0 and 23, spec.remediationWindows[0].duration: Invalid value: \"0s\": duration must be positive, spec.remediationWindows[0].timeZone: Invalid value: \"Nowhere/Special\": unknown time zone: unknown time zone Nowhere/Special]",
+		},
+		{
+			testCase: "with an external remediation strategy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Annotations = map[string]string{
+					remediationStrategyAnnotation: string(remediationStrategyExternal),
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an unknown remediation strategy",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Annotations = map[string]string{
+					remediationStrategyAnnotation: "reboot",
+				}
+			},
+			expectedOk:    false,
+			expectedError: "metadata.annotations[machine.openshift.io/remediation-strategy]: Unsupported value: \"reboot\": supported values: \"external-baremetal\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			maxUnhealthy := intstr.FromString("40%")
+			mhc := &MachineHealthCheck{
+				Spec: MachineHealthCheckSpec{
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"foo": "bar",
+						},
+					},
+					UnhealthyConditions: []UnhealthyCondition{
+						{
+							Type:    corev1.NodeReady,
+							Status:  corev1.ConditionUnknown,
+							Timeout: metav1.Duration{Duration: 5 * time.Minute},
+						},
+					},
+					MaxUnhealthy:       &maxUnhealthy,
+					NodeStartupTimeout: metav1.Duration{Duration: 10 * time.Minute},
+				},
+			}
+			if tc.modifyMHC != nil {
+				tc.modifyMHC(mhc)
+			}
+
+			ok, warnings, err := validateMachineHealthCheck(mhc, nil)
+			if ok != tc.expectedOk {
+				t.Errorf("expected: %v, got: %v", tc.expectedOk, ok)
+			}
+
+			if err == nil {
+				if tc.expectedError != "" {
+					t.Errorf("expected: %q, got: %v", tc.expectedError, err)
+				}
+			} else {
+				if err.Error() != tc.expectedError {
+					t.Errorf("expected: %q, got: %q", tc.expectedError, err.Error())
+				}
+			}
+
+			if !reflect.DeepEqual(warnings, tc.expectedWarnings) {
+				t.Errorf("expected: %q, got: %q", tc.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateMachineHealthCheckUpdate(t *testing.T) {
+	emptySelectorError := "spec.selector: Required value: selector must not be empty: an empty selector matches all machines, including control plane machines"
+	shortTimeoutError := "spec.nodeStartupTimeout: Invalid value: \"10s\": must be at least 30s"
+
+	testCases := []struct {
+		testCase         string
+		modifyOldMHC     func(*MachineHealthCheck)
+		modifyMHC        func(*MachineHealthCheck)
+		expectedError    string
+		expectedOk       bool
+		expectedWarnings []string
+	}{
+		{
+			testCase: "with an empty selector already set",
+			modifyOldMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.Selector = metav1.LabelSelector{}
+			},
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.Selector = metav1.LabelSelector{}
+				mhc.Labels = map[string]string{"foo": "bar"}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{emptySelectorError},
+		},
+		{
+			testCase: "with the selector changed to empty",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.Selector = metav1.LabelSelector{}
+			},
+			expectedOk:    false,
+			expectedError: emptySelectorError,
+		},
+		{
+			testCase: "with a short node startup timeout already set",
+			modifyOldMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.NodeStartupTimeout = metav1.Duration{Duration: 10 * time.Second}
+			},
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.NodeStartupTimeout = metav1.Duration{Duration: 10 * time.Second}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{shortTimeoutError},
+		},
+		{
+			testCase: "with the node startup timeout changed to a short one",
+			modifyMHC: func(mhc *MachineHealthCheck) {
+				mhc.Spec.NodeStartupTimeout = metav1.Duration{Duration: 10 * time.Second}
+			},
+			expectedOk:    false,
+			expectedError: shortTimeoutError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			newMHC := func(modify func(*MachineHealthCheck)) *MachineHealthCheck {
+				mhc := &MachineHealthCheck{
+					Spec: MachineHealthCheckSpec{
+						Selector: metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"foo": "bar",
+							},
+						},
+						UnhealthyConditions: []UnhealthyCondition{
+							{
+								Type:    corev1.NodeReady,
+								Status:  corev1.ConditionUnknown,
+								Timeout: metav1.Duration{Duration: 5 * time.Minute},
+							},
+						},
+						NodeStartupTimeout: metav1.Duration{Duration: 10 * time.Minute},
+					},
+				}
+				if modify != nil {
+					modify(mhc)
+				}
+				return mhc
+			}
+
+			ok, warnings, err := validateMachineHealthCheck(newMHC(tc.modifyMHC), newMHC(tc.modifyOldMHC))
+			if ok != tc.expectedOk {
+				t.Errorf("expected: %v, got: %v", tc.expectedOk, ok)
+			}
+
+			if err == nil {
+				if tc.expectedError != "" {
+					t.Errorf("expected: %q, got: %v", tc.expectedError, err)
+				}
+			} else {
+				if err.Error() != tc.expectedError {
+					t.Errorf("expected: %q, got: %q", tc.expectedError, err.Error())
+				}
+			}
+
+			if !reflect.DeepEqual(warnings, tc.expectedWarnings) {
+				t.Errorf("expected: %q, got: %q", tc.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestDefaultMachineHealthCheck(t *testing.T) {
+	maxUnhealthy := intstr.FromInt(2)
+	defaultMaxUnhealthy := intstr.FromString(defaultMachineHealthCheckMaxUnhealthy)
+
+	testCases := []struct {
+		testCase     string
+		spec         MachineHealthCheckSpec
+		expectedSpec MachineHealthCheckSpec
+	}{
+		{
+			testCase: "it defaults defaultable fields",
+			spec:     MachineHealthCheckSpec{},
+			expectedSpec: MachineHealthCheckSpec{
+				MaxUnhealthy:       &defaultMaxUnhealthy,
+				NodeStartupTimeout: metav1.Duration{Duration: defaultMachineHealthCheckNodeStartupTimeout},
+			},
+		},
+		{
+			testCase: "it does not default maxUnhealthy when unhealthyRange is set",
+			spec: MachineHealthCheckSpec{
+				UnhealthyRange: "[1-3]",
+			},
+			expectedSpec: MachineHealthCheckSpec{
+				UnhealthyRange:     "[1-3]",
+				NodeStartupTimeout: metav1.Duration{Duration: defaultMachineHealthCheckNodeStartupTimeout},
+			},
+		},
+		{
+			testCase: "it does not overwrite provided fields",
+			spec: MachineHealthCheckSpec{
+				MaxUnhealthy:       &maxUnhealthy,
+				NodeStartupTimeout: metav1.Duration{Duration: time.Hour},
+			},
+			expectedSpec: MachineHealthCheckSpec{
+				MaxUnhealthy:       &maxUnhealthy,
+				NodeStartupTimeout: metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			mhc := &MachineHealthCheck{Spec: tc.spec}
+
+			ok, warnings, err := defaultMachineHealthCheck(mhc)
+			if !ok {
+				t.Errorf("expected defaulting to succeed, got: %v", err)
+			}
+			if len(warnings) != 0 {
+				t.Errorf("expected no warnings, got: %q", warnings)
+			}
+
+			if !equality.Semantic.DeepEqual(tc.expectedSpec, mhc.Spec) {
+				t.Errorf("expected: %+v, got: %+v", tc.expectedSpec, mhc.Spec)
+			}
+		})
+	}
+}
@@ -39,6 +39,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MachineList{},
 		&MachineSet{},
 		&MachineSetList{},
+		&IPAddressPool{},
+		&IPAddressPoolList{},
+		&IPAddressClaim{},
+		&IPAddressClaimList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -24,8 +24,19 @@ type VSphereMachineProviderSpec struct {
 
 	// Template is the name, inventory path, or instance UUID of the template
 	// used to clone new machines.
+	// Either Template or ContentLibraryItem must be set.
 	Template string `json:"template"`
 
+	// ContentLibraryItem is the content library item holding the template
+	// used to clone new machines, instead of an inventory template.
+	// The item is deployed once to a virtual machine in the workspace folder,
+	// which new machines are then cloned from. A snapshot is taken of that
+	// virtual machine so that linked clones remain possible. Virtual machines
+	// deployed from previous versions of the item are left in place, as
+	// machines may still be linked clones of them.
+	// +optional
+	ContentLibraryItem *ContentLibraryItem `json:"contentLibraryItem,omitempty"`
+
 	Workspace *Workspace `json:"workspace,omitempty"`
 
 	// Network is the network configuration for this machine's VM.
@@ -47,11 +58,27 @@ type VSphereMachineProviderSpec struct {
 	// machine is cloned.
 	// +optional
 	MemoryMiB int64 `json:"memoryMiB,omitempty"`
+	// ResizePolicy specifies how changes of NumCPUs, NumCoresPerSocket and
+	// MemoryMiB are applied to the virtual machine once it is created.
+	// Valid values are None, HotAdd and Restart. Defaults to None, in which
+	// case the differences are only reported in the provider status.
+	// +optional
+	ResizePolicy ResizePolicy `json:"resizePolicy,omitempty"`
 	// DiskGiB is the size of a virtual machine's disk, in GiB.
 	// Defaults to the analogue property value in the template from which this
 	// machine is cloned.
 	// +optional
 	DiskGiB int32 `json:"diskGiB,omitempty"`
+	// DataDisks are additional disks created empty and attached to the
+	// virtual machine when it is cloned, e.g. for container storage or logs.
+	// They are added for both linked and full clones.
+	// +optional
+	DataDisks []VSphereDisk `json:"dataDisks,omitempty"`
+	// Tags is a list of vSphere tags attached to the virtual machine, in
+	// addition to the tag of the cluster ID. Missing categories and tags are
+	// created. Tags removed from the list are detached from the virtual machine.
+	// +optional
+	Tags []VSphereTag `json:"tags,omitempty"`
 	// Snapshot is the name of the snapshot from which the VM was cloned
 	// +optional
 	Snapshot string `json:"snapshot"`
@@ -84,6 +111,94 @@ const (
 	LinkedClone CloneMode = "linkedClone"
 )
 
+// ContentLibraryItem identifies an OVF or VM template item of a content library.
+type ContentLibraryItem struct {
+	// Library is the name or ID of the content library holding the item.
+	// Defaults to searching the item in all content libraries.
+	// +optional
+	Library string `json:"library,omitempty"`
+
+	// Item is the name or ID of the content library item.
+	Item string `json:"item"`
+}
+
+// ResizePolicy is the policy applied to resize the CPUs and memory of an
+// existing virtual machine. A powered off virtual machine is resized by
+// any policy but None.
+type ResizePolicy string
+
+const (
+	// NoResize leaves the virtual machine unchanged.
+	NoResize ResizePolicy = "None"
+
+	// HotAddResize resizes a powered on virtual machine only when its CPUs
+	// or memory are increased and hot-add is enabled for them.
+	HotAddResize ResizePolicy = "HotAdd"
+
+	// RestartResize resizes with hot-add when possible. Otherwise the node
+	// of the machine is drained and the guest is shut down for the time of
+	// the resize, then the virtual machine is powered on again. A guest which
+	// does not shut down within 5 minutes is powered off.
+	RestartResize ResizePolicy = "Restart"
+)
+
+// ProvisioningType is the type of provisioning used for the backing file of a disk.
+type ProvisioningType string
+
+const (
+	// ThinProvisioned allocates the space of the disk on demand.
+	ThinProvisioned ProvisioningType = "thin"
+
+	// ThickProvisioned allocates the whole space of the disk when it is
+	// created and zeroes it on first write.
+	ThickProvisioned ProvisioningType = "thick"
+
+	// EagerlyZeroedProvisioned allocates the whole space of the disk and
+	// zeroes it when the disk is created.
+	EagerlyZeroedProvisioned ProvisioningType = "eagerlyZeroed"
+)
+
+// VSphereDisk defines an additional disk of a virtual machine.
+type VSphereDisk struct {
+	// Name identifies the disk in the provider status. It must be unique
+	// among the data disks of the machine.
+	Name string `json:"name"`
+
+	// SizeGiB is the size of the disk, in GiB.
+	SizeGiB int32 `json:"sizeGiB"`
+
+	// Datastore is the datastore in which the disk is created.
+	// Defaults to the datastore of the virtual machine.
+	// +optional
+	Datastore string `json:"datastore,omitempty"`
+
+	// ProvisioningType is the provisioning type of the disk.
+	// Valid values are thin, thick and eagerlyZeroed. Defaults to thin.
+	// +optional
+	ProvisioningType ProvisioningType `json:"provisioningType,omitempty"`
+
+	// ControllerBusNumber is the bus number of the SCSI controller of the
+	// template the disk is attached to.
+	// Defaults to the controller of the first disk of the template.
+	// +optional
+	ControllerBusNumber *int32 `json:"controllerBusNumber,omitempty"`
+
+	// UnitNumber is the unit number of the disk on its controller.
+	// Defaults to the first unit number which is not used by the template
+	// or a previous data disk.
+	// +optional
+	UnitNumber *int32 `json:"unitNumber,omitempty"`
+}
+
+// VSphereTag identifies a vSphere tag by its name and the name of its category.
+type VSphereTag struct {
+	// Category is the name of the category of the tag.
+	Category string `json:"category"`
+
+	// Name is the name of the tag.
+	Name string `json:"name"`
+}
+
 // NetworkSpec defines the virtual machine's network configuration.
 type NetworkSpec struct {
 	Devices []NetworkDeviceSpec `json:"devices"`
@@ -95,6 +210,38 @@ type NetworkDeviceSpec struct {
 	// NetworkName is the name of the vSphere network to which the device
 	// will be connected.
 	NetworkName string `json:"networkName"`
+
+	// IPAddrs is a list of static IPv4 or IPv6 addresses, in CIDR notation
+	// (e.g. 192.168.1.10/24), assigned to the device.
+	// The device relies on DHCP when no address is set.
+	// +optional
+	IPAddrs []string `json:"ipAddrs,omitempty"`
+
+	// IPAddressPool is a reference to an IPAddressPool in the namespace of
+	// the machine. A free address of the pool is claimed for the device
+	// before the machine is cloned, and released when it is deleted.
+	// The gateway and DNS configuration of the pool apply unless they are
+	// set on the device. It is mutually exclusive with IPAddrs.
+	// +optional
+	IPAddressPool *corev1.LocalObjectReference `json:"ipAddressPool,omitempty"`
+
+	// Gateway4 is the IPv4 gateway of the device.
+	// It requires an IPv4 address in IPAddrs.
+	// +optional
+	Gateway4 string `json:"gateway4,omitempty"`
+
+	// Gateway6 is the IPv6 gateway of the device.
+	// It requires an IPv6 address in IPAddrs.
+	// +optional
+	Gateway6 string `json:"gateway6,omitempty"`
+
+	// Nameservers is a list of IPv4 or IPv6 addresses of DNS servers.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// SearchDomains is a list of DNS search domains.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
 }
 
 // WorkspaceConfig defines a workspace configuration for the vSphere cloud
@@ -116,6 +263,19 @@ type Workspace struct {
 	// +optional
 	Datastore string `gcfg:"default-datastore,omitempty" json:"datastore,omitempty"`
 
+	// DatastoreCluster is the datastore cluster in which VMs are created.
+	// The datastore is picked from the Storage DRS recommendations of the
+	// cluster. It is mutually exclusive with Datastore.
+	// +optional
+	DatastoreCluster string `gcfg:"datastore-cluster,omitempty" json:"datastoreCluster,omitempty"`
+
+	// StoragePolicyName is the name of the storage policy applied to the VM
+	// home and disks. The VM is placed on a datastore compatible with the
+	// policy, among Datastore, the datastores recommended for DatastoreCluster,
+	// or all the datastores of the datacenter when neither is set.
+	// +optional
+	StoragePolicyName string `gcfg:"storage-policy-name,omitempty" json:"storagePolicyName,omitempty"`
+
 	// ResourcePool is the resource pool in which VMs are created/located.
 	// +optional
 	ResourcePool string `gcfg:"resourcepool-path,omitempty" json:"resourcePool,omitempty"`
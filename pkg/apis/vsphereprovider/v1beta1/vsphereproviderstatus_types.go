@@ -13,6 +13,9 @@ const (
 	// MachineCreation indicates whether the machine has been created or not. If not,
 	// it should include a reason and message for the failure.
 	MachineCreation VSphereMachineProviderConditionType = "MachineCreation"
+	// MachineResize indicates whether the CPUs and memory of the virtual machine
+	// match the provider spec. If not, its message describes the differences.
+	MachineResize VSphereMachineProviderConditionType = "MachineResize"
 )
 
 // VSphereMachineProviderConditionReason is reason for the condition's last transition.
@@ -23,6 +26,14 @@ const (
 	MachineCreationSucceeded VSphereMachineProviderConditionReason = "MachineCreationSucceeded"
 	// MachineCreationFailed indicates machine creation failure.
 	MachineCreationFailed VSphereMachineProviderConditionReason = "MachineCreationFailed"
+	// MachineResizeSucceeded indicates the virtual machine was resized to the provider spec.
+	MachineResizeSucceeded VSphereMachineProviderConditionReason = "MachineResizeSucceeded"
+	// MachineResizeNotAllowed indicates the virtual machine differs from the
+	// provider spec and the resize policy doesn't allow to resize it.
+	MachineResizeNotAllowed VSphereMachineProviderConditionReason = "MachineResizeNotAllowed"
+	// MachineResizeInProgress indicates the node of the machine was drained and
+	// the virtual machine is powered off to be resized.
+	MachineResizeInProgress VSphereMachineProviderConditionReason = "MachineResizeInProgress"
 )
 
 // VSphereMachineProviderCondition is a condition in a VSphereMachineProviderStatus.
@@ -72,8 +83,89 @@ type VSphereMachineProviderStatus struct {
 	// TaskRef is a managed object reference to a Task related to the machine.
 	// This value is set automatically at runtime and should not be set or
 	// modified by users.
+	// Deprecated: use Tasks instead. It is only read for machines whose task
+	// was recorded before Tasks was introduced.
 	// +optional
 	TaskRef string `json:"taskRef,omitempty"`
+
+	// Tasks are the last vSphere tasks run for the machine, one per operation.
+	// They are set automatically at runtime and should not be set or
+	// modified by users.
+	// +optional
+	Tasks []VSphereMachineTask `json:"tasks,omitempty"`
+
+	// DataDisks is the state of the data disks of the virtual machine.
+	// +optional
+	DataDisks []VSphereDiskStatus `json:"dataDisks,omitempty"`
+
+	// Tags are the tags of the provider spec attached to the virtual machine.
+	// They are recorded so that the tags removed from the provider spec are detached.
+	// +optional
+	Tags []VSphereTag `json:"tags,omitempty"`
+}
+
+// VSphereMachineTaskOperation is the machine operation a vSphere task is run for.
+type VSphereMachineTaskOperation string
+
+const (
+	// CreateTaskOperation is the operation of the task cloning the virtual machine.
+	CreateTaskOperation VSphereMachineTaskOperation = "Create"
+	// DeleteTaskOperation is the operation of the task destroying the virtual machine.
+	DeleteTaskOperation VSphereMachineTaskOperation = "Delete"
+	// ResizeTaskOperation is the operation of the tasks powering off, reconfiguring
+	// and powering on the virtual machine to resize it.
+	ResizeTaskOperation VSphereMachineTaskOperation = "Resize"
+)
+
+// VSphereMachineTaskState is the state of a vSphere task.
+type VSphereMachineTaskState string
+
+const (
+	// TaskQueued means the task is waiting to run.
+	TaskQueued VSphereMachineTaskState = "queued"
+	// TaskRunning means the task is running.
+	TaskRunning VSphereMachineTaskState = "running"
+	// TaskSucceeded means the task completed successfully.
+	TaskSucceeded VSphereMachineTaskState = "success"
+	// TaskFailed means the task completed with an error.
+	TaskFailed VSphereMachineTaskState = "error"
+)
+
+// VSphereMachineTask is a vSphere task run for an operation on the machine.
+type VSphereMachineTask struct {
+	// Operation is the machine operation the task is run for.
+	Operation VSphereMachineTaskOperation `json:"operation"`
+
+	// TaskRef is a managed object reference to the task.
+	TaskRef string `json:"taskRef"`
+
+	// State is the last observed state of the task.
+	// +optional
+	State VSphereMachineTaskState `json:"state,omitempty"`
+
+	// Error is the error message of the task when it failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// VSphereDiskStatus is the state of a data disk attached to a virtual machine.
+type VSphereDiskStatus struct {
+	// Name is the name of the disk in the provider spec.
+	Name string `json:"name"`
+
+	// ControllerBusNumber is the bus number of the SCSI controller the disk is attached to.
+	ControllerBusNumber int32 `json:"controllerBusNumber"`
+
+	// UnitNumber is the unit number of the disk on its controller.
+	UnitNumber int32 `json:"unitNumber"`
+
+	// SizeGiB is the size of the disk attached to the virtual machine, in GiB.
+	// +optional
+	SizeGiB int32 `json:"sizeGiB,omitempty"`
+
+	// FileName is the datastore path of the backing file of the disk.
+	// +optional
+	FileName string `json:"fileName,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
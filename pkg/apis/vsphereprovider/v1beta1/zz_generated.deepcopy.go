@@ -26,9 +26,44 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContentLibraryItem) DeepCopyInto(out *ContentLibraryItem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContentLibraryItem.
+func (in *ContentLibraryItem) DeepCopy() *ContentLibraryItem {
+	if in == nil {
+		return nil
+	}
+	out := new(ContentLibraryItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkDeviceSpec) DeepCopyInto(out *NetworkDeviceSpec) {
 	*out = *in
+	if in.IPAddrs != nil {
+		in, out := &in.IPAddrs, &out.IPAddrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPAddressPool != nil {
+		in, out := &in.IPAddressPool, &out.IPAddressPool
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkDeviceSpec.
@@ -47,7 +82,9 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	if in.Devices != nil {
 		in, out := &in.Devices, &out.Devices
 		*out = make([]NetworkDeviceSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -61,6 +98,46 @@ func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereDisk) DeepCopyInto(out *VSphereDisk) {
+	*out = *in
+	if in.ControllerBusNumber != nil {
+		in, out := &in.ControllerBusNumber, &out.ControllerBusNumber
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UnitNumber != nil {
+		in, out := &in.UnitNumber, &out.UnitNumber
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDisk.
+func (in *VSphereDisk) DeepCopy() *VSphereDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereDiskStatus) DeepCopyInto(out *VSphereDiskStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereDiskStatus.
+func (in *VSphereDiskStatus) DeepCopy() *VSphereDiskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereDiskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereMachineProviderCondition) DeepCopyInto(out *VSphereMachineProviderCondition) {
 	*out = *in
@@ -93,12 +170,29 @@ func (in *VSphereMachineProviderSpec) DeepCopyInto(out *VSphereMachineProviderSp
 		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.ContentLibraryItem != nil {
+		in, out := &in.ContentLibraryItem, &out.ContentLibraryItem
+		*out = new(ContentLibraryItem)
+		**out = **in
+	}
 	if in.Workspace != nil {
 		in, out := &in.Workspace, &out.Workspace
 		*out = new(Workspace)
 		**out = **in
 	}
 	in.Network.DeepCopyInto(&out.Network)
+	if in.DataDisks != nil {
+		in, out := &in.DataDisks, &out.DataDisks
+		*out = make([]VSphereDisk, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]VSphereTag, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineProviderSpec.
@@ -140,6 +234,21 @@ func (in *VSphereMachineProviderStatus) DeepCopyInto(out *VSphereMachineProvider
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]VSphereMachineTask, len(*in))
+		copy(*out, *in)
+	}
+	if in.DataDisks != nil {
+		in, out := &in.DataDisks, &out.DataDisks
+		*out = make([]VSphereDiskStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]VSphereTag, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineProviderStatus.
@@ -160,6 +269,37 @@ func (in *VSphereMachineProviderStatus) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereMachineTask) DeepCopyInto(out *VSphereMachineTask) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereMachineTask.
+func (in *VSphereMachineTask) DeepCopy() *VSphereMachineTask {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereMachineTask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereTag) DeepCopyInto(out *VSphereTag) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereTag.
+func (in *VSphereTag) DeepCopy() *VSphereTag {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereTag)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Workspace) DeepCopyInto(out *Workspace) {
 	*out = *in
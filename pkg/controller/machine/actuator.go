@@ -20,6 +20,7 @@ import (
 	"context"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 /// [Actuator]
@@ -37,3 +38,25 @@ type Actuator interface {
 }
 
 /// [Actuator]
+
+// InstanceDetacher is implemented by actuators which can detach an instance from the cluster
+// without destroying it, when its machine is deleted with the OrphanInstanceAnnotation.
+type InstanceDetacher interface {
+	// Detach removes everything linking the instance to the cluster, e.g. cluster tags.
+	Detach(context.Context, *machinev1.Machine) error
+}
+
+// InstanceOrphaner is implemented by actuators which hold cluster resources on behalf of an
+// instance, which must be kept when its machine is deleted with the OrphanInstanceAnnotation.
+type InstanceOrphaner interface {
+	// Orphan hands over to the instance the resources it keeps using once its machine is gone,
+	// e.g. claimed IP addresses.
+	Orphan(context.Context, *machinev1.Machine) error
+}
+
+// EventSourcer is implemented by actuators which enqueue machines on events of their
+// infrastructure, e.g. when a long running operation on an instance is finished.
+type EventSourcer interface {
+	// Sources returns the sources of the events, whose objects are the machines to reconcile.
+	Sources() []source.Source
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	corev1 "k8s.io/api/core/v1"
@@ -50,6 +51,18 @@ const (
 	// ExcludeNodeDrainingAnnotation annotation explicitly skips node draining if set
 	ExcludeNodeDrainingAnnotation = "machine.openshift.io/exclude-node-draining"
 
+	// OrphanInstanceAnnotation annotation removes a deleted machine without draining its node
+	// or destroying its instance, e.g. to move the instance to another cluster
+	OrphanInstanceAnnotation = "machine.openshift.io/orphan-instance"
+
+	// OrphanInstanceDetach as OrphanInstanceAnnotation value also detaches the orphaned instance
+	// from the cluster, if the actuator supports it
+	OrphanInstanceDetach = "detach"
+
+	// AdoptInstanceAnnotation annotation marks a machine created for an existing instance,
+	// referenced by the providerID of the machine. The instance is adopted and never created.
+	AdoptInstanceAnnotation = "machine.openshift.io/adopt-instance"
+
 	// MachineRegionLabelName as annotation name for a machine region
 	MachineRegionLabelName = "machine.openshift.io/region"
 
@@ -98,7 +111,18 @@ const (
 var DefaultActuator Actuator
 
 func AddWithActuator(mgr manager.Manager, actuator Actuator) error {
-	return add(mgr, newReconciler(mgr, actuator))
+	return AddWithActuatorAndWorkers(mgr, actuator, mapicontroller.DefaultWorkerOptions())
+}
+
+// AddWithActuatorAndWorkers creates a new Machine Controller running the given workers and adds it to the Manager.
+// The actuator must be safe for concurrent use when more than one worker is configured.
+// Machines are also reconciled on the events of the actuator when it implements EventSourcer.
+func AddWithActuatorAndWorkers(mgr manager.Manager, actuator Actuator, workers mapicontroller.WorkerOptions) error {
+	var sources []source.Source
+	if sourcer, ok := actuator.(EventSourcer); ok {
+		sources = sourcer.Sources()
+	}
+	return add(mgr, newReconciler(mgr, actuator), workers, sources...)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -121,18 +145,28 @@ func stringPointerDeref(stringPointer *string) string {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, workers mapicontroller.WorkerOptions, sources ...source.Source) error {
 	// Create a new controller
-	c, err := controller.New("machine_controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("machine_controller", mgr, workers.ControllerOptions(r))
 	if err != nil {
 		return err
 	}
 
 	// Watch for changes to Machine
-	return c.Watch(
+	if err := c.Watch(
 		&source.Kind{Type: &machinev1.Machine{}},
 		&handler.EnqueueRequestForObject{},
-	)
+	); err != nil {
+		return err
+	}
+
+	// Watch for the events of the actuator
+	for _, s := range sources {
+		if err := c.Watch(s, &handler.EnqueueRequestForObject{}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ReconcileMachine reconciles a Machine object
@@ -205,6 +239,10 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{}, nil
 		}
 
+		if value, orphan := m.ObjectMeta.Annotations[OrphanInstanceAnnotation]; orphan {
+			return r.orphanInstance(ctx, m, value == OrphanInstanceDetach)
+		}
+
 		klog.Infof("%v: reconciling machine triggers delete", machineName)
 		// Drain node before deletion
 		// If a machine is not linked to a node, just delete the machine. Since a node
@@ -266,6 +304,28 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, nil
 	}
 
+	_, adopting := m.ObjectMeta.Annotations[AdoptInstanceAnnotation]
+	if adopting && stringPointerDeref(m.Spec.ProviderID) == "" {
+		err := InvalidMachineConfiguration("%v: providerID must reference the instance to adopt", machineName)
+		klog.Error(err)
+		return reconcile.Result{}, r.setPhase(m, phaseFailed, err)
+	}
+
+	if adopting && m.Status.Phase == nil {
+		// An instance must never be managed by more than one machine, deleting
+		// either of them would terminate the instance of the other one
+		owner, err := r.machineWithProviderID(m)
+		if err != nil {
+			klog.Errorf("%v: failed to check machines for providerID %q: %v", machineName, stringPointerDeref(m.Spec.ProviderID), err)
+			return reconcile.Result{}, err
+		}
+		if owner != "" {
+			err := InvalidMachineConfiguration("%v: instance %q is already managed by machine %q", machineName, stringPointerDeref(m.Spec.ProviderID), owner)
+			klog.Error(err)
+			return reconcile.Result{}, r.setPhase(m, phaseFailed, err)
+		}
+	}
+
 	instanceExists, err := r.actuator.Exists(ctx, m)
 	if err != nil {
 		klog.Errorf("%v: failed to check if machine exists: %v", machineName, err)
@@ -273,8 +333,17 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 	}
 
 	if instanceExists {
+		if adopting && m.Status.Phase == nil {
+			klog.Infof("%v: adopting instance %q", machineName, stringPointerDeref(m.Spec.ProviderID))
+			r.eventRecorder.Eventf(m, corev1.EventTypeNormal, "Adopted", "Adopted instance %v", stringPointerDeref(m.Spec.ProviderID))
+		}
+
 		klog.Infof("%v: reconciling machine triggers idempotent update", machineName)
 		if err := r.actuator.Update(ctx, m); err != nil {
+			var requeueAfterError *RequeueAfterError
+			if errors.As(err, &requeueAfterError) {
+				return delayIfRequeueAfterError(err)
+			}
 			klog.Errorf("%v: error updating machine: %v, retrying in %v seconds", machineName, err, requeueAfter)
 			return reconcile.Result{RequeueAfter: requeueAfter}, nil
 		}
@@ -296,6 +365,14 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, r.setPhase(m, phaseRunning, nil)
 	}
 
+	// Instances to adopt are never created
+	if adopting {
+		if err := r.setPhase(m, phaseFailed, fmt.Errorf("Can't find instance %v to adopt.", stringPointerDeref(m.Spec.ProviderID))); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
 	// Instance does not exist but the machine has been given a providerID/address.
 	// This can only be reached if an instance was deleted outside the machine API
 	if machineIsProvisioned(m) {
@@ -388,6 +465,44 @@ func (r *ReconcileMachine) drainNode(machine *machinev1.Machine) error {
 	return nil
 }
 
+// orphanInstance removes the finalizer of a deleted machine without draining its node or
+// deleting its instance. The instance and its node are left in place, along with the
+// resources the actuator holds for the instance.
+func (r *ReconcileMachine) orphanInstance(ctx context.Context, machine *machinev1.Machine, detach bool) (reconcile.Result, error) {
+	machineName := machine.GetName()
+	klog.Infof("%v: reconciling machine orphans its instance", machineName)
+
+	if orphaner, ok := r.actuator.(InstanceOrphaner); ok {
+		if err := orphaner.Orphan(ctx, machine); err != nil {
+			klog.Errorf("%v: failed to orphan instance resources: %v", machineName, err)
+			r.eventRecorder.Eventf(machine, corev1.EventTypeWarning, "FailedOrphan", "Failed to orphan instance: %v", err)
+			return delayIfRequeueAfterError(err)
+		}
+	}
+
+	if detach {
+		if detacher, ok := r.actuator.(InstanceDetacher); ok {
+			if err := detacher.Detach(ctx, machine); err != nil {
+				klog.Errorf("%v: failed to detach instance from the cluster: %v", machineName, err)
+				r.eventRecorder.Eventf(machine, corev1.EventTypeWarning, "FailedDetach", "Failed to detach instance: %v", err)
+				return delayIfRequeueAfterError(err)
+			}
+		} else {
+			klog.Warningf("%v: actuator does not support detaching instances, the instance is left attached to the cluster", machineName)
+		}
+	}
+
+	machine.ObjectMeta.Finalizers = util.Filter(machine.ObjectMeta.Finalizers, machinev1.MachineFinalizer)
+	if err := r.Client.Update(ctx, machine); err != nil {
+		klog.Errorf("%v: failed to remove finalizer from machine: %v", machineName, err)
+		return reconcile.Result{}, err
+	}
+
+	r.eventRecorder.Eventf(machine, corev1.EventTypeNormal, "Orphaned", "Machine deleted without deleting its instance")
+	klog.Infof("%v: machine deletion successful, instance orphaned", machineName)
+	return reconcile.Result{}, nil
+}
+
 func (r *ReconcileMachine) deleteNode(ctx context.Context, name string) error {
 	var node corev1.Node
 	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
@@ -401,6 +516,24 @@ func (r *ReconcileMachine) deleteNode(ctx context.Context, name string) error {
 	return r.Client.Delete(ctx, &node)
 }
 
+// machineWithProviderID returns the name of another machine in the namespace of the given
+// machine which references the same providerID, or an empty string if there is none
+func (r *ReconcileMachine) machineWithProviderID(machine *machinev1.Machine) (string, error) {
+	machines := &machinev1.MachineList{}
+	if err := r.Client.List(context.Background(), machines, client.InNamespace(machine.GetNamespace())); err != nil {
+		return "", err
+	}
+	for i := range machines.Items {
+		if machines.Items[i].GetName() == machine.GetName() {
+			continue
+		}
+		if stringPointerDeref(machines.Items[i].Spec.ProviderID) == stringPointerDeref(machine.Spec.ProviderID) {
+			return machines.Items[i].GetName(), nil
+		}
+	}
+	return "", nil
+}
+
 func delayIfRequeueAfterError(err error) (reconcile.Result, error) {
 	var requeueAfterError *RequeueAfterError
 	if errors.As(err, &requeueAfterError) {
@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -325,6 +326,301 @@ func TestReconcileRequest(t *testing.T) {
 	}
 }
 
+// detachingActuator is a TestActuator which can orphan and detach instances
+type detachingActuator struct {
+	*TestActuator
+	orphanCallCount int64
+	orphanErr       error
+	detachCallCount int64
+	detachErr       error
+}
+
+func (a *detachingActuator) Orphan(context.Context, *machinev1.Machine) error {
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	a.orphanCallCount++
+	return a.orphanErr
+}
+
+func (a *detachingActuator) Detach(context.Context, *machinev1.Machine) error {
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	a.detachCallCount++
+	return a.detachErr
+}
+
+func TestReconcileOrphanInstance(t *testing.T) {
+	now := metav1.Now()
+	newMachine := func(orphanValue string) *machinev1.Machine {
+		return &machinev1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "orphan",
+				Namespace:         "default",
+				Finalizers:        []string{machinev1.MachineFinalizer, metav1.FinalizerDeleteDependents},
+				DeletionTimestamp: &now,
+				Annotations: map[string]string{
+					OrphanInstanceAnnotation: orphanValue,
+				},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+			Status: machinev1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{
+					Name: "a node",
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name                    string
+		orphanValue             string
+		supportsDetach          bool
+		orphanErr               error
+		detachErr               error
+		expectedOrphanCallCount int64
+		expectedDetachCallCount int64
+		expectedFinalizer       bool
+		expectError             bool
+		expectedEvents          []string
+	}{
+		{
+			name:                    "orphan the instance",
+			orphanValue:             "",
+			supportsDetach:          true,
+			expectedOrphanCallCount: 1,
+			expectedEvents:          []string{"Normal Orphaned"},
+		},
+		{
+			name:                    "orphan and detach the instance",
+			orphanValue:             OrphanInstanceDetach,
+			supportsDetach:          true,
+			expectedOrphanCallCount: 1,
+			expectedDetachCallCount: 1,
+			expectedEvents:          []string{"Normal Orphaned"},
+		},
+		{
+			name:           "orphan the instance when detaching is not supported",
+			orphanValue:    OrphanInstanceDetach,
+			supportsDetach: false,
+			expectedEvents: []string{"Normal Orphaned"},
+		},
+		{
+			name:                    "keep the finalizer when detaching fails",
+			orphanValue:             OrphanInstanceDetach,
+			supportsDetach:          true,
+			detachErr:               errors.New("vSphere is unavailable"),
+			expectedOrphanCallCount: 1,
+			expectedDetachCallCount: 1,
+			expectedFinalizer:       true,
+			expectError:             true,
+			expectedEvents:          []string{"Warning FailedDetach"},
+		},
+		{
+			name:                    "keep the finalizer when orphaning fails",
+			orphanValue:             "",
+			supportsDetach:          true,
+			orphanErr:               errors.New("API server is unavailable"),
+			expectedOrphanCallCount: 1,
+			expectedFinalizer:       true,
+			expectError:             true,
+			expectedEvents:          []string{"Warning FailedOrphan"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			testActuator := newTestActuator()
+			detacher := &detachingActuator{TestActuator: testActuator, orphanErr: tc.orphanErr, detachErr: tc.detachErr}
+			var actuator Actuator = testActuator
+			if tc.supportsDetach {
+				actuator = detacher
+			}
+
+			machinev1.AddToScheme(scheme.Scheme)
+			recorder := record.NewFakeRecorder(10)
+			machine := newMachine(tc.orphanValue)
+			r := &ReconcileMachine{
+				Client:        fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      actuator,
+			}
+
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err != nil).To(Equal(tc.expectError), "unexpected error: %v", err)
+
+			// neither the node is drained nor the instance deleted
+			g.Expect(testActuator.DeleteCallCount).To(BeZero())
+			g.Expect(testActuator.ExistsCallCount).To(BeZero())
+			g.Expect(detacher.orphanCallCount).To(Equal(tc.expectedOrphanCallCount))
+			g.Expect(detacher.detachCallCount).To(Equal(tc.expectedDetachCallCount))
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machine), got)).To(Succeed())
+			g.Expect(got.Finalizers).To(ContainElement(metav1.FinalizerDeleteDependents))
+			if tc.expectedFinalizer {
+				g.Expect(got.Finalizers).To(ContainElement(machinev1.MachineFinalizer))
+			} else {
+				g.Expect(got.Finalizers).ToNot(ContainElement(machinev1.MachineFinalizer))
+			}
+			g.Expect(stringPointerDeref(got.Status.Phase)).To(Equal(phaseDeleting))
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(HaveLen(len(tc.expectedEvents)))
+			for i, prefix := range tc.expectedEvents {
+				g.Expect(events[i]).To(HavePrefix(prefix))
+			}
+		})
+	}
+}
+
+func TestReconcileAdoptInstance(t *testing.T) {
+	newMachine := func(providerID string) *machinev1.Machine {
+		m := &machinev1.Machine{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "Machine",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "adopt",
+				Namespace:  "default",
+				Finalizers: []string{machinev1.MachineFinalizer},
+				Annotations: map[string]string{
+					AdoptInstanceAnnotation: "",
+				},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+		}
+		if providerID != "" {
+			m.Spec.ProviderID = pointer.StringPtr(providerID)
+		}
+		return m
+	}
+
+	testCases := []struct {
+		name                    string
+		providerID              string
+		otherProviderID         string
+		existsValue             bool
+		expectedExistsCallCount int64
+		expectedUpdateCallCount int64
+		expectedPhase           string
+		expectedEvents          []string
+	}{
+		{
+			name:                    "adopt an existing instance",
+			providerID:              "providerID",
+			existsValue:             true,
+			expectedExistsCallCount: 1,
+			expectedUpdateCallCount: 1,
+			expectedPhase:           phaseProvisioned,
+			expectedEvents:          []string{"Normal Adopted"},
+		},
+		{
+			name:                    "fail when the instance does not exist",
+			providerID:              "providerID",
+			existsValue:             false,
+			expectedExistsCallCount: 1,
+			expectedPhase:           phaseFailed,
+		},
+		{
+			name:          "fail without a providerID",
+			existsValue:   true,
+			expectedPhase: phaseFailed,
+		},
+		{
+			name:            "fail when another machine manages the instance",
+			providerID:      "providerID",
+			otherProviderID: "providerID",
+			existsValue:     true,
+			expectedPhase:   phaseFailed,
+		},
+		{
+			name:                    "adopt when another machine manages a different instance",
+			providerID:              "providerID",
+			otherProviderID:         "otherProviderID",
+			existsValue:             true,
+			expectedExistsCallCount: 1,
+			expectedUpdateCallCount: 1,
+			expectedPhase:           phaseProvisioned,
+			expectedEvents:          []string{"Normal Adopted"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			act := newTestActuator()
+			act.ExistsValue = tc.existsValue
+			machinev1.AddToScheme(scheme.Scheme)
+			recorder := record.NewFakeRecorder(10)
+			machine := newMachine(tc.providerID)
+			objects := []runtime.Object{machine}
+			if tc.otherProviderID != "" {
+				other := newMachine(tc.otherProviderID)
+				other.Name = "other"
+				delete(other.Annotations, AdoptInstanceAnnotation)
+				objects = append(objects, other)
+			}
+			r := &ReconcileMachine{
+				Client:        fake.NewFakeClientWithScheme(scheme.Scheme, objects...),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+			}
+
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// instances to adopt are never created
+			g.Expect(act.CreateCallCount).To(BeZero())
+			g.Expect(act.ExistsCallCount).To(Equal(tc.expectedExistsCallCount))
+			g.Expect(act.UpdateCallCount).To(Equal(tc.expectedUpdateCallCount))
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machine), got)).To(Succeed())
+			g.Expect(stringPointerDeref(got.Status.Phase)).To(Equal(tc.expectedPhase))
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(HaveLen(len(tc.expectedEvents)))
+			for i, prefix := range tc.expectedEvents {
+				g.Expect(events[i]).To(HavePrefix(prefix))
+			}
+		})
+	}
+}
+
 func TestSetPhase(t *testing.T) {
 	testCases := []struct {
 		name                   string
@@ -22,6 +22,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	machinev1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"golang.org/x/net/context"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -62,7 +63,7 @@ func TestReconcile(t *testing.T) {
 
 	a := newTestActuator()
 	recFn := newReconciler(mgr, a)
-	if err := add(mgr, recFn); err != nil {
+	if err := add(mgr, recFn, mapicontroller.DefaultWorkerOptions()); err != nil {
 		t.Fatalf("error adding controller to manager: %v", err)
 	}
 
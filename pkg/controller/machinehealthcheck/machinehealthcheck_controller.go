@@ -13,6 +13,8 @@ import (
 	"k8s.io/klog/v2"
 
 	mapiv1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1/cron"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
@@ -22,11 +24,14 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	apimachineryutilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -38,12 +43,18 @@ const (
 	machineRoleLabel              = "machine.openshift.io/cluster-api-machine-role"
 	machineMasterRole             = "master"
 	machinePhaseFailed            = "Failed"
+	machinePhaseRunning           = "Running"
 	remediationStrategyAnnotation = "machine.openshift.io/remediation-strategy"
 	remediationStrategyExternal   = mapiv1.RemediationStrategyType("external-baremetal")
 	defaultNodeStartupTimeout     = 10 * time.Minute
 	machineNodeNameIndex          = "machineNodeNameIndex"
 	controllerName                = "machinehealthcheck-controller"
 
+	// replacementMachineAnnotationKey is set on an unowned machine being replaced and names its replacement
+	replacementMachineAnnotationKey = "machine.openshift.io/replacement-machine"
+	// replacementForAnnotationKey is set on a replacement machine and names the machine it replaces
+	replacementForAnnotationKey = "machine.openshift.io/replacement-for"
+
 	// Event types
 	// EventRemediationRestricted is emitted in case when machine remediation
 	// is restricted by remediation circuit shorting logic
@@ -66,16 +77,51 @@ const (
 	// EventExternalAnnotationAdded is emitted when external annotation was
 	// successfully added to a Node object
 	EventExternalAnnotationAdded string = "ExternalAnnotationAdded"
+	// EventReplacementMachineCreated is emitted when a replacement machine was
+	// successfully created for an unhealthy machine without a controller owner
+	EventReplacementMachineCreated string = "ReplacementMachineCreated"
+	// EventReplacementMachineCreationFailed is emitted in case creating a replacement
+	// machine for an unhealthy machine without a controller owner failed
+	EventReplacementMachineCreationFailed string = "ReplacementMachineCreationFailed"
+	// EventReplacementMachineFailed is emitted in case a replacement machine failed
+	// and is deleted so that a new replacement can be created
+	EventReplacementMachineFailed string = "ReplacementMachineFailed"
+	// EventReplacementMachineCancelled is emitted when a machine being replaced
+	// recovered before its replacement was running and the replacement is deleted
+	EventReplacementMachineCancelled string = "ReplacementMachineCancelled"
+	// EventRemediationDeferred is emitted in case an unhealthy machine is not
+	// remediated because none of the MHC remediation windows is open
+	EventRemediationDeferred string = "RemediationDeferred"
+	// EventSkippedLowerPriority is emitted in case an unhealthy machine is also
+	// selected by a MachineHealthCheck with a higher priority
+	EventSkippedLowerPriority string = "SkippedLowerPriority"
+	// EventOverlappingMachineHealthChecks is emitted in case machines selected by a
+	// MachineHealthCheck are also selected by other MachineHealthChecks
+	EventOverlappingMachineHealthChecks string = "OverlappingMachineHealthChecks"
 )
 
 // Add creates a new MachineHealthCheck Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and start it when the Manager is started.
 func Add(mgr manager.Manager, opts manager.Options) error {
+	return AddWithWorkers(mgr, opts, mapicontroller.DefaultWorkerOptions())
+}
+
+// AddWithWorkers creates a new MachineHealthCheck Controller running the given workers and adds it to the Manager.
+func AddWithWorkers(mgr manager.Manager, opts manager.Options, workers mapicontroller.WorkerOptions) error {
 	r, err := newReconciler(mgr, opts)
 	if err != nil {
 		return fmt.Errorf("error building reconciler: %v", err)
 	}
-	return add(mgr, r, r.mhcRequestsFromMachine, r.mhcRequestsFromNode)
+	c, err := add(mgr, r, workers, r.mhcRequestsFromMHC, r.mhcRequestsFromMachine, r.mhcRequestsFromNode)
+	if err != nil {
+		return err
+	}
+
+	// Probe pods may run in any namespace, so they are not read from the
+	// cache of the manager, which may be namespaced. Their namespaces are only
+	// known from the MHCs, so the pod caches are started on demand.
+	r.probePodCaches = newProbePodCaches(probePodCacheStarter(mgr, c, r.mhcRequestsFromPod))
+	return nil
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -93,6 +139,7 @@ func newReconciler(mgr manager.Manager, opts manager.Options) (*ReconcileMachine
 		scheme:    mgr.GetScheme(),
 		namespace: opts.Namespace,
 		recorder:  mgr.GetEventRecorderFor(controllerName),
+		mhcIndex:  newMHCSelectorIndex(),
 	}, nil
 }
 
@@ -111,23 +158,35 @@ func indexMachineByNodeName(object client.Object) []string {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler, mapMachineToMHC, mapNodeToMHC handler.MapFunc) error {
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+func add(mgr manager.Manager, r reconcile.Reconciler, workers mapicontroller.WorkerOptions, mapMHCToMHC, mapMachineToMHC, mapNodeToMHC handler.MapFunc) (controller.Controller, error) {
+	c, err := controller.New(controllerName, mgr, workers.ControllerOptions(r))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = c.Watch(&source.Kind{Type: &mapiv1.MachineHealthCheck{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Peers of an MHC only need to recompute their overlap when its selector
+	// changes or it is deleted, not on its own status updates.
+	err = c.Watch(&source.Kind{Type: &mapiv1.MachineHealthCheck{}}, handler.EnqueueRequestsFromMapFunc(mapMHCToMHC), predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return nil, err
 	}
 
 	err = c.Watch(&source.Kind{Type: &mapiv1.Machine{}}, handler.EnqueueRequestsFromMapFunc(mapMachineToMHC))
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(mapNodeToMHC))
+	if err != nil {
+		return nil, err
 	}
 
-	return c.Watch(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(mapNodeToMHC))
+	return c, nil
 }
 
 var _ reconcile.Reconciler = &ReconcileMachineHealthCheck{}
@@ -136,16 +195,24 @@ var _ reconcile.Reconciler = &ReconcileMachineHealthCheck{}
 type ReconcileMachineHealthCheck struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client    client.Client
-	scheme    *runtime.Scheme
-	namespace string
-	recorder  record.EventRecorder
+	client client.Client
+	// probePodCaches holds the pod caches of the namespaces probe pods
+	// are read from
+	probePodCaches *probePodCaches
+	scheme         *runtime.Scheme
+	namespace      string
+	recorder       record.EventRecorder
+	// mhcIndex maps machine and node events to MHCs without listing them
+	mhcIndex *mhcSelectorIndex
 }
 
 type target struct {
 	Machine mapiv1.Machine
 	Node    *corev1.Node
 	MHC     mapiv1.MachineHealthCheck
+	// ProbePods are the pods selected by any of the MHC unhealthy probes
+	// which are scheduled to the node
+	ProbePods []corev1.Pod
 }
 
 // Reconcile fetch all targets for a MachineHealthCheck request and does health checking for each of them
@@ -158,6 +225,8 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 			// Request object not found, could have been deleted after reconcile request.
 			// In the event that this was a deletion, we need to remove the associated metric label
 			metrics.DeleteMachineHealthCheckNodesCovered(request.NamespacedName.Name, request.NamespacedName.Namespace)
+			metrics.DeleteMachineHealthCheckOverlappingMachines(request.NamespacedName.Name, request.NamespacedName.Namespace)
+			metrics.DeleteMachineHealthCheckRemediationWindowWait(request.NamespacedName.Name, request.NamespacedName.Namespace)
 			return reconcile.Result{}, nil
 		}
 		klog.Errorf("Reconciling %s: failed to get MHC: %v", request.String(), err)
@@ -177,18 +246,25 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 
 	metrics.ObserveMachineHealthCheckNodesCovered(mhc.Name, mhc.Namespace, totalTargets)
 
+	// find machines which are also selected by other MHCs
+	overlaps, err := r.getOverlappingMHCs(mhc, targets)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	r.reconcileOverlapCondition(mhc, overlaps)
+
 	// health check all targets and reconcile mhc status
 	currentHealthy, needRemediationTargets, nextCheckTimes, errList := r.healthCheckTargets(targets, mhc.Spec.NodeStartupTimeout.Duration)
 	mhc.Status.CurrentHealthy = &currentHealthy
 	mhc.Status.ExpectedMachines = &totalTargets
 	unhealthyCount := totalTargets - currentHealthy
 
-	// check MHC current health against MaxUnhealthy
+	// check MHC current health against MaxUnhealthy or UnhealthyRange
 	if !isAllowedRemediation(mhc) {
-		klog.Warningf("Reconciling %s: total targets: %v,  maxUnhealthy: %v, unhealthy: %v. Short-circuiting remediation",
+		klog.Warningf("Reconciling %s: total targets: %v,  %s, unhealthy: %v. Short-circuiting remediation",
 			request.String(),
 			totalTargets,
-			mhc.Spec.MaxUnhealthy,
+			remediationLimit(mhc),
 			totalTargets-currentHealthy,
 		)
 
@@ -197,8 +273,15 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 			unhealthyCount,
 			mhc.Spec.MaxUnhealthy,
 		)
+		if mhc.Spec.UnhealthyRange != "" {
+			message = fmt.Sprintf("Remediation is not allowed, the number of not started or unhealthy machines is above unhealthyRange (total: %v, unhealthy: %v, unhealthyRange: %v)",
+				totalTargets,
+				unhealthyCount,
+				mhc.Spec.UnhealthyRange,
+			)
+		}
 
-		// Remediation not allowed, the number of not started or unhealthy machines exceeds maxUnhealthy
+		// Remediation not allowed, the number of not started or unhealthy machines is out of bounds
 		mhc.Status.RemediationsAllowed = 0
 		conditions.Set(mhc, &mapiv1.Condition{
 			Type:     mapiv1.RemediationAllowedCondition,
@@ -217,22 +300,72 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 			mhc,
 			corev1.EventTypeWarning,
 			EventRemediationRestricted,
-			"Remediation restricted due to exceeded number of unhealthy machines (total: %v, unhealthy: %v, maxUnhealthy: %v)",
+			"Remediation restricted due to number of unhealthy machines (total: %v, unhealthy: %v, %s)",
 			totalTargets,
 			unhealthyCount,
-			mhc.Spec.MaxUnhealthy,
+			remediationLimit(mhc),
 		)
 		metrics.ObserveMachineHealthCheckShortCircuitEnabled(mhc.Name, mhc.Namespace)
+		metrics.ObserveMachineHealthCheckRemediationWindowWait(mhc.Name, mhc.Namespace, 0)
 		return reconcile.Result{Requeue: true}, nil
 	}
-	klog.V(3).Infof("Remediations are allowed for %s: total targets: %v,  max unhealthy: %v, unhealthy targets: %v",
+	klog.V(3).Infof("Remediations are allowed for %s: total targets: %v,  %s, unhealthy targets: %v",
 		request.String(),
 		totalTargets,
-		mhc.Spec.MaxUnhealthy,
+		remediationLimit(mhc),
 		unhealthyCount,
 	)
 	metrics.ObserveMachineHealthCheckShortCircuitDisabled(mhc.Name, mhc.Namespace)
 
+	// unhealthy machines are only remediated once there are at least as many
+	// as the lower bound of unhealthyRange
+	if len(needRemediationTargets) > 0 && isBelowUnhealthyRange(mhc) {
+		klog.V(3).Infof("Reconciling %s: total targets: %v, unhealthyRange: %v, unhealthy: %v. Waiting for more unhealthy targets before remediating",
+			request.String(),
+			totalTargets,
+			mhc.Spec.UnhealthyRange,
+			unhealthyCount,
+		)
+		conditions.Set(mhc, conditions.FalseCondition(
+			mapiv1.RemediationAllowedCondition,
+			mapiv1.TooFewUnhealthyReason,
+			mapiv1.ConditionSeverityInfo,
+			"Remediation is not allowed, the number of not started or unhealthy machines is below unhealthyRange (total: %v, unhealthy: %v, unhealthyRange: %v)",
+			totalTargets,
+			unhealthyCount,
+			mhc.Spec.UnhealthyRange,
+		))
+		if err := r.reconcileStatus(mergeBase, mhc); err != nil {
+			klog.Errorf("Reconciling %s: error patching status: %v", request.String(), err)
+			return reconcile.Result{}, err
+		}
+		metrics.ObserveMachineHealthCheckRemediationWindowWait(mhc.Name, mhc.Namespace, 0)
+		return reconcile.Result{RequeueAfter: minDuration(nextCheckTimes)}, nil
+	}
+
+	// check whether one of the remediation windows is open
+	if len(needRemediationTargets) > 0 {
+		waitTime, err := timeUntilRemediationWindow(mhc, time.Now())
+		if err != nil {
+			klog.Errorf("Reconciling %s: invalid remediation windows: %v", request.String(), err)
+			conditions.Set(mhc, conditions.FalseCondition(
+				mapiv1.RemediationAllowedCondition,
+				mapiv1.OutsideRemediationWindowReason,
+				mapiv1.ConditionSeverityWarning,
+				"Remediation is not allowed, remediation windows are invalid: %v", err,
+			))
+			if err := r.reconcileStatus(mergeBase, mhc); err != nil {
+				klog.Errorf("Reconciling %s: error patching status: %v", request.String(), err)
+			}
+			return reconcile.Result{}, err
+		}
+
+		if waitTime > 0 {
+			return r.deferRemediation(mergeBase, mhc, needRemediationTargets, waitTime)
+		}
+	}
+	metrics.ObserveMachineHealthCheckRemediationWindowWait(mhc.Name, mhc.Namespace, 0)
+
 	conditions.MarkTrue(mhc, mapiv1.RemediationAllowedCondition)
 	if err := r.reconcileStatus(mergeBase, mhc); err != nil {
 		klog.Errorf("Reconciling %s: error patching status: %v", request.String(), err)
@@ -241,6 +374,19 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 
 	// remediate
 	for _, t := range needRemediationTargets {
+		if preferred := higherPriorityMHC(mhc, overlaps[t.Machine.Name]); preferred != nil {
+			klog.Infof("Reconciling %s: skipping remediation, machine is also selected by %s with a higher priority", t.string(), namespacedName(preferred).String())
+			r.recorder.Eventf(
+				&t.Machine,
+				corev1.EventTypeNormal,
+				EventSkippedLowerPriority,
+				"Machine %v is also selected by MachineHealthCheck %v with a higher priority, skipping remediation",
+				t.string(),
+				preferred.GetName(),
+			)
+			continue
+		}
+
 		klog.V(3).Infof("Reconciling %s: meet unhealthy criteria, triggers remediation", t.string())
 		if err := t.remediate(r); err != nil {
 			klog.Errorf("Reconciling %s: error remediating: %v", t.string(), err)
@@ -264,7 +410,92 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 	return reconcile.Result{}, nil
 }
 
+// deferRemediation records unhealthy targets which can not be remediated until
+// the next remediation window opens and requeues the MHC for when it does
+func (r *ReconcileMachineHealthCheck) deferRemediation(mergeBase client.Patch, mhc *mapiv1.MachineHealthCheck, targets []target, waitTime time.Duration) (reconcile.Result, error) {
+	nextWindow := time.Now().Add(waitTime).UTC().Truncate(time.Minute).Format(time.RFC3339)
+	klog.Infof("%s: %v targets need remediation, deferring remediation until the next remediation window opens at %s",
+		namespacedName(mhc).String(),
+		len(targets),
+		nextWindow,
+	)
+
+	// The message must not change while waiting for the same window, so that
+	// the transition time of the condition tells since when remediation is deferred
+	conditions.Set(mhc, conditions.FalseCondition(
+		mapiv1.RemediationAllowedCondition,
+		mapiv1.OutsideRemediationWindowReason,
+		mapiv1.ConditionSeverityInfo,
+		"Remediation is deferred until the next remediation window opens at %s", nextWindow,
+	))
+	if err := r.reconcileStatus(mergeBase, mhc); err != nil {
+		klog.Errorf("Reconciling %s: error patching status: %v", namespacedName(mhc).String(), err)
+		return reconcile.Result{}, err
+	}
+
+	if condition := conditions.Get(mhc, mapiv1.RemediationAllowedCondition); condition != nil {
+		metrics.ObserveMachineHealthCheckRemediationWindowWait(mhc.Name, mhc.Namespace, time.Since(condition.LastTransitionTime.Time))
+	}
+
+	for _, t := range targets {
+		r.recorder.Eventf(
+			&t.Machine,
+			corev1.EventTypeNormal,
+			EventRemediationDeferred,
+			"Machine %v is unhealthy, remediation is deferred until the next remediation window opens at %s",
+			t.string(),
+			nextWindow,
+		)
+	}
+
+	return reconcile.Result{RequeueAfter: waitTime}, nil
+}
+
+// timeUntilRemediationWindow returns how long it takes until one of the remediation windows
+// of the MHC opens. It returns zero when a window is open or no windows are configured
+func timeUntilRemediationWindow(mhc *mapiv1.MachineHealthCheck, now time.Time) (time.Duration, error) {
+	if len(mhc.Spec.RemediationWindows) == 0 {
+		return 0, nil
+	}
+
+	var waitTime time.Duration
+	for _, w := range mhc.Spec.RemediationWindows {
+		schedule, err := cron.Parse(w.Schedule)
+		if err != nil {
+			return 0, fmt.Errorf("invalid schedule %q: %v", w.Schedule, err)
+		}
+
+		location := time.UTC
+		if w.TimeZone != "" {
+			if location, err = time.LoadLocation(w.TimeZone); err != nil {
+				return 0, fmt.Errorf("invalid time zone %q: %v", w.TimeZone, err)
+			}
+		}
+
+		// the window is open if it started within the last duration
+		localNow := now.In(location)
+		start := schedule.Next(localNow.Add(-w.Duration.Duration))
+		if start.IsZero() {
+			// the schedule never starts the window
+			continue
+		}
+		if !start.After(localNow) {
+			return 0, nil
+		}
+
+		if wait := start.Sub(localNow); waitTime == 0 || wait < waitTime {
+			waitTime = wait
+		}
+	}
+
+	if waitTime == 0 {
+		return 0, errors.New("none of the remediation windows ever opens")
+	}
+	return waitTime, nil
+}
+
 func isAllowedRemediation(mhc *mapiv1.MachineHealthCheck) bool {
+	// When unhealthyRange is set, maxUnhealthy is its upper bound
 	maxUnhealthy, err := getMaxUnhealthy(mhc)
 	if err != nil {
 		return false
@@ -274,7 +505,31 @@ func isAllowedRemediation(mhc *mapiv1.MachineHealthCheck) bool {
 	return unhealthyMachineCount(mhc) <= maxUnhealthy
 }
 
+// isBelowUnhealthyRange returns true if fewer machines are unhealthy than the
+// lower bound of unhealthyRange, in which case they are not remediated yet
+func isBelowUnhealthyRange(mhc *mapiv1.MachineHealthCheck) bool {
+	if mhc.Spec.UnhealthyRange == "" {
+		return false
+	}
+	min, _, err := getUnhealthyRange(mhc)
+	if err != nil {
+		return false
+	}
+	return unhealthyMachineCount(mhc) < min
+}
+
+// getMaxUnhealthy returns the maximum number of unhealthy machines for which
+// remediation is allowed. When unhealthyRange is set, this is its upper bound
 func getMaxUnhealthy(mhc *mapiv1.MachineHealthCheck) (int, error) {
+	if mhc.Spec.UnhealthyRange != "" {
+		_, max, err := getUnhealthyRange(mhc)
+		if err != nil {
+			klog.Errorf("%s: error decoding unhealthyRange, remediation won't be allowed: %v", namespacedName(mhc), err)
+			return 0, err
+		}
+		return max, nil
+	}
+
 	if mhc.Spec.MaxUnhealthy == nil {
 		// This value should be defaulted, but if not, 100% is the default
 		return derefInt(mhc.Status.ExpectedMachines), nil
@@ -292,6 +547,42 @@ func getMaxUnhealthy(mhc *mapiv1.MachineHealthCheck) (int, error) {
 	return maxUnhealthy, nil
 }
 
+// getUnhealthyRange parses an unhealthyRange of the form "[min-max]"
+func getUnhealthyRange(mhc *mapiv1.MachineHealthCheck) (int, int, error) {
+	unhealthyRange := mhc.Spec.UnhealthyRange
+	if !strings.HasPrefix(unhealthyRange, "[") || !strings.HasSuffix(unhealthyRange, "]") {
+		return 0, 0, fmt.Errorf("invalid unhealthyRange %q: expected the format [min-max]", unhealthyRange)
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(unhealthyRange, "["), "]"), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid unhealthyRange %q: expected the format [min-max]", unhealthyRange)
+	}
+
+	min, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid unhealthyRange %q: %v", unhealthyRange, err)
+	}
+	max, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid unhealthyRange %q: %v", unhealthyRange, err)
+	}
+
+	if min < 0 || max < min {
+		return 0, 0, fmt.Errorf("invalid unhealthyRange %q: min must not be negative or greater than max", unhealthyRange)
+	}
+
+	return min, max, nil
+}
+
+// remediationLimit describes the configured limit on unhealthy machines for logs and events
+func remediationLimit(mhc *mapiv1.MachineHealthCheck) string {
+	if mhc.Spec.UnhealthyRange != "" {
+		return fmt.Sprintf("unhealthyRange: %v", mhc.Spec.UnhealthyRange)
+	}
+	return fmt.Sprintf("maxUnhealthy: %v", mhc.Spec.MaxUnhealthy)
+}
+
 // unhealthyMachineCount calculates the number of presently unhealthy or missing machines
 // ie the delta between the expected number of machines and the current number deemed healthy
 func unhealthyMachineCount(mhc *mapiv1.MachineHealthCheck) int {
@@ -305,13 +596,95 @@ func derefInt(i *int) int {
 	return 0
 }
 
+// getOverlappingMHCs returns, keyed by machine name, the other MachineHealthChecks
+// in the same namespace which select any of the given targets
+func (r *ReconcileMachineHealthCheck) getOverlappingMHCs(mhc *mapiv1.MachineHealthCheck, targets []target) (map[string][]mapiv1.MachineHealthCheck, error) {
+	mhcList := &mapiv1.MachineHealthCheckList{}
+	if err := r.client.List(context.Background(), mhcList, client.InNamespace(mhc.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list mhc: %v", err)
+	}
+
+	overlaps := map[string][]mapiv1.MachineHealthCheck{}
+	for k := range mhcList.Items {
+		other := &mhcList.Items[k]
+		if other.GetName() == mhc.GetName() || !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		for i := range targets {
+			if hasMatchingLabels(other, &targets[i].Machine) {
+				machineName := targets[i].Machine.GetName()
+				overlaps[machineName] = append(overlaps[machineName], *other)
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+// reconcileOverlapCondition sets the NoOverlap condition and the overlap metric
+// for the MHC based on the overlaps found by getOverlappingMHCs
+func (r *ReconcileMachineHealthCheck) reconcileOverlapCondition(mhc *mapiv1.MachineHealthCheck, overlaps map[string][]mapiv1.MachineHealthCheck) {
+	metrics.ObserveMachineHealthCheckOverlappingMachines(mhc.Name, mhc.Namespace, len(overlaps))
+
+	if len(overlaps) == 0 {
+		conditions.MarkTrue(mhc, mapiv1.NoOverlapCondition)
+		return
+	}
+
+	overlappingMHCs := sets.NewString()
+	for _, mhcs := range overlaps {
+		for k := range mhcs {
+			overlappingMHCs.Insert(mhcs[k].GetName())
+		}
+	}
+
+	klog.Warningf("Reconciling %s: %v machines are also selected by MachineHealthChecks %v",
+		namespacedName(mhc).String(),
+		len(overlaps),
+		overlappingMHCs.List(),
+	)
+
+	// Only emit an event when the overlap is first detected or has changed
+	message := fmt.Sprintf("%v machines are also selected by MachineHealthChecks %v", len(overlaps), overlappingMHCs.List())
+	if condition := conditions.Get(mhc, mapiv1.NoOverlapCondition); condition == nil || condition.Message != message {
+		r.recorder.Eventf(
+			mhc,
+			corev1.EventTypeWarning,
+			EventOverlappingMachineHealthChecks,
+			"Machines are also selected by MachineHealthChecks %v, remediation may race between them",
+			overlappingMHCs.List(),
+		)
+	}
+
+	conditions.Set(mhc, conditions.FalseCondition(
+		mapiv1.NoOverlapCondition,
+		mapiv1.OverlappingMachineHealthChecksReason,
+		mapiv1.ConditionSeverityWarning,
+		message,
+	))
+}
+
+// higherPriorityMHC returns the MHC with the highest priority amongst the
+// given overlapping MHCs if its priority is higher than the one of mhc
+func higherPriorityMHC(mhc *mapiv1.MachineHealthCheck, overlapping []mapiv1.MachineHealthCheck) *mapiv1.MachineHealthCheck {
+	var preferred *mapiv1.MachineHealthCheck
+	for k := range overlapping {
+		if overlapping[k].Spec.Priority <= mhc.Spec.Priority {
+			continue
+		}
+		if preferred == nil || overlapping[k].Spec.Priority > preferred.Spec.Priority {
+			preferred = &overlapping[k]
+		}
+	}
+	return preferred
+}
+
 func (r *ReconcileMachineHealthCheck) reconcileStatus(baseToPatch client.Patch, mhc *mapiv1.MachineHealthCheck) error {
 	maxUnhealthy, err := getMaxUnhealthy(mhc)
 	if err != nil {
 		return fmt.Errorf("failed to get value for maxUnhealthy: %v", err)
 	}
 	mhc.Status.RemediationsAllowed = int32(maxUnhealthy - unhealthyMachineCount(mhc))
-	if mhc.Status.RemediationsAllowed < 0 {
+	if mhc.Status.RemediationsAllowed < 0 || !isAllowedRemediation(mhc) || conditions.IsFalse(mhc, mapiv1.RemediationAllowedCondition) {
 		mhc.Status.RemediationsAllowed = 0
 	}
 
@@ -358,6 +731,12 @@ func (r *ReconcileMachineHealthCheck) healthCheckTargets(targets []target, timeo
 
 		if t.Machine.DeletionTimestamp == nil {
 			currentHealthy++
+			if _, ok := t.Machine.Annotations[replacementMachineAnnotationKey]; ok {
+				if err := t.cancelReplacement(r); err != nil {
+					klog.Errorf("Reconciling %s: error cancelling replacement: %v", t.string(), err)
+					errList = append(errList, err)
+				}
+			}
 		}
 	}
 	return currentHealthy, needRemediationTargets, nextCheckTimes, errList
@@ -372,6 +751,11 @@ func (r *ReconcileMachineHealthCheck) getTargetsFromMHC(mhc mapiv1.MachineHealth
 		return nil, nil
 	}
 
+	probePods, err := r.getProbePodsFromMHC(mhc)
+	if err != nil {
+		return nil, fmt.Errorf("error getting probe pods from MHC: %v", err)
+	}
+
 	var targets []target
 	for k := range machines {
 		target := target{
@@ -388,11 +772,50 @@ func (r *ReconcileMachineHealthCheck) getTargetsFromMHC(mhc mapiv1.MachineHealth
 			node.Name = machines[k].Status.NodeRef.Name
 		}
 		target.Node = node
+		if node != nil {
+			target.ProbePods = probePods[node.Name]
+		}
 		targets = append(targets, target)
 	}
 	return targets, nil
 }
 
+// getProbePodsFromMHC returns the pods selected by the MHC unhealthy probes keyed by node name
+func (r *ReconcileMachineHealthCheck) getProbePodsFromMHC(mhc mapiv1.MachineHealthCheck) (map[string][]corev1.Pod, error) {
+	if len(mhc.Spec.UnhealthyProbes) == 0 {
+		return nil, nil
+	}
+
+	probePods := map[string][]corev1.Pod{}
+	for _, probe := range mhc.Spec.UnhealthyProbes {
+		selector, err := metav1.LabelSelectorAsSelector(&probe.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build probe selector: %v", err)
+		}
+
+		podCache, err := r.probePodCaches.reader(probe.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		podList := &corev1.PodList{}
+		if err := podCache.List(context.Background(), podList,
+			client.InNamespace(probe.Namespace),
+			client.MatchingLabelsSelector{Selector: selector},
+		); err != nil {
+			return nil, fmt.Errorf("failed to list probe pods: %v", err)
+		}
+
+		for _, pod := range podList.Items {
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+			probePods[pod.Spec.NodeName] = append(probePods[pod.Spec.NodeName], pod)
+		}
+	}
+	return probePods, nil
+}
+
 func (r *ReconcileMachineHealthCheck) getMachinesFromMHC(mhc mapiv1.MachineHealthCheck) ([]mapiv1.Machine, error) {
 	selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
 	if err != nil {
@@ -427,67 +850,112 @@ func (r *ReconcileMachineHealthCheck) getMachineFromNode(nodeName string) (*mapi
 
 func (r *ReconcileMachineHealthCheck) mhcRequestsFromNode(o client.Object) []reconcile.Request {
 	klog.V(4).Infof("Getting MHC requests from node %q", namespacedName(o).String())
-	node := &corev1.Node{}
-	if err := r.client.Get(context.Background(), namespacedName(o), node); err != nil {
-		if apimachineryerrors.IsNotFound(err) {
-			node.Name = o.GetName()
-		} else {
-			klog.Errorf("No-op: Unable to retrieve node %q from store: %v", namespacedName(o).String(), err)
-			return nil
+
+	machine, err := r.getMachineFromNode(o.GetName())
+	if machine == nil || err != nil {
+		klog.Errorf("No-op: Unable to retrieve machine from node %q: %v", namespacedName(o).String(), err)
+		return nil
+	}
+
+	// get all MHCs which selectors match this machine
+	return r.mhcRequestsMatchingMachine(machine)
+}
+
+func (r *ReconcileMachineHealthCheck) mhcRequestsFromMHC(o client.Object) []reconcile.Request {
+	klog.V(4).Infof("Getting MHC requests from MHC %q", namespacedName(o).String())
+
+	// the MHC itself is always reconciled, even when it can not be found anymore
+	requests := []reconcile.Request{{NamespacedName: namespacedName(o)}}
+
+	if err := r.syncMHCIndex(); err != nil {
+		klog.Errorf("Unable to sync mhc index: %v", err)
+		return requests
+	}
+
+	mhc := &mapiv1.MachineHealthCheck{}
+	if err := r.client.Get(context.Background(), namespacedName(o), mhc); err != nil {
+		if !apimachineryerrors.IsNotFound(err) {
+			klog.Errorf("Unable to retrieve mhc %q from store: %v", namespacedName(o).String(), err)
+			return requests
 		}
+		r.mhcIndex.delete(namespacedName(o))
+	} else {
+		r.mhcIndex.set(mhc)
 	}
 
-	machine, err := r.getMachineFromNode(node.Name)
-	if machine == nil || err != nil {
-		klog.Errorf("No-op: Unable to retrieve machine from node %q: %v", namespacedName(node).String(), err)
+	// any other MHC in the namespace may be overlapping with this one
+	// and needs to recompute its overlap condition
+	for _, key := range r.mhcIndex.inNamespace(o.GetNamespace()) {
+		if key.Name == o.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+	return requests
+}
+
+// mhcRequestsFromPod returns requests for all MHCs which unhealthy probes select the pod.
+// The probe selectors are read from the MHC index rather than listing all MHCs
+func (r *ReconcileMachineHealthCheck) mhcRequestsFromPod(o client.Object) []reconcile.Request {
+	pod, ok := o.(*corev1.Pod)
+	if !ok {
+		klog.Errorf("No-op: Expected a pod, got: %T", o)
+		return nil
+	}
+	if pod.Spec.NodeName == "" {
 		return nil
 	}
 
-	mhcList := &mapiv1.MachineHealthCheckList{}
-	if err := r.client.List(context.Background(), mhcList); err != nil {
-		klog.Errorf("No-op: Unable to list mhc: %v", err)
+	if err := r.syncMHCIndex(); err != nil {
+		klog.Errorf("No-op: Unable to sync mhc index: %v", err)
 		return nil
 	}
 
-	// get all MHCs which selectors match this machine
 	var requests []reconcile.Request
-	for k := range mhcList.Items {
-		if hasMatchingLabels(&mhcList.Items[k], machine) {
-			requests = append(requests, reconcile.Request{NamespacedName: namespacedName(&mhcList.Items[k])})
-		}
+	for _, key := range r.mhcIndex.matchingProbePod(pod) {
+		klog.V(4).Infof("Probe pod %q is selected by mhc %q", namespacedName(pod).String(), key.String())
+		requests = append(requests, reconcile.Request{NamespacedName: key})
 	}
 	return requests
 }
 
 func (r *ReconcileMachineHealthCheck) mhcRequestsFromMachine(o client.Object) []reconcile.Request {
 	klog.V(4).Infof("Getting MHC requests from machine %q", namespacedName(o).String())
-	machine := &mapiv1.Machine{}
-	if err := r.client.Get(context.Background(),
-		client.ObjectKey{
-			Namespace: o.GetNamespace(),
-			Name:      o.GetName(),
-		},
-		machine,
-	); err != nil {
-		klog.Errorf("No-op: Unable to retrieve machine %q from store: %v", namespacedName(o).String(), err)
+	machine, ok := o.(*mapiv1.Machine)
+	if !ok {
+		klog.Errorf("No-op: Expected a machine, got: %T", o)
 		return nil
 	}
 
-	mhcList := &mapiv1.MachineHealthCheckList{}
-	if err := r.client.List(context.Background(), mhcList); err != nil {
-		klog.Errorf("No-op: Unable to list mhc: %v", err)
+	return r.mhcRequestsMatchingMachine(machine)
+}
+
+// mhcRequestsMatchingMachine returns requests for all MHCs which selectors match the machine.
+// The selectors are read from the MHC index rather than listing all MHCs
+func (r *ReconcileMachineHealthCheck) mhcRequestsMatchingMachine(machine *mapiv1.Machine) []reconcile.Request {
+	if err := r.syncMHCIndex(); err != nil {
+		klog.Errorf("No-op: Unable to sync mhc index: %v", err)
 		return nil
 	}
 
 	var requests []reconcile.Request
-	for k := range mhcList.Items {
-		if hasMatchingLabels(&mhcList.Items[k], machine) {
-			requests = append(requests, reconcile.Request{NamespacedName: namespacedName(&mhcList.Items[k])})
-		}
+	for _, key := range r.mhcIndex.matchingMachine(machine) {
+		requests = append(requests, reconcile.Request{NamespacedName: key})
 	}
 	return requests
 }
 
+// syncMHCIndex populates the MHC index from the cache if it has not been populated yet
+func (r *ReconcileMachineHealthCheck) syncMHCIndex() error {
+	return r.mhcIndex.ensureSynced(func() ([]mapiv1.MachineHealthCheck, error) {
+		mhcList := &mapiv1.MachineHealthCheckList{}
+		if err := r.client.List(context.Background(), mhcList); err != nil {
+			return nil, fmt.Errorf("unable to list mhc: %v", err)
+		}
+		return mhcList.Items, nil
+	})
+}
+
 func (t *target) remediate(r *ReconcileMachineHealthCheck) error {
 	klog.Infof(" %s: start remediation logic", t.string())
 
@@ -500,6 +968,10 @@ func (t *target) remediate(r *ReconcileMachineHealthCheck) error {
 	}
 
 	if !t.hasControllerOwner() {
+		if t.MHC.Spec.UnownedMachineRemediation == mapiv1.UnownedMachineRemediationReplace {
+			return t.remediationStrategyReplace(r)
+		}
+
 		r.recorder.Eventf(
 			&t.Machine,
 			corev1.EventTypeNormal,
@@ -511,6 +983,11 @@ func (t *target) remediate(r *ReconcileMachineHealthCheck) error {
 		return nil
 	}
 
+	return t.deleteMachine(r)
+}
+
+// deleteMachine remediates the target by deleting its machine
+func (t *target) deleteMachine(r *ReconcileMachineHealthCheck) error {
 	key := client.ObjectKey{Namespace: t.Machine.Namespace, Name: t.Machine.Name}
 	machine := &mapiv1.Machine{}
 	if err := r.client.Get(context.TODO(), key, machine); err != nil {
@@ -550,6 +1027,166 @@ func (t *target) remediate(r *ReconcileMachineHealthCheck) error {
 	return nil
 }
 
+// remediationStrategyReplace remediates a machine without a controller owner by creating a
+// replacement machine from its spec. The unhealthy machine is only deleted once the
+// replacement is running, so that no capacity is lost while the replacement is provisioned.
+func (t *target) remediationStrategyReplace(r *ReconcileMachineHealthCheck) error {
+	if !t.Machine.GetDeletionTimestamp().IsZero() {
+		// Delete already initiated
+		return nil
+	}
+
+	// a replacement which has not taken over yet is handled through the machine it replaces
+	if original, ok := t.Machine.Annotations[replacementForAnnotationKey]; ok {
+		originalMachine := &mapiv1.Machine{}
+		err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: t.Machine.Namespace, Name: original}, originalMachine)
+		if err == nil && originalMachine.GetDeletionTimestamp().IsZero() {
+			klog.Infof("%s: machine is replacing %s which still exists, skipping remediation", t.string(), original)
+			return nil
+		}
+		if err != nil && !apimachineryerrors.IsNotFound(err) {
+			return fmt.Errorf("%s: failed to get replaced machine %s: %v", t.string(), original, err)
+		}
+	}
+
+	replacementName, ok := t.Machine.Annotations[replacementMachineAnnotationKey]
+	if !ok {
+		// Record the name of the replacement before creating it, so that
+		// a replacement is never created twice for the same machine
+		replacementName = fmt.Sprintf("%s-%s", t.Machine.Name, utilrand.String(5))
+		if t.Machine.Annotations == nil {
+			t.Machine.Annotations = map[string]string{}
+		}
+		t.Machine.Annotations[replacementMachineAnnotationKey] = replacementName
+		if err := r.client.Update(context.TODO(), &t.Machine); err != nil {
+			return fmt.Errorf("%s: failed to record replacement machine: %v", t.string(), err)
+		}
+	}
+
+	replacement := &mapiv1.Machine{}
+	key := client.ObjectKey{Namespace: t.Machine.Namespace, Name: replacementName}
+	if err := r.client.Get(context.TODO(), key, replacement); err != nil {
+		if !apimachineryerrors.IsNotFound(err) {
+			return fmt.Errorf("%s: failed to get replacement machine %s: %v", t.string(), replacementName, err)
+		}
+
+		replacement = newReplacementMachine(&t.Machine, replacementName)
+		klog.Infof("%s: creating replacement machine %s", t.string(), replacementName)
+		if err := r.client.Create(context.TODO(), replacement); err != nil {
+			r.recorder.Eventf(
+				&t.Machine,
+				corev1.EventTypeWarning,
+				EventReplacementMachineCreationFailed,
+				"Machine %v remediation failed: unable to create replacement Machine %v: %v",
+				t.string(),
+				replacementName,
+				err,
+			)
+			return fmt.Errorf("%s: failed to create replacement machine: %v", t.string(), err)
+		}
+		r.recorder.Eventf(
+			&t.Machine,
+			corev1.EventTypeNormal,
+			EventReplacementMachineCreated,
+			"Created replacement Machine %v for Machine %v, waiting for it to be running",
+			replacementName,
+			t.string(),
+		)
+		return nil
+	}
+
+	switch derefStringPointer(replacement.Status.Phase) {
+	case machinePhaseRunning:
+		klog.Infof("%s: replacement machine %s is running", t.string(), replacementName)
+		return t.deleteMachine(r)
+	case machinePhaseFailed:
+		// Delete the failed replacement and forget about it so that a new one is created
+		klog.Infof("%s: replacement machine %s failed, deleting it", t.string(), replacementName)
+		if err := r.client.Delete(context.TODO(), replacement); err != nil && !apimachineryerrors.IsNotFound(err) {
+			return fmt.Errorf("%s: failed to delete failed replacement machine %s: %v", t.string(), replacementName, err)
+		}
+		delete(t.Machine.Annotations, replacementMachineAnnotationKey)
+		if err := r.client.Update(context.TODO(), &t.Machine); err != nil {
+			return fmt.Errorf("%s: failed to reset replacement machine: %v", t.string(), err)
+		}
+		r.recorder.Eventf(
+			&t.Machine,
+			corev1.EventTypeWarning,
+			EventReplacementMachineFailed,
+			"Replacement Machine %v for Machine %v failed and has been deleted",
+			replacementName,
+			t.string(),
+		)
+		return nil
+	default:
+		// The replacement machine is watched, its progress triggers a new reconcile
+		klog.Infof("%s: waiting for replacement machine %s to be running", t.string(), replacementName)
+		return nil
+	}
+}
+
+// cancelReplacement handles a machine which recovered while being replaced. A replacement
+// which is not running yet is deleted and forgotten, a running replacement has already
+// taken over, so the swap is finished by deleting the recovered machine.
+func (t *target) cancelReplacement(r *ReconcileMachineHealthCheck) error {
+	replacementName := t.Machine.Annotations[replacementMachineAnnotationKey]
+	replacement := &mapiv1.Machine{}
+	key := client.ObjectKey{Namespace: t.Machine.Namespace, Name: replacementName}
+	if err := r.client.Get(context.TODO(), key, replacement); err != nil {
+		if !apimachineryerrors.IsNotFound(err) {
+			return fmt.Errorf("%s: failed to get replacement machine %s: %v", t.string(), replacementName, err)
+		}
+		replacement = nil
+	}
+
+	if replacement != nil && replacement.GetDeletionTimestamp().IsZero() &&
+		derefStringPointer(replacement.Status.Phase) == machinePhaseRunning {
+		klog.Infof("%s: recovered, but replacement machine %s is already running", t.string(), replacementName)
+		return t.deleteMachine(r)
+	}
+
+	if replacement != nil {
+		klog.Infof("%s: recovered, deleting replacement machine %s", t.string(), replacementName)
+		if err := r.client.Delete(context.TODO(), replacement); err != nil && !apimachineryerrors.IsNotFound(err) {
+			return fmt.Errorf("%s: failed to delete replacement machine %s: %v", t.string(), replacementName, err)
+		}
+	}
+	delete(t.Machine.Annotations, replacementMachineAnnotationKey)
+	if err := r.client.Update(context.TODO(), &t.Machine); err != nil {
+		return fmt.Errorf("%s: failed to reset replacement machine: %v", t.string(), err)
+	}
+	r.recorder.Eventf(
+		&t.Machine,
+		corev1.EventTypeNormal,
+		EventReplacementMachineCancelled,
+		"Machine %v recovered, replacement Machine %v has been deleted",
+		t.string(),
+		replacementName,
+	)
+	return nil
+}
+
+// newReplacementMachine returns a machine with the labels and spec of the given machine.
+// Anything tied to the existing instance, such as the providerID, annotations and status, is not copied.
+func newReplacementMachine(machine *mapiv1.Machine, name string) *mapiv1.Machine {
+	replacement := &mapiv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: machine.Namespace,
+			Labels:    map[string]string{},
+			Annotations: map[string]string{
+				replacementForAnnotationKey: machine.Name,
+			},
+		},
+		Spec: *machine.Spec.DeepCopy(),
+	}
+	for k, v := range machine.Labels {
+		replacement.Labels[k] = v
+	}
+	replacement.Spec.ProviderID = nil
+	return replacement
+}
+
 func (t *target) remediationStrategyExternal(r *ReconcileMachineHealthCheck) error {
 	// we already have external annotation on the machine, stop reconcile
 	if _, ok := t.Machine.Annotations[machineExternalAnnotationKey]; ok {
@@ -667,9 +1304,87 @@ func (t *target) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bo
 			nextCheckTimes = append(nextCheckTimes, nextCheck)
 		}
 	}
+
+	// check taints
+	for _, ut := range t.MHC.Spec.UnhealthyTaints {
+		for _, taint := range t.Node.Spec.Taints {
+			if taint.Key != ut.Key || taint.Effect != ut.Effect || (ut.Value != "" && taint.Value != ut.Value) {
+				continue
+			}
+
+			// Without a timestamp the duration of the taint can not be measured
+			if taint.TimeAdded == nil {
+				klog.V(3).Infof("%s: unhealthy: taint %v with effect %v present", t.string(), ut.Key, ut.Effect)
+				return true, time.Duration(0), nil
+			}
+
+			if taint.TimeAdded.Add(ut.Timeout.Duration).Before(now) {
+				klog.V(3).Infof("%s: unhealthy: taint %v with effect %v present longer than %v", t.string(), ut.Key, ut.Effect, ut.Timeout)
+				return true, time.Duration(0), nil
+			}
+
+			durationUnhealthy := now.Sub(taint.TimeAdded.Time)
+			nextCheck := ut.Timeout.Duration - durationUnhealthy + time.Second
+			if nextCheck > 0 {
+				nextCheckTimes = append(nextCheckTimes, nextCheck)
+			}
+		}
+	}
+
+	// check labels and annotations
+	for _, m := range t.MHC.Spec.UnhealthyNodeMetadata {
+		metadata := t.Node.Labels
+		if m.Type == mapiv1.UnhealthyNodeAnnotation {
+			metadata = t.Node.Annotations
+		}
+
+		if value, ok := metadata[m.Key]; ok && (m.Value == "" || value == m.Value) {
+			klog.V(3).Infof("%s: unhealthy: %s %v present", t.string(), strings.ToLower(string(m.Type)), m.Key)
+			return true, time.Duration(0), nil
+		}
+	}
+
+	// check probe pods
+	for _, p := range t.MHC.Spec.UnhealthyProbes {
+		selector, err := metav1.LabelSelectorAsSelector(&p.Selector)
+		if err != nil {
+			return false, time.Duration(0), fmt.Errorf("failed to build probe selector: %v", err)
+		}
+
+		for _, pod := range t.ProbePods {
+			if pod.Namespace != p.Namespace || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+
+			podReady := getPodCondition(&pod, corev1.PodReady)
+			if podReady == nil || podReady.Status == corev1.ConditionTrue {
+				continue
+			}
+
+			if podReady.LastTransitionTime.Add(p.Timeout.Duration).Before(now) {
+				klog.V(3).Infof("%s: unhealthy: probe pod %s/%s not ready longer than %v", t.string(), pod.Namespace, pod.Name, p.Timeout)
+				return true, time.Duration(0), nil
+			}
+
+			durationUnhealthy := now.Sub(podReady.LastTransitionTime.Time)
+			nextCheck := p.Timeout.Duration - durationUnhealthy + time.Second
+			if nextCheck > 0 {
+				nextCheckTimes = append(nextCheckTimes, nextCheck)
+			}
+		}
+	}
 	return false, minDuration(nextCheckTimes), nil
 }
 
+func getPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
 func (t *target) hasControllerOwner() bool {
 	return metav1.GetControllerOf(&t.Machine) != nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -181,13 +182,26 @@ func newFakeReconciler(initObjects ...runtime.Object) *ReconcileMachineHealthChe
 func newFakeReconcilerWithCustomRecorder(recorder record.EventRecorder, initObjects ...runtime.Object) *ReconcileMachineHealthCheck {
 	fakeClient := fake.NewFakeClient(initObjects...)
 	return &ReconcileMachineHealthCheck{
-		client:    fakeClient,
+		client: fakeClient,
+		probePodCaches: newProbePodCaches(func(string) (probePodCache, error) {
+			return fakeProbePodCache{fakeClient}, nil
+		}),
 		scheme:    scheme.Scheme,
 		namespace: namespace,
 		recorder:  recorder,
+		mhcIndex:  newMHCSelectorIndex(),
 	}
 }
 
+// fakeProbePodCache reads probe pods from a fake client, which is always synced
+type fakeProbePodCache struct {
+	client.Reader
+}
+
+func (fakeProbePodCache) WaitForCacheSync(context.Context) bool {
+	return true
+}
+
 type expectedReconcile struct {
 	result reconcile.Result
 	error  bool
@@ -243,6 +257,10 @@ func TestReconcile(t *testing.T) {
 	negativeOne := intstr.FromInt(-1)
 	machineHealthCheckNegativeMaxUnhealthy.Spec.MaxUnhealthy = &negativeOne
 
+	machineHealthCheckUnhealthyRange := maotesting.NewMachineHealthCheck("machineHealthCheckUnhealthyRange")
+	machineHealthCheckUnhealthyRange.Spec.MaxUnhealthy = nil
+	machineHealthCheckUnhealthyRange.Spec.UnhealthyRange = "[2-3]"
+
 	// remediationExternal
 	nodeUnhealthyForTooLong := maotesting.NewNode("nodeUnhealthyForTooLong", false)
 	nodeUnhealthyForTooLong.Annotations = map[string]string{
@@ -262,6 +280,11 @@ func TestReconcile(t *testing.T) {
 		Status: corev1.ConditionTrue,
 	}
 
+	noOverlapCondition := mapiv1beta1.Condition{
+		Type:   mapiv1beta1.NoOverlapCondition,
+		Status: corev1.ConditionTrue,
+	}
+
 	testCases := []struct {
 		testCase       string
 		machine        *mapiv1beta1.Machine
@@ -287,6 +310,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -306,6 +330,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 1,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -328,6 +353,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -347,6 +373,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -366,6 +393,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -385,6 +413,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -406,6 +435,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -425,6 +455,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -444,6 +475,7 @@ func TestReconcile(t *testing.T) {
 				RemediationsAllowed: 0,
 				Conditions: mapiv1beta1.Conditions{
 					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -471,6 +503,53 @@ func TestReconcile(t *testing.T) {
 						Reason:   mapiv1beta1.TooManyUnhealthyReason,
 						Message:  "Remediation is not allowed, the number of not started or unhealthy machines exceeds maxUnhealthy (total: 1, unhealthy: 1, maxUnhealthy: -1)",
 					},
+					noOverlapCondition,
+				},
+			},
+		},
+		{
+			testCase: "machine unhealthy below MHC unhealthyRange",
+			machine:  machineUnhealthyForTooLong,
+			node:     nodeUnhealthyForTooLong,
+			mhc:      machineHealthCheckUnhealthyRange,
+			expected: expectedReconcile{
+				result: reconcile.Result{},
+				error:  false,
+			},
+			expectedEvents: []string{},
+			expectedStatus: &mapiv1beta1.MachineHealthCheckStatus{
+				ExpectedMachines:    IntPtr(1),
+				CurrentHealthy:      IntPtr(0),
+				RemediationsAllowed: 0,
+				Conditions: mapiv1beta1.Conditions{
+					{
+						Type:     mapiv1beta1.RemediationAllowedCondition,
+						Status:   corev1.ConditionFalse,
+						Severity: mapiv1beta1.ConditionSeverityInfo,
+						Reason:   mapiv1beta1.TooFewUnhealthyReason,
+						Message:  "Remediation is not allowed, the number of not started or unhealthy machines is below unhealthyRange (total: 1, unhealthy: 1, unhealthyRange: [2-3])",
+					},
+					noOverlapCondition,
+				},
+			},
+		},
+		{
+			testCase: "machine healthy below MHC unhealthyRange",
+			machine:  machineWithNodeHealthy,
+			node:     nodeHealthy,
+			mhc:      machineHealthCheckUnhealthyRange,
+			expected: expectedReconcile{
+				result: reconcile.Result{},
+				error:  false,
+			},
+			expectedEvents: []string{},
+			expectedStatus: &mapiv1beta1.MachineHealthCheckStatus{
+				ExpectedMachines:    IntPtr(1),
+				CurrentHealthy:      IntPtr(1),
+				RemediationsAllowed: 3,
+				Conditions: mapiv1beta1.Conditions{
+					remediationAllowedCondition,
+					noOverlapCondition,
 				},
 			},
 		},
@@ -525,6 +604,368 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcileOverlappingMHCs(t *testing.T) {
+	ctx := context.Background()
+
+	nodeUnhealthyForTooLong := maotesting.NewNode("nodeUnhealthyForTooLong", false)
+	machineUnhealthyForTooLong := maotesting.NewMachine("machineUnhealthyForTooLong", nodeUnhealthyForTooLong.Name)
+
+	lowPriorityMHC := maotesting.NewMachineHealthCheck("lowPriority")
+	highPriorityMHC := maotesting.NewMachineHealthCheck("highPriority")
+	highPriorityMHC.Spec.Priority = 10
+	noMatchMHC := maotesting.NewMachineHealthCheck("noMatch")
+	noMatchMHC.Spec.Selector = metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"no": "match",
+		},
+	}
+
+	testCases := []struct {
+		testCase          string
+		mhc               *mapiv1beta1.MachineHealthCheck
+		otherMHCs         []*mapiv1beta1.MachineHealthCheck
+		expectedEvents    []string
+		expectedCondition mapiv1beta1.Condition
+	}{
+		{
+			testCase:       "no overlap",
+			mhc:            lowPriorityMHC,
+			otherMHCs:      []*mapiv1beta1.MachineHealthCheck{noMatchMHC},
+			expectedEvents: []string{EventMachineDeleted},
+			expectedCondition: mapiv1beta1.Condition{
+				Type:   mapiv1beta1.NoOverlapCondition,
+				Status: corev1.ConditionTrue,
+			},
+		},
+		{
+			testCase:       "overlap with a lower priority MHC",
+			mhc:            highPriorityMHC,
+			otherMHCs:      []*mapiv1beta1.MachineHealthCheck{lowPriorityMHC, noMatchMHC},
+			expectedEvents: []string{EventOverlappingMachineHealthChecks, EventMachineDeleted},
+			expectedCondition: mapiv1beta1.Condition{
+				Type:     mapiv1beta1.NoOverlapCondition,
+				Status:   corev1.ConditionFalse,
+				Severity: mapiv1beta1.ConditionSeverityWarning,
+				Reason:   mapiv1beta1.OverlappingMachineHealthChecksReason,
+				Message:  "1 machines are also selected by MachineHealthChecks [lowPriority]",
+			},
+		},
+		{
+			testCase:       "overlap with a higher priority MHC",
+			mhc:            lowPriorityMHC,
+			otherMHCs:      []*mapiv1beta1.MachineHealthCheck{highPriorityMHC, noMatchMHC},
+			expectedEvents: []string{EventOverlappingMachineHealthChecks, EventSkippedLowerPriority},
+			expectedCondition: mapiv1beta1.Condition{
+				Type:     mapiv1beta1.NoOverlapCondition,
+				Status:   corev1.ConditionFalse,
+				Severity: mapiv1beta1.ConditionSeverityWarning,
+				Reason:   mapiv1beta1.OverlappingMachineHealthChecksReason,
+				Message:  "1 machines are also selected by MachineHealthChecks [highPriority]",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objects := []runtime.Object{tc.mhc.DeepCopy(), machineUnhealthyForTooLong.DeepCopy(), nodeUnhealthyForTooLong.DeepCopy()}
+			for _, mhc := range tc.otherMHCs {
+				objects = append(objects, mhc.DeepCopy())
+			}
+			recorder := record.NewFakeRecorder(2)
+			r := newFakeReconcilerWithCustomRecorder(recorder, objects...)
+
+			request := reconcile.Request{
+				NamespacedName: namespacedName(tc.mhc),
+			}
+			_, err := r.Reconcile(ctx, request)
+			g.Expect(err).ToNot(HaveOccurred())
+			assertEvents(t, tc.testCase, tc.expectedEvents, recorder.Events)
+
+			mhc := &mapiv1beta1.MachineHealthCheck{}
+			g.Expect(r.client.Get(ctx, request.NamespacedName, mhc)).To(Succeed())
+			condition := conditions.Get(mhc, mapiv1beta1.NoOverlapCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(*condition).To(conditions.MatchCondition(tc.expectedCondition))
+		})
+	}
+}
+
+func TestReconcileConcurrently(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// every MachineHealthCheck selects a single unhealthy machine, so that the MachineHealthChecks
+	// can be reconciled by concurrent workers as they would with --max-concurrent-reconciles
+	const groups = 10
+	var objects []runtime.Object
+	var mhcs []*mapiv1beta1.MachineHealthCheck
+	var machines []*mapiv1beta1.Machine
+	for i := 0; i < groups; i++ {
+		labels := map[string]string{"group": fmt.Sprintf("%d", i)}
+		node := maotesting.NewNode(fmt.Sprintf("node-%d", i), false)
+		machine := maotesting.NewMachine(fmt.Sprintf("machine-%d", i), node.Name)
+		machine.Labels = labels
+		mhc := maotesting.NewMachineHealthCheck(fmt.Sprintf("mhc-%d", i))
+		mhc.Spec.Selector = *maotesting.NewSelector(labels)
+
+		objects = append(objects, node, machine, mhc)
+		mhcs = append(mhcs, mhc)
+		machines = append(machines, machine)
+	}
+	recorder := record.NewFakeRecorder(10 * groups)
+	r := newFakeReconcilerWithCustomRecorder(recorder, objects...)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*groups)
+	for i := 0; i < groups; i++ {
+		wg.Add(2)
+		go func(mhc *mapiv1beta1.MachineHealthCheck) {
+			defer wg.Done()
+			if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName(mhc)}); err != nil {
+				errs <- err
+			}
+		}(mhcs[i])
+		// machine events are mapped while MachineHealthChecks are reconciled
+		go func(machine *mapiv1beta1.Machine) {
+			defer wg.Done()
+			r.mhcRequestsFromMachine(machine)
+		}(machines[i])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < groups; i++ {
+		machine := &mapiv1beta1.Machine{}
+		err := r.client.Get(ctx, namespacedName(machines[i]), machine)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "expected machine %q to be remediated, got error: %v", machines[i].Name, err)
+
+		mhc := &mapiv1beta1.MachineHealthCheck{}
+		g.Expect(r.client.Get(ctx, namespacedName(mhcs[i]), mhc)).To(Succeed())
+		g.Expect(mhc.Status.ExpectedMachines).To(Equal(IntPtr(1)))
+		g.Expect(mhc.Status.CurrentHealthy).To(Equal(IntPtr(0)))
+
+		// every MachineHealthCheck only maps its own machine
+		requests := r.mhcRequestsFromMachine(machines[i])
+		g.Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: namespacedName(mhcs[i])}))
+	}
+}
+
+func TestReconcileRemediationWindows(t *testing.T) {
+	ctx := context.Background()
+	later := time.Now().UTC().Add(2 * time.Hour)
+
+	testCases := []struct {
+		testCase               string
+		windows                []mapiv1beta1.RemediationWindow
+		expectedMachineDeleted bool
+		expectedCondition      *mapiv1beta1.Condition
+		expectedEvents         []string
+	}{
+		{
+			testCase: "remediates inside of a window",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedMachineDeleted: true,
+			expectedCondition:      conditions.TrueCondition(mapiv1beta1.RemediationAllowedCondition),
+			expectedEvents:         []string{EventMachineDeleted},
+		},
+		{
+			testCase: "defers remediation outside of a window",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: fmt.Sprintf("%d %d * * *", later.Minute(), later.Hour()), Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedMachineDeleted: false,
+			expectedCondition: conditions.FalseCondition(
+				mapiv1beta1.RemediationAllowedCondition,
+				mapiv1beta1.OutsideRemediationWindowReason,
+				mapiv1beta1.ConditionSeverityInfo,
+				"Remediation is deferred until the next remediation window opens at %s", later.Truncate(time.Minute).Format(time.RFC3339),
+			),
+			expectedEvents: []string{EventRemediationDeferred},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			node := maotesting.NewNode("node", false)
+			node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+			machine := maotesting.NewMachine("machine", node.Name)
+			mhc := maotesting.NewMachineHealthCheck("mhc")
+			mhc.Spec.RemediationWindows = tc.windows
+
+			recorder := record.NewFakeRecorder(2)
+			r := newFakeReconcilerWithCustomRecorder(recorder, mhc, machine, node)
+
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName(mhc)})
+			g.Expect(err).ToNot(HaveOccurred())
+			assertEvents(t, tc.testCase, tc.expectedEvents, recorder.Events)
+
+			err = r.client.Get(ctx, namespacedName(machine), &mapiv1beta1.Machine{})
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(tc.expectedMachineDeleted))
+			if !tc.expectedMachineDeleted {
+				g.Expect(result.RequeueAfter).To(BeNumerically(">", time.Hour))
+				g.Expect(result.RequeueAfter).To(BeNumerically("<=", 2*time.Hour))
+			}
+
+			g.Expect(r.client.Get(ctx, namespacedName(mhc), mhc)).To(Succeed())
+			condition := conditions.Get(mhc, mapiv1beta1.RemediationAllowedCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(*condition).To(conditions.MatchCondition(*tc.expectedCondition))
+			if !tc.expectedMachineDeleted {
+				g.Expect(mhc.Status.RemediationsAllowed).To(BeZero())
+			}
+		})
+	}
+}
+
+func TestTimeUntilRemediationWindow(t *testing.T) {
+	// Wednesday
+	now := time.Date(2021, time.January, 13, 10, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		testCase         string
+		windows          []mapiv1beta1.RemediationWindow
+		expectedWaitTime time.Duration
+		expectedError    bool
+	}{
+		{
+			testCase:         "no windows",
+			expectedWaitTime: 0,
+		},
+		{
+			testCase: "inside of a window",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 10 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedWaitTime: 0,
+		},
+		{
+			testCase: "after a window closed",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 10 * * *", Duration: metav1.Duration{Duration: 30 * time.Minute}},
+			},
+			expectedWaitTime: 23*time.Hour + 30*time.Minute,
+		},
+		{
+			testCase: "the earliest of several windows",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: time.Hour}},
+				{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedWaitTime: 11*time.Hour + 30*time.Minute,
+		},
+		{
+			testCase: "in a time zone",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 10 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Etc/GMT-1"},
+			},
+			// 10:00 in GMT+1 is 09:00 UTC, the window closed at 10:00 UTC
+			expectedWaitTime: 22*time.Hour + 30*time.Minute,
+		},
+		{
+			testCase: "an invalid schedule",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 10 * *", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedError: true,
+		},
+		{
+			testCase: "a window which never opens",
+			windows: []mapiv1beta1.RemediationWindow{
+				{Schedule: "0 0 30 2 *", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tc.windows != nil && tc.windows[0].TimeZone != "" {
+				if _, err := time.LoadLocation(tc.windows[0].TimeZone); err != nil {
+					t.Skipf("Time zone database not available: %v", err)
+				}
+			}
+
+			mhc := maotesting.NewMachineHealthCheck("mhc")
+			mhc.Spec.RemediationWindows = tc.windows
+
+			waitTime, err := timeUntilRemediationWindow(mhc, now)
+			if tc.expectedError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(waitTime).To(Equal(tc.expectedWaitTime))
+		})
+	}
+}
+
+func TestHigherPriorityMHC(t *testing.T) {
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	mhc.Spec.Priority = 5
+
+	samePriority := maotesting.NewMachineHealthCheck("samePriority")
+	samePriority.Spec.Priority = 5
+	lowerPriority := maotesting.NewMachineHealthCheck("lowerPriority")
+	lowerPriority.Spec.Priority = 1
+	higherPriority := maotesting.NewMachineHealthCheck("higherPriority")
+	higherPriority.Spec.Priority = 10
+	highestPriority := maotesting.NewMachineHealthCheck("highestPriority")
+	highestPriority.Spec.Priority = 20
+
+	testCases := []struct {
+		testCase    string
+		overlapping []mapiv1beta1.MachineHealthCheck
+		expected    string
+	}{
+		{
+			testCase:    "no overlapping MHCs",
+			overlapping: nil,
+			expected:    "",
+		},
+		{
+			testCase:    "same or lower priority",
+			overlapping: []mapiv1beta1.MachineHealthCheck{*samePriority, *lowerPriority},
+			expected:    "",
+		},
+		{
+			testCase:    "higher priority",
+			overlapping: []mapiv1beta1.MachineHealthCheck{*lowerPriority, *higherPriority},
+			expected:    higherPriority.Name,
+		},
+		{
+			testCase:    "highest priority wins",
+			overlapping: []mapiv1beta1.MachineHealthCheck{*higherPriority, *highestPriority, *samePriority},
+			expected:    highestPriority.Name,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			preferred := higherPriorityMHC(mhc, tc.overlapping)
+			if tc.expected == "" {
+				if preferred != nil {
+					t.Errorf("Expected no preferred MHC, got: %v", preferred.Name)
+				}
+				return
+			}
+			if preferred == nil || preferred.Name != tc.expected {
+				t.Errorf("Expected preferred MHC %v, got: %v", tc.expected, preferred)
+			}
+		})
+	}
+}
+
 func TestHasControllerOwner(t *testing.T) {
 	machineWithMachineSet := maotesting.NewMachine("machineWithMachineSet", "node")
 
@@ -624,6 +1065,52 @@ func TestApplyRemediationExternal(t *testing.T) {
 	}
 }
 
+func TestMHCRequestsFromMHC(t *testing.T) {
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	otherMHC := maotesting.NewMachineHealthCheck("other")
+	otherNamespaceMHC := maotesting.NewMachineHealthCheck("otherNamespace")
+	otherNamespaceMHC.Namespace = "other-namespace"
+
+	testCases := []struct {
+		testCase         string
+		objects          []runtime.Object
+		expectedRequests []reconcile.Request
+	}{
+		{
+			testCase: "only the MHC itself",
+			objects:  []runtime.Object{mhc, otherNamespaceMHC},
+			expectedRequests: []reconcile.Request{
+				{NamespacedName: namespacedName(mhc)},
+			},
+		},
+		{
+			testCase: "the MHC and its peers in the namespace",
+			objects:  []runtime.Object{mhc, otherMHC, otherNamespaceMHC},
+			expectedRequests: []reconcile.Request{
+				{NamespacedName: namespacedName(mhc)},
+				{NamespacedName: namespacedName(otherMHC)},
+			},
+		},
+		{
+			testCase: "a deleted MHC still requeues its peers",
+			objects:  []runtime.Object{otherMHC},
+			expectedRequests: []reconcile.Request{
+				{NamespacedName: namespacedName(mhc)},
+				{NamespacedName: namespacedName(otherMHC)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			r := newFakeReconciler(tc.objects...)
+			requests := r.mhcRequestsFromMHC(mhc)
+			if !reflect.DeepEqual(requests, tc.expectedRequests) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedRequests, requests)
+			}
+		})
+	}
+}
+
 func TestMHCRequestsFromMachine(t *testing.T) {
 	testCases := []struct {
 		testCase         string
@@ -1327,27 +1814,129 @@ func TestGetTargetsFromMHC(t *testing.T) {
 	}
 }
 
-func TestGetNodeFromMachine(t *testing.T) {
+func TestGetProbePodsFromMHC(t *testing.T) {
+	probeSelector := metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"app": "probe",
+		},
+	}
+	newPod := func(name, namespace, nodeName string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    podLabels,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+			},
+		}
+	}
+	probeLabels := map[string]string{"app": "probe"}
+
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	mhc.Spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+		{Namespace: "probes", Selector: probeSelector, Timeout: metav1.Duration{Duration: time.Minute}},
+	}
+
+	r := newFakeReconciler(
+		newPod("probe-a", "probes", "node-a", probeLabels),
+		newPod("probe-b", "probes", "node-b", probeLabels),
+		newPod("unscheduled", "probes", "", probeLabels),
+		newPod("other-app", "probes", "node-a", map[string]string{"app": "other"}),
+		newPod("other-namespace", "other", "node-a", probeLabels),
+	)
+
+	g := NewWithT(t)
+	probePods, err := r.getProbePodsFromMHC(*mhc)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(probePods).To(HaveLen(2))
+	g.Expect(probePods["node-a"]).To(HaveLen(1))
+	g.Expect(probePods["node-a"][0].Name).To(Equal("probe-a"))
+	g.Expect(probePods["node-b"]).To(HaveLen(1))
+	g.Expect(probePods["node-b"][0].Name).To(Equal("probe-b"))
+
+	probePods, err = r.getProbePodsFromMHC(*maotesting.NewMachineHealthCheck("noProbes"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(probePods).To(BeEmpty())
+}
+
+func TestMHCRequestsFromPod(t *testing.T) {
+	probeLabels := map[string]string{"app": "probe"}
+	newPod := func(namespace, nodeName string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "probe",
+				Namespace: namespace,
+				Labels:    podLabels,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+			},
+		}
+	}
+
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	mhc.Spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+		{Namespace: "probes", Selector: metav1.LabelSelector{MatchLabels: probeLabels}, Timeout: metav1.Duration{Duration: time.Minute}},
+	}
+	noProbesMHC := maotesting.NewMachineHealthCheck("noProbes")
+
 	testCases := []struct {
-		testCase      string
-		machine       *mapiv1beta1.Machine
-		node          *corev1.Node
-		expectedNode  *corev1.Node
-		expectedError bool
+		testCase         string
+		pod              *corev1.Pod
+		expectedRequests []reconcile.Request
 	}{
 		{
-			testCase: "match",
-			machine: &mapiv1beta1.Machine{
-				TypeMeta: metav1.TypeMeta{Kind: "Machine"},
-				ObjectMeta: metav1.ObjectMeta{
-					Annotations:     make(map[string]string),
-					Name:            "machine",
-					Namespace:       namespace,
-					Labels:          map[string]string{"foo": "bar"},
-					OwnerReferences: []metav1.OwnerReference{{Kind: "MachineSet"}},
-				},
-				Spec: mapiv1beta1.MachineSpec{},
-				Status: mapiv1beta1.MachineStatus{
+			testCase:         "selected probe pod",
+			pod:              newPod("probes", "node", probeLabels),
+			expectedRequests: []reconcile.Request{{NamespacedName: namespacedName(mhc)}},
+		},
+		{
+			testCase: "unscheduled probe pod",
+			pod:      newPod("probes", "", probeLabels),
+		},
+		{
+			testCase: "pod in another namespace",
+			pod:      newPod("other", "node", probeLabels),
+		},
+		{
+			testCase: "pod not matching the selector",
+			pod:      newPod("probes", "node", map[string]string{"app": "other"}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			r := newFakeReconciler(mhc, noProbesMHC)
+			requests := r.mhcRequestsFromPod(tc.pod)
+			if !reflect.DeepEqual(requests, tc.expectedRequests) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestGetNodeFromMachine(t *testing.T) {
+	testCases := []struct {
+		testCase      string
+		machine       *mapiv1beta1.Machine
+		node          *corev1.Node
+		expectedNode  *corev1.Node
+		expectedError bool
+	}{
+		{
+			testCase: "match",
+			machine: &mapiv1beta1.Machine{
+				TypeMeta: metav1.TypeMeta{Kind: "Machine"},
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:     make(map[string]string),
+					Name:            "machine",
+					Namespace:       namespace,
+					Labels:          map[string]string{"foo": "bar"},
+					OwnerReferences: []metav1.OwnerReference{{Kind: "MachineSet"}},
+				},
+				Spec: mapiv1beta1.MachineSpec{},
+				Status: mapiv1beta1.MachineStatus{
 					NodeRef: &corev1.ObjectReference{
 						Name:      "node",
 						Namespace: metav1.NamespaceNone,
@@ -1858,6 +2447,235 @@ func TestNeedsRemediation(t *testing.T) {
 	}
 }
 
+func TestNeedsRemediationNodeSignals(t *testing.T) {
+	now := time.Now()
+	probeSelector := metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"app": "probe",
+		},
+	}
+	newProbePod := func(ready corev1.ConditionStatus, lastTransition time.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "probe",
+				Namespace: "probes",
+				Labels: map[string]string{
+					"app": "probe",
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "node",
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{
+						Type:               corev1.PodReady,
+						Status:             ready,
+						LastTransitionTime: metav1.Time{Time: lastTransition},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		testCase                 string
+		modifyNode               func(*corev1.Node)
+		modifySpec               func(*mapiv1beta1.MachineHealthCheckSpec)
+		probePods                []corev1.Pod
+		expectedNeedsRemediation bool
+		expectedNextCheck        time.Duration
+		expectedError            bool
+	}{
+		{
+			testCase: "healthy: taint not present",
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyTaints = []mapiv1beta1.UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			expectedNeedsRemediation: false,
+		},
+		{
+			testCase: "unhealthy: taint present longer than timeout",
+			modifyNode: func(node *corev1.Node) {
+				node.Spec.Taints = []corev1.Taint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, TimeAdded: &metav1.Time{Time: now.Add(-2 * time.Minute)}},
+				}
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyTaints = []mapiv1beta1.UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			expectedNeedsRemediation: true,
+		},
+		{
+			testCase: "healthy: taint present shorter than timeout",
+			modifyNode: func(node *corev1.Node) {
+				node.Spec.Taints = []corev1.Taint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, TimeAdded: &metav1.Time{Time: now}},
+				}
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyTaints = []mapiv1beta1.UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			expectedNeedsRemediation: false,
+			expectedNextCheck:        time.Minute,
+		},
+		{
+			testCase: "healthy: taint with a different effect",
+			modifyNode: func(node *corev1.Node) {
+				node.Spec.Taints = []corev1.Taint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoSchedule},
+				}
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyTaints = []mapiv1beta1.UnhealthyTaint{
+					{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			expectedNeedsRemediation: false,
+		},
+		{
+			testCase: "unhealthy: taint without timestamp",
+			modifyNode: func(node *corev1.Node) {
+				node.Spec.Taints = []corev1.Taint{
+					{Key: "example.com/broken", Value: "disk", Effect: corev1.TaintEffectNoSchedule},
+				}
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyTaints = []mapiv1beta1.UnhealthyTaint{
+					{Key: "example.com/broken", Value: "disk", Effect: corev1.TaintEffectNoSchedule, Timeout: metav1.Duration{Duration: time.Hour}},
+				}
+			},
+			expectedNeedsRemediation: true,
+		},
+		{
+			testCase: "unhealthy: label present",
+			modifyNode: func(node *corev1.Node) {
+				node.Labels["example.com/broken"] = "true"
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyNodeMetadata = []mapiv1beta1.UnhealthyNodeMetadata{
+					{Type: mapiv1beta1.UnhealthyNodeLabel, Key: "example.com/broken"},
+				}
+			},
+			expectedNeedsRemediation: true,
+		},
+		{
+			testCase: "healthy: label present with a different value",
+			modifyNode: func(node *corev1.Node) {
+				node.Labels["example.com/broken"] = "false"
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyNodeMetadata = []mapiv1beta1.UnhealthyNodeMetadata{
+					{Type: mapiv1beta1.UnhealthyNodeLabel, Key: "example.com/broken", Value: "true"},
+				}
+			},
+			expectedNeedsRemediation: false,
+		},
+		{
+			testCase: "healthy: annotation expected but only a label is present",
+			modifyNode: func(node *corev1.Node) {
+				node.Labels["example.com/broken"] = "true"
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyNodeMetadata = []mapiv1beta1.UnhealthyNodeMetadata{
+					{Type: mapiv1beta1.UnhealthyNodeAnnotation, Key: "example.com/broken"},
+				}
+			},
+			expectedNeedsRemediation: false,
+		},
+		{
+			testCase: "unhealthy: annotation present",
+			modifyNode: func(node *corev1.Node) {
+				node.Annotations["example.com/broken"] = "true"
+			},
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyNodeMetadata = []mapiv1beta1.UnhealthyNodeMetadata{
+					{Type: mapiv1beta1.UnhealthyNodeAnnotation, Key: "example.com/broken", Value: "true"},
+				}
+			},
+			expectedNeedsRemediation: true,
+		},
+		{
+			testCase: "healthy: probe pod ready",
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+					{Namespace: "probes", Selector: probeSelector, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			probePods:                []corev1.Pod{newProbePod(corev1.ConditionTrue, now.Add(-time.Hour))},
+			expectedNeedsRemediation: false,
+		},
+		{
+			testCase: "unhealthy: probe pod not ready longer than timeout",
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+					{Namespace: "probes", Selector: probeSelector, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			probePods:                []corev1.Pod{newProbePod(corev1.ConditionFalse, now.Add(-2*time.Minute))},
+			expectedNeedsRemediation: true,
+		},
+		{
+			testCase: "healthy: probe pod not ready shorter than timeout",
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+					{Namespace: "probes", Selector: probeSelector, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			probePods:                []corev1.Pod{newProbePod(corev1.ConditionFalse, now)},
+			expectedNeedsRemediation: false,
+			expectedNextCheck:        time.Minute,
+		},
+		{
+			testCase: "healthy: not ready pod in another namespace",
+			modifySpec: func(spec *mapiv1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyProbes = []mapiv1beta1.UnhealthyProbe{
+					{Namespace: "other", Selector: probeSelector, Timeout: metav1.Duration{Duration: time.Minute}},
+				}
+			},
+			probePods:                []corev1.Pod{newProbePod(corev1.ConditionFalse, now.Add(-2*time.Minute))},
+			expectedNeedsRemediation: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			node := maotesting.NewNode("node", true)
+			if tc.modifyNode != nil {
+				tc.modifyNode(node)
+			}
+			mhc := maotesting.NewMachineHealthCheck("test")
+			if tc.modifySpec != nil {
+				tc.modifySpec(&mhc.Spec)
+			}
+			target := &target{
+				Machine:   *maotesting.NewMachine("test", node.Name),
+				Node:      node,
+				MHC:       *mhc,
+				ProbePods: tc.probePods,
+			}
+
+			needsRemediation, nextCheck, err := target.needsRemediation(defaultNodeStartupTimeout)
+			if needsRemediation != tc.expectedNeedsRemediation {
+				t.Errorf("Case: %v. Got: %v, expected: %v", tc.testCase, needsRemediation, tc.expectedNeedsRemediation)
+			}
+			// the next check is computed against now() again, allow for a margin
+			if nextCheck > tc.expectedNextCheck+time.Second || nextCheck < tc.expectedNextCheck-time.Second {
+				t.Errorf("Case: %v. Got: %v, expected: %v", tc.testCase, nextCheck, tc.expectedNextCheck)
+			}
+			if tc.expectedError != (err != nil) {
+				t.Errorf("Case: %v. Got: %v, expected error: %v", tc.testCase, err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func TestMinDuration(t *testing.T) {
 	testCases := []struct {
 		testCase  string
@@ -2070,6 +2888,208 @@ func TestRemediate(t *testing.T) {
 	}
 }
 
+func TestRemediationStrategyReplace(t *testing.T) {
+	newUnownedMachine := func(name string, annotations map[string]string, phase string) *mapiv1beta1.Machine {
+		machine := maotesting.NewMachine(name, "")
+		machine.OwnerReferences = nil
+		machine.Spec.ProviderID = pointer.StringPtr("provider://" + name)
+		for k, v := range annotations {
+			machine.Annotations[k] = v
+		}
+		if phase != "" {
+			machine.Status.Phase = pointer.StringPtr(phase)
+		}
+		return machine
+	}
+
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	mhc.Spec.UnownedMachineRemediation = mapiv1beta1.UnownedMachineRemediationReplace
+
+	testCases := []struct {
+		testCase                  string
+		machine                   *mapiv1beta1.Machine
+		objects                   []runtime.Object
+		expectedMachineDeleted    bool
+		expectedReplacement       bool
+		expectedReplacementPhase  string
+		expectedReplacementRecord bool
+		expectedEvents            []string
+	}{
+		{
+			testCase:                  "creates a replacement",
+			machine:                   newUnownedMachine("unhealthy", nil, ""),
+			expectedReplacement:       true,
+			expectedReplacementRecord: true,
+			expectedEvents:            []string{EventReplacementMachineCreated},
+		},
+		{
+			testCase: "waits for the replacement to be running",
+			machine:  newUnownedMachine("unhealthy", map[string]string{replacementMachineAnnotationKey: "replacement"}, ""),
+			objects: []runtime.Object{
+				newUnownedMachine("replacement", map[string]string{replacementForAnnotationKey: "unhealthy"}, "Provisioned"),
+			},
+			expectedReplacement:       true,
+			expectedReplacementPhase:  "Provisioned",
+			expectedReplacementRecord: true,
+			expectedEvents:            []string{},
+		},
+		{
+			testCase: "deletes the machine once the replacement is running",
+			machine:  newUnownedMachine("unhealthy", map[string]string{replacementMachineAnnotationKey: "replacement"}, ""),
+			objects: []runtime.Object{
+				newUnownedMachine("replacement", map[string]string{replacementForAnnotationKey: "unhealthy"}, machinePhaseRunning),
+			},
+			expectedMachineDeleted:   true,
+			expectedReplacement:      true,
+			expectedReplacementPhase: machinePhaseRunning,
+			expectedEvents:           []string{EventMachineDeleted},
+		},
+		{
+			testCase: "deletes a failed replacement",
+			machine:  newUnownedMachine("unhealthy", map[string]string{replacementMachineAnnotationKey: "replacement"}, ""),
+			objects: []runtime.Object{
+				newUnownedMachine("replacement", map[string]string{replacementForAnnotationKey: "unhealthy"}, machinePhaseFailed),
+			},
+			expectedEvents: []string{EventReplacementMachineFailed},
+		},
+		{
+			testCase: "skips a replacement while the replaced machine exists",
+			machine:  newUnownedMachine("unhealthy", map[string]string{replacementForAnnotationKey: "original"}, ""),
+			objects: []runtime.Object{
+				newUnownedMachine("original", map[string]string{replacementMachineAnnotationKey: "unhealthy"}, ""),
+			},
+			expectedEvents: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objects := append([]runtime.Object{tc.machine}, tc.objects...)
+			recorder := record.NewFakeRecorder(2)
+			r := newFakeReconcilerWithCustomRecorder(recorder, objects...)
+
+			target := &target{Machine: *tc.machine, MHC: *mhc}
+			g.Expect(target.remediate(r)).To(Succeed())
+			assertEvents(t, tc.testCase, tc.expectedEvents, recorder.Events)
+
+			machine := &mapiv1beta1.Machine{}
+			err := r.client.Get(context.TODO(), namespacedName(tc.machine), machine)
+			if tc.expectedMachineDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			replacementName, ok := machine.Annotations[replacementMachineAnnotationKey]
+			g.Expect(ok).To(Equal(tc.expectedReplacementRecord))
+
+			machineList := &mapiv1beta1.MachineList{}
+			g.Expect(r.client.List(context.TODO(), machineList)).To(Succeed())
+			var replacement *mapiv1beta1.Machine
+			for i := range machineList.Items {
+				if machineList.Items[i].Annotations[replacementForAnnotationKey] == tc.machine.Name {
+					replacement = &machineList.Items[i]
+				}
+			}
+			if !tc.expectedReplacement {
+				g.Expect(replacement).To(BeNil())
+				return
+			}
+			g.Expect(replacement).ToNot(BeNil())
+			g.Expect(replacement.Name).To(Equal(replacementName))
+			g.Expect(replacement.Labels).To(Equal(tc.machine.Labels))
+			if len(tc.objects) == 0 {
+				// a newly created replacement must not reuse the instance of the replaced machine
+				g.Expect(replacement.Spec.ProviderID).To(BeNil())
+			}
+			g.Expect(derefStringPointer(replacement.Status.Phase)).To(Equal(tc.expectedReplacementPhase))
+		})
+	}
+}
+
+func TestCancelReplacement(t *testing.T) {
+	newUnownedMachine := func(name string, annotations map[string]string, phase string) *mapiv1beta1.Machine {
+		machine := maotesting.NewMachine(name, name)
+		machine.OwnerReferences = nil
+		for k, v := range annotations {
+			machine.Annotations[k] = v
+		}
+		if phase != "" {
+			machine.Status.Phase = pointer.StringPtr(phase)
+		}
+		return machine
+	}
+
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	mhc.Spec.UnownedMachineRemediation = mapiv1beta1.UnownedMachineRemediationReplace
+
+	testCases := []struct {
+		testCase                  string
+		objects                   []runtime.Object
+		expectedMachineDeleted    bool
+		expectedReplacement       bool
+		expectedReplacementRecord bool
+		expectedEvents            []string
+	}{
+		{
+			testCase: "deletes a provisioning replacement",
+			objects: []runtime.Object{
+				newUnownedMachine("replacement", map[string]string{replacementForAnnotationKey: "recovered"}, "Provisioning"),
+			},
+			expectedEvents: []string{EventReplacementMachineCancelled},
+		},
+		{
+			testCase:       "forgets a missing replacement",
+			expectedEvents: []string{EventReplacementMachineCancelled},
+		},
+		{
+			testCase: "finishes the swap once the replacement is running",
+			objects: []runtime.Object{
+				newUnownedMachine("replacement", map[string]string{replacementForAnnotationKey: "recovered"}, machinePhaseRunning),
+			},
+			expectedMachineDeleted: true,
+			expectedReplacement:    true,
+			expectedEvents:         []string{EventMachineDeleted},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machine := newUnownedMachine("recovered", map[string]string{replacementMachineAnnotationKey: "replacement"}, machinePhaseRunning)
+			objects := append([]runtime.Object{machine}, tc.objects...)
+			recorder := record.NewFakeRecorder(2)
+			r := newFakeReconcilerWithCustomRecorder(recorder, objects...)
+
+			targets := []target{{Machine: *machine, Node: maotesting.NewNode("recovered", true), MHC: *mhc}}
+			currentHealthy, needRemediationTargets, _, errList := r.healthCheckTargets(targets, defaultNodeStartupTimeout)
+			g.Expect(errList).To(BeEmpty())
+			g.Expect(currentHealthy).To(Equal(1))
+			g.Expect(needRemediationTargets).To(BeEmpty())
+			assertEvents(t, tc.testCase, tc.expectedEvents, recorder.Events)
+
+			err := r.client.Get(context.TODO(), namespacedName(machine), machine)
+			if tc.expectedMachineDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(machine.Annotations).ToNot(HaveKey(replacementMachineAnnotationKey))
+			}
+
+			replacement := &mapiv1beta1.Machine{}
+			err = r.client.Get(context.TODO(), client.ObjectKey{Namespace: machine.Namespace, Name: "replacement"}, replacement)
+			if tc.expectedReplacement {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	}
+}
+
 func TestReconcileStatus(t *testing.T) {
 	testCases := []struct {
 		testCase            string
@@ -2137,6 +3157,66 @@ func TestReconcileStatus(t *testing.T) {
 			currentHealthy:      7,
 			remediationsAllowed: 1,
 		},
+		{
+			testCase: "when the unhealthy machines are within unhealthyRange",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{},
+			},
+			totalTargets:        10,
+			currentHealthy:      8,
+			remediationsAllowed: 1,
+		},
+		{
+			testCase: "when the unhealthy machines are below unhealthyRange",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{},
+			},
+			totalTargets:        10,
+			currentHealthy:      10,
+			remediationsAllowed: 3,
+		},
+		{
+			testCase: "when the unhealthy machines are above unhealthyRange",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{},
+			},
+			totalTargets:        10,
+			currentHealthy:      6,
+			remediationsAllowed: 0,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.testCase, func(t *testing.T) {
@@ -2734,6 +3814,90 @@ func TestIsAllowedRemediation(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			testCase: "within unhealthyRange",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{
+					ExpectedMachines: IntPtr(5),
+					CurrentHealthy:   IntPtr(3),
+				},
+			},
+			expected: true,
+		},
+		{
+			testCase: "below unhealthyRange is not short-circuited",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{
+					ExpectedMachines: IntPtr(5),
+					CurrentHealthy:   IntPtr(5),
+				},
+			},
+			expected: true,
+		},
+		{
+			testCase: "above unhealthyRange",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[1-3]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{
+					ExpectedMachines: IntPtr(5),
+					CurrentHealthy:   IntPtr(1),
+				},
+			},
+			expected: false,
+		},
+		{
+			testCase: "unhealthyRange is malformed",
+			mhc: &mapiv1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: namespace,
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind: "MachineHealthCheck",
+				},
+				Spec: mapiv1beta1.MachineHealthCheckSpec{
+					Selector:       metav1.LabelSelector{},
+					UnhealthyRange: "[3-1]",
+				},
+				Status: mapiv1beta1.MachineHealthCheckStatus{
+					ExpectedMachines: IntPtr(5),
+					CurrentHealthy:   IntPtr(3),
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2749,6 +3913,7 @@ func TestGetMaxUnhealthy(t *testing.T) {
 	testCases := []struct {
 		name                 string
 		maxUnhealthy         *intstr.IntOrString
+		unhealthyRange       string
 		expectedMaxUnhealthy int
 		expectedMachines     int
 		expectedErr          error
@@ -2788,6 +3953,20 @@ func TestGetMaxUnhealthy(t *testing.T) {
 			expectedMaxUnhealthy: 4,
 			expectedErr:          nil,
 		},
+		{
+			name:                 "when unhealthyRange is set",
+			unhealthyRange:       "[1-3]",
+			expectedMachines:     7,
+			expectedMaxUnhealthy: 3,
+			expectedErr:          nil,
+		},
+		{
+			name:                 "when unhealthyRange is malformed",
+			unhealthyRange:       "[1-a]",
+			expectedMachines:     7,
+			expectedMaxUnhealthy: 0,
+			expectedErr:          errors.New("invalid unhealthyRange \"[1-a]\": strconv.Atoi: parsing \"a\": invalid syntax"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2796,7 +3975,8 @@ func TestGetMaxUnhealthy(t *testing.T) {
 
 			mhc := &mapiv1beta1.MachineHealthCheck{
 				Spec: mapiv1beta1.MachineHealthCheckSpec{
-					MaxUnhealthy: tc.maxUnhealthy,
+					MaxUnhealthy:   tc.maxUnhealthy,
+					UnhealthyRange: tc.unhealthyRange,
 				},
 				Status: mapiv1beta1.MachineHealthCheckStatus{
 					ExpectedMachines: &tc.expectedMachines,
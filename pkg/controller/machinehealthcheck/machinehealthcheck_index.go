@@ -0,0 +1,196 @@
+package machinehealthcheck
+
+import (
+	"sort"
+	"sync"
+
+	mapiv1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// mhcSelectorIndex keeps the parsed selectors of all known MachineHealthChecks
+// so that machine, node and probe pod events can be mapped to MachineHealthChecks
+// without listing and parsing every MachineHealthCheck on each event.
+// It is populated from a full list the first time it is used and kept up to
+// date by MachineHealthCheck events afterwards.
+type mhcSelectorIndex struct {
+	lock      sync.RWMutex
+	synced    bool
+	selectors map[types.NamespacedName]labels.Selector
+	// probes holds the unhealthy probe selectors keyed by the namespace of
+	// the probe pods, then by MachineHealthCheck
+	probes map[string]map[types.NamespacedName][]labels.Selector
+}
+
+func newMHCSelectorIndex() *mhcSelectorIndex {
+	return &mhcSelectorIndex{
+		selectors: map[types.NamespacedName]labels.Selector{},
+		probes:    map[string]map[types.NamespacedName][]labels.Selector{},
+	}
+}
+
+// ensureSynced populates the index using list unless it has been populated already.
+// The lock is held while listing so that concurrent updates are not overwritten
+// by a stale list.
+func (i *mhcSelectorIndex) ensureSynced(list func() ([]mapiv1.MachineHealthCheck, error)) error {
+	i.lock.RLock()
+	synced := i.synced
+	i.lock.RUnlock()
+	if synced {
+		return nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.synced {
+		return nil
+	}
+
+	mhcs, err := list()
+	if err != nil {
+		return err
+	}
+
+	for k := range mhcs {
+		i.selectors[namespacedName(&mhcs[k])] = selectorForMHC(&mhcs[k])
+		i.setProbes(namespacedName(&mhcs[k]), probeSelectorsForMHC(&mhcs[k]))
+	}
+	i.synced = true
+	return nil
+}
+
+// set adds or updates the selector of the given MachineHealthCheck
+func (i *mhcSelectorIndex) set(mhc *mapiv1.MachineHealthCheck) {
+	selector := selectorForMHC(mhc)
+	probes := probeSelectorsForMHC(mhc)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.selectors[namespacedName(mhc)] = selector
+	i.setProbes(namespacedName(mhc), probes)
+}
+
+// delete removes the given MachineHealthCheck from the index
+func (i *mhcSelectorIndex) delete(key types.NamespacedName) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	delete(i.selectors, key)
+	i.setProbes(key, nil)
+}
+
+// setProbes replaces the probe selectors of the given MachineHealthCheck.
+// The lock must be held by the caller.
+func (i *mhcSelectorIndex) setProbes(key types.NamespacedName, probes map[string][]labels.Selector) {
+	for namespace, mhcs := range i.probes {
+		delete(mhcs, key)
+		if len(mhcs) == 0 {
+			delete(i.probes, namespace)
+		}
+	}
+	for namespace, selectors := range probes {
+		if i.probes[namespace] == nil {
+			i.probes[namespace] = map[types.NamespacedName][]labels.Selector{}
+		}
+		i.probes[namespace][key] = selectors
+	}
+}
+
+// matchingMachine returns the keys of all MachineHealthChecks in the machine namespace
+// which selectors match the machine, sorted by name
+func (i *mhcSelectorIndex) matchingMachine(machine *mapiv1.Machine) []types.NamespacedName {
+	machineLabels := labels.Set(machine.Labels)
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	var keys []types.NamespacedName
+	for key, selector := range i.selectors {
+		if key.Namespace != machine.Namespace {
+			continue
+		}
+		if selector.Matches(machineLabels) {
+			keys = append(keys, key)
+		}
+	}
+	sortNamespacedNames(keys)
+	return keys
+}
+
+// matchingProbePod returns the keys of all MachineHealthChecks which unhealthy
+// probes select the pod, sorted by name
+func (i *mhcSelectorIndex) matchingProbePod(pod *corev1.Pod) []types.NamespacedName {
+	podLabels := labels.Set(pod.Labels)
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	var keys []types.NamespacedName
+	for key, selectors := range i.probes[pod.Namespace] {
+		for _, selector := range selectors {
+			if selector.Matches(podLabels) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sortNamespacedNames(keys)
+	return keys
+}
+
+// inNamespace returns the keys of all MachineHealthChecks in the given namespace, sorted by name
+func (i *mhcSelectorIndex) inNamespace(namespace string) []types.NamespacedName {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	var keys []types.NamespacedName
+	for key := range i.selectors {
+		if key.Namespace == namespace {
+			keys = append(keys, key)
+		}
+	}
+	sortNamespacedNames(keys)
+	return keys
+}
+
+// selectorForMHC parses the MachineHealthCheck selector the same way hasMatchingLabels does:
+// an empty selector matches all machines and an invalid one matches none
+func selectorForMHC(mhc *mapiv1.MachineHealthCheck) labels.Selector {
+	selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+	if err != nil {
+		klog.Warningf("unable to convert selector of MHC %q: %v", namespacedName(mhc).String(), err)
+		return labels.Nothing()
+	}
+	return selector
+}
+
+// probeSelectorsForMHC parses the MachineHealthCheck unhealthy probe selectors,
+// keyed by the namespace of the probe pods. An invalid selector matches no pod
+func probeSelectorsForMHC(mhc *mapiv1.MachineHealthCheck) map[string][]labels.Selector {
+	if len(mhc.Spec.UnhealthyProbes) == 0 {
+		return nil
+	}
+
+	probes := map[string][]labels.Selector{}
+	for _, probe := range mhc.Spec.UnhealthyProbes {
+		selector, err := metav1.LabelSelectorAsSelector(&probe.Selector)
+		if err != nil {
+			klog.Warningf("unable to convert probe selector of MHC %q: %v", namespacedName(mhc).String(), err)
+			selector = labels.Nothing()
+		}
+		probes[probe.Namespace] = append(probes[probe.Namespace], selector)
+	}
+	return probes
+}
+
+func sortNamespacedNames(keys []types.NamespacedName) {
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Namespace != keys[b].Namespace {
+			return keys[a].Namespace < keys[b].Namespace
+		}
+		return keys[a].Name < keys[b].Name
+	})
+}
@@ -0,0 +1,200 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	maotesting "github.com/openshift/machine-api-operator/pkg/util/testing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMHCSelectorIndex(t *testing.T) {
+	match := maotesting.NewMachineHealthCheck("match")
+	noMatch := maotesting.NewMachineHealthCheck("noMatch")
+	noMatch.Spec.Selector = metav1.LabelSelector{MatchLabels: map[string]string{"no": "match"}}
+	empty := maotesting.NewMachineHealthCheck("empty")
+	empty.Spec.Selector = metav1.LabelSelector{}
+	invalid := maotesting.NewMachineHealthCheck("invalid")
+	invalid.Spec.Selector = metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: "Bogus"}},
+	}
+	otherNamespace := maotesting.NewMachineHealthCheck("otherNamespace")
+	otherNamespace.Namespace = "other-namespace"
+
+	index := newMHCSelectorIndex()
+	if err := index.ensureSynced(func() ([]mapiv1beta1.MachineHealthCheck, error) {
+		return []mapiv1beta1.MachineHealthCheck{*match, *noMatch, *empty, *invalid, *otherNamespace}, nil
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// once synced, the index is not populated again
+	if err := index.ensureSynced(func() ([]mapiv1beta1.MachineHealthCheck, error) {
+		return nil, fmt.Errorf("should not be called")
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	machine := maotesting.NewMachine("machine", "node")
+	expected := []types.NamespacedName{namespacedName(empty), namespacedName(match)}
+	if got := index.matchingMachine(machine); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+
+	// updating a selector is reflected by subsequent lookups
+	updated := noMatch.DeepCopy()
+	updated.Spec.Selector = match.Spec.Selector
+	index.set(updated)
+	index.delete(namespacedName(empty))
+	expected = []types.NamespacedName{namespacedName(match), namespacedName(noMatch)}
+	if got := index.matchingMachine(machine); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+
+	expected = []types.NamespacedName{namespacedName(invalid), namespacedName(match), namespacedName(noMatch)}
+	if got := index.inNamespace(namespace); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestMHCSelectorIndexProbes(t *testing.T) {
+	probeLabels := map[string]string{"app": "probe"}
+	newMHC := func(name string, probes ...mapiv1beta1.UnhealthyProbe) *mapiv1beta1.MachineHealthCheck {
+		mhc := maotesting.NewMachineHealthCheck(name)
+		mhc.Spec.UnhealthyProbes = probes
+		return mhc
+	}
+	probes := newMHC("probes", mapiv1beta1.UnhealthyProbe{Namespace: "probes", Selector: metav1.LabelSelector{MatchLabels: probeLabels}})
+	otherNamespace := newMHC("otherNamespace", mapiv1beta1.UnhealthyProbe{Namespace: "other", Selector: metav1.LabelSelector{MatchLabels: probeLabels}})
+	invalid := newMHC("invalid", mapiv1beta1.UnhealthyProbe{Namespace: "probes", Selector: metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: "Bogus"}},
+	}})
+	noProbes := newMHC("noProbes")
+
+	index := newMHCSelectorIndex()
+	if err := index.ensureSynced(func() ([]mapiv1beta1.MachineHealthCheck, error) {
+		return []mapiv1beta1.MachineHealthCheck{*probes, *otherNamespace, *invalid, *noProbes}, nil
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "probe", Namespace: "probes", Labels: probeLabels}}
+	expected := []types.NamespacedName{namespacedName(probes)}
+	if got := index.matchingProbePod(pod); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+
+	// moving the probes to another namespace is reflected by subsequent lookups
+	updated := otherNamespace.DeepCopy()
+	updated.Spec.UnhealthyProbes[0].Namespace = "probes"
+	index.set(updated)
+	index.delete(namespacedName(probes))
+	expected = []types.NamespacedName{namespacedName(otherNamespace)}
+	if got := index.matchingProbePod(pod); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+	if _, ok := index.probes["other"]; ok {
+		t.Errorf("Expected no probes in namespace other, got: %v", index.probes["other"])
+	}
+}
+
+func TestMHCRequestsFromMHCUpdatesIndex(t *testing.T) {
+	mhc := maotesting.NewMachineHealthCheck("mhc")
+	machine := maotesting.NewMachine("machine", "node")
+	r := newFakeReconciler(mhc, machine)
+
+	expected := []reconcile.Request{{NamespacedName: namespacedName(mhc)}}
+	if requests := r.mhcRequestsFromMachine(machine); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, requests)
+	}
+
+	// a selector change must be picked up through the MHC event
+	mhc.Spec.Selector = metav1.LabelSelector{MatchLabels: map[string]string{"no": "match"}}
+	if err := r.client.Update(context.TODO(), mhc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r.mhcRequestsFromMHC(mhc)
+	if requests := r.mhcRequestsFromMachine(machine); requests != nil {
+		t.Errorf("Expected no requests, got: %v", requests)
+	}
+
+	// a deleted MHC must be removed through the MHC event
+	mhc.Spec.Selector = metav1.LabelSelector{}
+	r.mhcIndex.set(mhc)
+	if err := r.client.Delete(context.TODO(), mhc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r.mhcRequestsFromMHC(mhc)
+	if requests := r.mhcRequestsFromMachine(machine); requests != nil {
+		t.Errorf("Expected no requests, got: %v", requests)
+	}
+}
+
+// listMHCRequestsFromMachine maps a machine to MHCs by listing all MHCs, as was
+// done before the MHC index was introduced. It is kept as a baseline for benchmarks.
+func listMHCRequestsFromMachine(r *ReconcileMachineHealthCheck, machine *mapiv1beta1.Machine) []reconcile.Request {
+	mhcList := &mapiv1beta1.MachineHealthCheckList{}
+	if err := r.client.List(context.Background(), mhcList); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for k := range mhcList.Items {
+		if hasMatchingLabels(&mhcList.Items[k], machine) {
+			requests = append(requests, reconcile.Request{NamespacedName: namespacedName(&mhcList.Items[k])})
+		}
+	}
+	return requests
+}
+
+func BenchmarkMHCRequestsFromMachine(b *testing.B) {
+	machine := maotesting.NewMachine("machine", "node")
+	objects := []runtime.Object{machine}
+	for i := 0; i < 500; i++ {
+		mhc := maotesting.NewMachineHealthCheck(fmt.Sprintf("mhc-%d", i))
+		if i%10 != 0 {
+			mhc.Spec.Selector = metav1.LabelSelector{MatchLabels: map[string]string{"mhc": mhc.Name}}
+		}
+		objects = append(objects, mhc)
+	}
+
+	benchmarks := []struct {
+		name    string
+		mapFunc func(r *ReconcileMachineHealthCheck, o client.Object) []reconcile.Request
+	}{
+		{
+			name: "list",
+			mapFunc: func(r *ReconcileMachineHealthCheck, o client.Object) []reconcile.Request {
+				return listMHCRequestsFromMachine(r, o.(*mapiv1beta1.Machine))
+			},
+		},
+		{
+			name: "index",
+			mapFunc: func(r *ReconcileMachineHealthCheck, o client.Object) []reconcile.Request {
+				return r.mhcRequestsFromMachine(o)
+			},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := newFakeReconciler(objects...)
+			if requests := bm.mapFunc(r, machine); len(requests) != 50 {
+				b.Fatalf("Expected 50 requests, got: %d", len(requests))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bm.mapFunc(r, machine)
+			}
+		})
+	}
+}
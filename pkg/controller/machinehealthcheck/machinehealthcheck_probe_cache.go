@@ -0,0 +1,90 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// probePodCacheSyncTimeout is how long reading probe pods waits for the pod
+// cache of their namespace to sync
+const probePodCacheSyncTimeout = time.Minute
+
+// probePodCache is a pod cache restricted to a namespace
+type probePodCache interface {
+	client.Reader
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// probePodCaches holds a pod cache per namespace which unhealthy probes select
+// pods in. The cache of a namespace is only started the first time a
+// MachineHealthCheck reads its probe pods, so that pods are not cached
+// cluster wide and not at all when no MachineHealthCheck defines probes.
+type probePodCaches struct {
+	lock   sync.Mutex
+	caches map[string]probePodCache
+	// start starts the pod cache of a namespace along with its watch
+	start func(namespace string) (probePodCache, error)
+}
+
+func newProbePodCaches(start func(namespace string) (probePodCache, error)) *probePodCaches {
+	return &probePodCaches{
+		caches: map[string]probePodCache{},
+		start:  start,
+	}
+}
+
+// reader returns the synced pod cache of the namespace, starting it if needed
+func (p *probePodCaches) reader(namespace string) (client.Reader, error) {
+	p.lock.Lock()
+	podCache, ok := p.caches[namespace]
+	if !ok {
+		klog.Infof("Starting probe pod cache for namespace %q", namespace)
+		var err error
+		if podCache, err = p.start(namespace); err != nil {
+			p.lock.Unlock()
+			return nil, fmt.Errorf("error starting probe pod cache for namespace %q: %v", namespace, err)
+		}
+		p.caches[namespace] = podCache
+	}
+	p.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), probePodCacheSyncTimeout)
+	defer cancel()
+	if !podCache.WaitForCacheSync(ctx) {
+		return nil, fmt.Errorf("timed out waiting for probe pod cache of namespace %q to sync", namespace)
+	}
+	return podCache, nil
+}
+
+// probePodCacheStarter returns a function starting a pod cache restricted to a
+// namespace, which pod events are mapped to MachineHealthChecks by mapPodToMHC
+func probePodCacheStarter(mgr manager.Manager, c controller.Controller, mapPodToMHC handler.MapFunc) func(namespace string) (probePodCache, error) {
+	return func(namespace string) (probePodCache, error) {
+		podCache, err := cache.New(mgr.GetConfig(), cache.Options{
+			Scheme:    mgr.GetScheme(),
+			Mapper:    mgr.GetRESTMapper(),
+			Namespace: namespace,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := mgr.Add(podCache); err != nil {
+			return nil, err
+		}
+		if err := c.Watch(source.NewKindWithCache(&corev1.Pod{}, podCache), handler.EnqueueRequestsFromMapFunc(mapPodToMHC)); err != nil {
+			return nil, err
+		}
+		return podCache, nil
+	}
+}
@@ -0,0 +1,59 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// unsyncedProbePodCache is a probe pod cache which never syncs
+type unsyncedProbePodCache struct {
+	fakeProbePodCache
+}
+
+func (unsyncedProbePodCache) WaitForCacheSync(context.Context) bool {
+	return false
+}
+
+func TestProbePodCaches(t *testing.T) {
+	var started []string
+	caches := newProbePodCaches(func(namespace string) (probePodCache, error) {
+		started = append(started, namespace)
+		switch namespace {
+		case "failing":
+			return nil, errors.New("unable to start")
+		case "unsynced":
+			return unsyncedProbePodCache{}, nil
+		}
+		return fakeProbePodCache{fake.NewFakeClient()}, nil
+	})
+
+	for _, namespace := range []string{"probes", "probes", "other"} {
+		if _, err := caches.reader(namespace); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// a cache is only started once per namespace
+	expected := []string{"probes", "other"}
+	if !reflect.DeepEqual(started, expected) {
+		t.Errorf("Expected caches started for %v, got: %v", expected, started)
+	}
+
+	// a cache failing to start is started again on the next read
+	for i := 0; i < 2; i++ {
+		if _, err := caches.reader("failing"); err == nil {
+			t.Error("Expected an error")
+		}
+	}
+	expected = append(expected, "failing", "failing")
+	if !reflect.DeepEqual(started, expected) {
+		t.Errorf("Expected caches started for %v, got: %v", expected, started)
+	}
+
+	if _, err := caches.reader("unsynced"); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	machinev1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -59,8 +60,13 @@ var (
 // Add creates a new MachineSet Controller and adds it to the Manager with default RBAC.
 // The Manager will set fields on the Controller and Start it when the Manager is Started.
 func Add(mgr manager.Manager, opts manager.Options) error {
+	return AddWithWorkers(mgr, opts, mapicontroller.DefaultWorkerOptions())
+}
+
+// AddWithWorkers creates a new MachineSet Controller running the given workers and adds it to the Manager.
+func AddWithWorkers(mgr manager.Manager, opts manager.Options, workers mapicontroller.WorkerOptions) error {
 	r := newReconciler(mgr)
-	return add(mgr, r, r.MachineToMachineSets)
+	return add(mgr, r, r.MachineToMachineSets, workers)
 }
 
 // newReconciler returns a new reconcile.Reconciler.
@@ -69,9 +75,9 @@ func newReconciler(mgr manager.Manager) *ReconcileMachineSet {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
-func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.MapFunc) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.MapFunc, workers mapicontroller.WorkerOptions) error {
 	// Create a new controller.
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, workers.ControllerOptions(r))
 	if err != nil {
 		return err
 	}
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,7 +43,7 @@ var _ = Describe("MachineSet Reconciler", func() {
 		By("Setting up a new reconciler")
 		reconciler := newReconciler(mgr)
 
-		err = add(mgr, reconciler, reconciler.MachineToMachineSets)
+		err = add(mgr, reconciler, reconciler.MachineToMachineSets, mapicontroller.DefaultWorkerOptions())
 		Expect(err).NotTo(HaveOccurred())
 
 		By("Starting the manager")
@@ -4,11 +4,20 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -24,6 +33,14 @@ const (
 	machineProviderIDIndex = "machineProviderIDIndex"
 	nodeInternalIPIndex    = "nodeInternalIPIndex"
 	nodeProviderIDIndex    = "nodeProviderIDIndex"
+	machineNodeRefIndex    = "machineNodeRefIndex"
+
+	// The managed annotations record the keys nodelink copied from the machine spec to the node,
+	// so that keys which are removed from the machine spec can be removed from the node as well.
+	// They are kept empty rather than removed, as their absence marks a node never synced before
+	managedLabelsAnnotationKey      = "machine.openshift.io/managed-labels"
+	managedAnnotationsAnnotationKey = "machine.openshift.io/managed-annotations"
+	managedTaintsAnnotationKey      = "machine.openshift.io/managed-taints"
 )
 
 // blank assignment to verify that ReconcileNodeLink implements reconcile.Reconciler
@@ -38,17 +55,35 @@ type ReconcileNodeLink struct {
 	// and emulate Client.List.MatchingField behaviour
 	listNodesByFieldFunc    func(key, value string) ([]corev1.Node, error)
 	listMachinesByFieldFunc func(key, value string) ([]mapiv1beta1.Machine, error)
-	nodeReadinessCache      map[string]bool
+	nodeReadinessCache      *readinessCache
+	recorder                record.EventRecorder
+	// conflicts holds the names of the nodes claimed by more than one machine
+	conflicts     sets.String
+	conflictsLock sync.Mutex
+	// matchers are used in order to link machines and nodes,
+	// the first matcher finding any candidate wins
+	matchers []nodeMatcher
 }
 
 // Add creates a new Nodelink Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, opts manager.Options) error {
-	reconciler, err := newReconciler(mgr)
+	return AddWithNodeMatchers(mgr, opts, DefaultNodeMatchers, mapicontroller.DefaultWorkerOptions())
+}
+
+// AddWithNodeMatchers creates a new Nodelink Controller which links machines and nodes using the
+// given node matchers in priority order, runs the given workers, and adds it to the Manager.
+func AddWithNodeMatchers(mgr manager.Manager, opts manager.Options, nodeMatcherNames []string, workers mapicontroller.WorkerOptions) error {
+	matchers, err := parseNodeMatchers(nodeMatcherNames)
+	if err != nil {
+		return fmt.Errorf("error parsing node matchers: %v", err)
+	}
+
+	reconciler, err := newReconciler(mgr, matchers)
 	if err != nil {
 		return fmt.Errorf("error building reconciler: %v", err)
 	}
-	return add(mgr, reconciler, reconciler.nodeRequestFromMachine)
+	return add(mgr, reconciler, reconciler.nodeRequestFromMachine, workers)
 }
 
 func indexNodeByProviderID(object client.Object) []string {
@@ -113,45 +148,55 @@ func indexMachineByInternalIP(object client.Object) []string {
 	return keys
 }
 
-// newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) (*ReconcileNodeLink, error) {
-	// set convenient indexers
-	if err := mgr.GetCache().IndexField(context.TODO(),
-		&corev1.Node{},
-		nodeProviderIDIndex,
-		indexNodeByProviderID,
-	); err != nil {
-		return nil, fmt.Errorf("error setting index fields: %v", err)
+func indexMachineByNodeRef(object client.Object) []string {
+	machine, ok := object.(*mapiv1beta1.Machine)
+	if !ok {
+		klog.Warningf("Expected a machine for indexing field, got: %T", object)
+		return nil
 	}
 
-	if err := mgr.GetCache().IndexField(context.TODO(),
-		&mapiv1beta1.Machine{},
-		machineProviderIDIndex,
-		indexMachineByProvider,
-	); err != nil {
-		return nil, fmt.Errorf("error setting index fields: %v", err)
+	if machine.Status.NodeRef != nil && machine.Status.NodeRef.Name != "" {
+		return []string{machine.Status.NodeRef.Name}
 	}
+	return nil
+}
 
-	if err := mgr.GetCache().IndexField(context.TODO(),
-		&corev1.Node{},
-		nodeInternalIPIndex,
-		indexNodeByInternalIP,
-	); err != nil {
-		return nil, fmt.Errorf("error setting index fields: %v", err)
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, matchers []nodeMatcher) (*ReconcileNodeLink, error) {
+	// set convenient indexers for every configured matcher
+	for _, matcher := range matchers {
+		if err := mgr.GetCache().IndexField(context.TODO(),
+			&corev1.Node{},
+			matcher.nodeIndex,
+			matcher.indexNode,
+		); err != nil {
+			return nil, fmt.Errorf("error setting index fields: %v", err)
+		}
+
+		if err := mgr.GetCache().IndexField(context.TODO(),
+			&mapiv1beta1.Machine{},
+			matcher.machineIndex,
+			matcher.indexMachine,
+		); err != nil {
+			return nil, fmt.Errorf("error setting index fields: %v", err)
+		}
 	}
 
 	if err := mgr.GetCache().IndexField(context.TODO(),
 		&mapiv1beta1.Machine{},
-		machineInternalIPIndex,
-		indexMachineByInternalIP,
+		machineNodeRefIndex,
+		indexMachineByNodeRef,
 	); err != nil {
 		return nil, fmt.Errorf("error setting index fields: %v", err)
 	}
 
 	r := ReconcileNodeLink{
-		client: mgr.GetClient(),
+		client:    mgr.GetClient(),
+		recorder:  mgr.GetEventRecorderFor("nodelink-controller"),
+		matchers:  matchers,
+		conflicts: sets.NewString(),
 	}
-	r.nodeReadinessCache = make(map[string]bool)
+	r.nodeReadinessCache = newReadinessCache(defaultReadinessCacheTTL)
 
 	// This is useful for unit testing so we can mock cache IndexField
 	// and emulate Client.List.MatchingField behaviour
@@ -161,9 +206,9 @@ func newReconciler(mgr manager.Manager) (*ReconcileNodeLink, error) {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.MapFunc) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.MapFunc, workers mapicontroller.WorkerOptions) error {
 	// Create a new controller
-	c, err := controller.New("nodelink-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("nodelink-controller", mgr, workers.ControllerOptions(r))
 	if err != nil {
 		return err
 	}
@@ -198,6 +243,8 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			r.nodeReadinessCache.delete(request.Name)
+			r.setConflict(request.Name, false)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -206,6 +253,11 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 	}
 
 	machine, err := r.findMachineFromNode(node)
+	if ambiguousErr, ok := err.(*ambiguousMatchError); ok {
+		klog.Warningf("Unable to link node %q: %v", node.GetName(), ambiguousErr)
+		message := fmt.Sprintf("Node %q is claimed by more than one machine: %v", node.GetName(), ambiguousErr)
+		return reconcile.Result{}, r.markConflict(node, ambiguousErr.machines, message)
+	}
 	if err != nil {
 		klog.Errorf("Failed to find machine from node %q: %v", node.GetName(), err)
 		return reconcile.Result{}, fmt.Errorf("failed to find machine from node %q: %v", node.GetName(), err)
@@ -213,9 +265,37 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 
 	if machine == nil {
 		klog.Warningf("Machine for node %q not found", node.GetName())
+		r.setConflict(node.GetName(), false)
 		return reconcile.Result{}, nil
 	}
 
+	// refuse to link the node if the machine matches other nodes as well
+	if _, err := r.findNodeFromMachine(machine); err != nil {
+		if ambiguousErr, ok := err.(*ambiguousMatchError); ok {
+			klog.Warningf("Unable to link machine %q: %v", machine.GetName(), ambiguousErr)
+			message := fmt.Sprintf("Unable to link machine to a node: %v", ambiguousErr)
+			return reconcile.Result{}, r.markNodeLinkFailed(machine, mapiv1beta1.AmbiguousNodeMatchReason, message)
+		}
+		klog.Errorf("Failed to find node from machine %q: %v", machine.GetName(), err)
+		return reconcile.Result{}, fmt.Errorf("failed to find node from machine %q: %v", machine.GetName(), err)
+	}
+
+	// refuse to link the node if another machine references it already
+	claiming, err := r.otherMachinesClaimingNode(machine, node)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error finding machines claiming node %q: %v", node.GetName(), err)
+	}
+	if len(claiming) > 0 {
+		names := []string{machine.GetName()}
+		for i := range claiming {
+			names = append(names, claiming[i].GetName())
+		}
+		klog.Warningf("Unable to link node %q to machine %q, it is already referenced by another machine", node.GetName(), machine.GetName())
+		message := fmt.Sprintf("Node %q is claimed by more than one machine: %s", node.GetName(), strings.Join(names, ", "))
+		return reconcile.Result{}, r.markConflict(node, append([]mapiv1beta1.Machine{*machine}, claiming...), message)
+	}
+	r.setConflict(node.GetName(), false)
+
 	if err := r.updateNodeRef(machine, node); err != nil {
 		return reconcile.Result{}, fmt.Errorf("error updating nodeRef for machine %q and node %q: %v", machine.GetName(), node.GetName(), err)
 	}
@@ -235,12 +315,10 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 		modNode.Labels = map[string]string{}
 	}
 
-	for k, v := range machine.Spec.Labels {
-		klog.V(4).Infof("Copying label %s = %s", k, v)
-		modNode.Labels[k] = v
-	}
-
+	syncLabelsToNode(modNode, machine)
+	syncAnnotationsToNode(modNode, machine)
 	addTaintsToNode(modNode, machine)
+	removeTaintsFromNode(modNode, machine)
 
 	if !reflect.DeepEqual(node, modNode) {
 		klog.V(3).Infof("Node %q has changed, updating", modNode.GetName())
@@ -258,28 +336,33 @@ func (r *ReconcileNodeLink) updateNodeRef(machine *mapiv1beta1.Machine, node *co
 	machine.Status.LastUpdated = &now
 
 	if !node.DeletionTimestamp.IsZero() {
-		delete(r.nodeReadinessCache, node.GetName())
+		r.nodeReadinessCache.delete(node.GetName())
 		return nil
 	}
 
 	nodeReady := isNodeReady(node)
-	// skip update if cached and no change in readiness.
-	if cachedReady, ok := r.nodeReadinessCache[node.GetName()]; ok &&
-		cachedReady == nodeReady {
+	nodeInfo := nodeInfoFromNode(node)
+	// skip update if cached, no change in readiness and the machine references the node already.
+	if cachedReady, ok := r.nodeReadinessCache.get(node); ok &&
+		cachedReady == nodeReady && hasNodeRef(machine, node) &&
+		conditions.IsTrue(machine, mapiv1beta1.NodeLinkedCondition) &&
+		equality.Semantic.DeepEqual(machine.Status.NodeInfo, nodeInfo) {
 		return nil
 	}
 
-	// if the nodeReadiness has changed the machine is updated so
+	// if the nodeReadiness or the node info has changed the machine is updated so
 	// watchers can take action, e.g machine controller
 	machine.Status.NodeRef = &corev1.ObjectReference{
 		Kind: "Node",
 		Name: node.GetName(),
 		UID:  node.GetUID(),
 	}
+	machine.Status.NodeInfo = nodeInfo
+	conditions.MarkTrue(machine, mapiv1beta1.NodeLinkedCondition)
 	if err := r.client.Status().Update(context.Background(), machine); err != nil {
 		return fmt.Errorf("error updating machine %q: %v", machine.GetName(), err)
 	}
-	r.nodeReadinessCache[node.GetName()] = nodeReady
+	r.nodeReadinessCache.set(node, nodeReady)
 
 	klog.Infof("Successfully updated nodeRef for machine %q and node %q", machine.GetName(), node.GetName())
 	return nil
@@ -309,6 +392,20 @@ func (r *ReconcileNodeLink) nodeRequestFromMachine(o client.Object) []reconcile.
 
 	// find node
 	node, err := r.findNodeFromMachine(machine)
+	if ambiguousErr, ok := err.(*ambiguousMatchError); ok {
+		// all the candidates are reconciled, so that the ambiguity is reported on the machine
+		klog.Warningf("Unable to link machine %q: %v", machine.GetName(), ambiguousErr)
+		requests := []reconcile.Request{}
+		for i := range ambiguousErr.nodes {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: ambiguousErr.nodes[i].GetNamespace(),
+					Name:      ambiguousErr.nodes[i].GetName(),
+				},
+			})
+		}
+		return requests
+	}
 	if err != nil {
 		klog.Errorf("No-op: Failed to find node for machine %q: %v", machine.GetName(), err)
 		return []reconcile.Request{}
@@ -328,174 +425,267 @@ func (r *ReconcileNodeLink) nodeRequestFromMachine(o client.Object) []reconcile.
 	return []reconcile.Request{}
 }
 
-// findNodeFromMachine find a node from by providerID and fallback to find by IP
+// findNodeFromMachine finds a node for the machine using the configured matchers in priority order.
+// The first matcher finding any node wins, an ambiguousMatchError is returned if it finds more than one.
 func (r *ReconcileNodeLink) findNodeFromMachine(machine *mapiv1beta1.Machine) (*corev1.Node, error) {
 	klog.V(3).Infof("Finding node from machine %q", machine.GetName())
-	node, err := r.findNodeFromMachineByProviderID(machine)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find node from machine %q by ProviderID: %v", machine.GetName(), err)
-	}
-	if node != nil {
-		return node, nil
-	}
-
-	node, err = r.findNodeFromMachineByIP(machine)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find node from machine %q by internal IP: %v", machine.GetName(), err)
+	for _, matcher := range r.matchers {
+		node, err := r.findNodeFromMachineWith(matcher, machine)
+		if _, ok := err.(*ambiguousMatchError); ok {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find node from machine %q by %s: %v", machine.GetName(), matcher.name, err)
+		}
+		if node != nil {
+			return node, nil
+		}
 	}
-	return node, nil
+	return nil, nil
 }
 
-func (r *ReconcileNodeLink) findNodeFromMachineByProviderID(machine *mapiv1beta1.Machine) (*corev1.Node, error) {
-	klog.V(3).Infof("Finding node from machine %q by providerID", machine.GetName())
-	if machine.Spec.ProviderID == nil {
-		klog.Warningf("Machine %q has no providerID", machine.GetName())
-		return nil, nil
+// findMachineFromNode finds a machine for the node using the configured matchers in priority order.
+// The first matcher finding any machine wins, an ambiguousMatchError is returned if it finds more than one.
+func (r *ReconcileNodeLink) findMachineFromNode(node *corev1.Node) (*mapiv1beta1.Machine, error) {
+	klog.V(3).Infof("Finding machine from node %q", node.GetName())
+	for _, matcher := range r.matchers {
+		machine, err := r.findMachineFromNodeWith(matcher, node)
+		if _, ok := err.(*ambiguousMatchError); ok {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find machine from node %q by %s: %v", node.GetName(), matcher.name, err)
+		}
+		if machine != nil {
+			return machine, nil
+		}
 	}
+	return nil, nil
+}
 
-	nodes, err := r.listNodesByFieldFunc(nodeProviderIDIndex, *machine.Spec.ProviderID)
+// otherMachinesClaimingNode returns the machines other than the given one which reference the node,
+// ignoring machines which are being deleted
+func (r *ReconcileNodeLink) otherMachinesClaimingNode(machine *mapiv1beta1.Machine, node *corev1.Node) ([]mapiv1beta1.Machine, error) {
+	machines, err := r.listMachinesByFieldFunc(machineNodeRefIndex, node.GetName())
 	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
+		return nil, err
 	}
 
-	if len(nodes) > 1 {
-		return nil, fmt.Errorf("failed getting node: expected 1 node, got %v", len(nodes))
-	}
-
-	if len(nodes) == 1 {
-		klog.V(3).Infof("Found node %q for machine %q with providerID %q", nodes[0].GetName(), machine.GetName(), nodes[0].Spec.ProviderID)
-		return nodes[0].DeepCopy(), nil
-	}
-
-	return nil, nil
-}
-
-func (r *ReconcileNodeLink) findNodeFromMachineByIP(machine *mapiv1beta1.Machine) (*corev1.Node, error) {
-	klog.V(3).Infof("Finding node from machine %q by IP", machine.GetName())
-	var machineInternalAddress string
-	for _, a := range machine.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			machineInternalAddress = a.Address
-			klog.V(3).Infof("Found internal IP for machine %q: %q", machine.GetName(), machineInternalAddress)
-			break
+	var claiming []mapiv1beta1.Machine
+	for i := range machines {
+		if machines[i].GetNamespace() == machine.GetNamespace() && machines[i].GetName() == machine.GetName() {
+			continue
 		}
+		if !machines[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		claiming = append(claiming, machines[i])
 	}
+	return claiming, nil
+}
 
-	if machineInternalAddress == "" {
-		klog.Warningf("not found internal IP for machine %q", machine.GetName())
-		return nil, nil
-	}
+// markConflict records the node as claimed by more than one machine and marks all the claiming machines
+func (r *ReconcileNodeLink) markConflict(node *corev1.Node, machines []mapiv1beta1.Machine, message string) error {
+	r.setConflict(node.GetName(), true)
 
-	nodes, err := r.listNodesByFieldFunc(nodeInternalIPIndex, machineInternalAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
+	var errs []error
+	for i := range machines {
+		if err := r.markNodeLinkFailed(&machines[i], mapiv1beta1.NodeConflictReason, message); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return utilerrors.NewAggregate(errs)
+}
 
-	if len(nodes) > 1 {
-		return nil, fmt.Errorf("failed getting node: expected 1 node, got %v", len(nodes))
-	}
+// setConflict records whether the node is claimed by more than one machine and updates the conflicts metric
+func (r *ReconcileNodeLink) setConflict(nodeName string, conflict bool) {
+	r.conflictsLock.Lock()
+	defer r.conflictsLock.Unlock()
 
-	if len(nodes) == 1 {
-		klog.V(3).Infof("Found node %q for machine %q with internal IP %q", nodes[0].GetName(), machine.GetName(), machineInternalAddress)
-		return nodes[0].DeepCopy(), nil
+	if conflict {
+		r.conflicts.Insert(nodeName)
+	} else {
+		r.conflicts.Delete(nodeName)
 	}
-
-	klog.V(3).Infof("Matching node not found for machine %q with internal IP %q", machine.GetName(), machineInternalAddress)
-	return nil, nil
+	metrics.ObserveNodeLinkUnresolvedConflicts(r.conflicts.Len())
 }
 
-func (r *ReconcileNodeLink) findMachineFromNode(node *corev1.Node) (*mapiv1beta1.Machine, error) {
-	klog.V(3).Infof("Finding machine from node %q", node.GetName())
-	machine, err := r.findMachineFromNodeByProviderID(node)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find machine from node %q by ProviderID: %v", node.GetName(), err)
-	}
-	if machine != nil {
-		return machine, nil
+// markNodeLinkFailed sets the NodeLinked condition of the machine to false when it can
+// not be linked to a node and records an event whenever the condition changes
+func (r *ReconcileNodeLink) markNodeLinkFailed(machine *mapiv1beta1.Machine, reason, message string) error {
+	if c := conditions.Get(machine, mapiv1beta1.NodeLinkedCondition); c != nil &&
+		c.Status == corev1.ConditionFalse && c.Reason == reason && c.Message == message {
+		return nil
 	}
-
-	machine, err = r.findMachineFromNodeByIP(node)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find machine from node %q by internal IP: %v", node.GetName(), err)
+	conditions.Set(machine, conditions.FalseCondition(
+		mapiv1beta1.NodeLinkedCondition,
+		reason,
+		mapiv1beta1.ConditionSeverityWarning,
+		"%s", message,
+	))
+	if err := r.client.Status().Update(context.Background(), machine); err != nil {
+		return fmt.Errorf("error updating machine %q: %v", machine.GetName(), err)
 	}
-	return machine, nil
+	r.recorder.Event(machine, corev1.EventTypeWarning, reason, message)
+	return nil
 }
 
-func (r *ReconcileNodeLink) findMachineFromNodeByProviderID(node *corev1.Node) (*mapiv1beta1.Machine, error) {
-	klog.V(3).Infof("Finding machine from node %q by ProviderID", node.GetName())
-	if node.Spec.ProviderID == "" {
-		klog.Warningf("Node %q has no providerID", node.GetName())
-		return nil, nil
+// addTaintsToNode adds taints from machine object to the node object
+// Taints are to be an authoritative list on the machine spec per cluster-api comments.
+// However, we believe many components can directly taint a node and there is no direct source of truth that should enforce a single writer of taints.
+// Only the taints it adds are recorded as managed, so that taints which were already on the node are never removed.
+// On the first sync, the machine taints already on the node were added by a previous version and are managed too.
+func addTaintsToNode(node *corev1.Node, machine *mapiv1beta1.Machine) {
+	firstSync := !hasManagedKeys(node, managedTaintsAnnotationKey)
+	managed := getManagedKeys(node, managedTaintsAnnotationKey)
+	defer setManagedKeys(node, managedTaintsAnnotationKey, managed)
+	for _, mTaint := range machine.Spec.Taints {
+		klog.V(4).Infof("Adding taint %v from machine %q to node %q", mTaint, machine.GetName(), node.GetName())
+		alreadyPresent := false
+		for _, nTaint := range node.Spec.Taints {
+			if nTaint.Key == mTaint.Key && nTaint.Effect == mTaint.Effect {
+				klog.V(4).Infof("Skipping to add machine taint, %v, to the node. Node already has a taint with same key and effect", mTaint)
+				alreadyPresent = true
+				break
+			}
+		}
+		if !alreadyPresent {
+			node.Spec.Taints = append(node.Spec.Taints, mTaint)
+			managed.Insert(taintKey(mTaint))
+		} else if firstSync {
+			managed.Insert(taintKey(mTaint))
+		}
 	}
+}
 
-	machines, err := r.listMachinesByFieldFunc(machineProviderIDIndex, node.Spec.ProviderID)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
+// syncLabelsToNode copies the labels of the machine spec to the node and removes
+// the labels it copied before which are no longer part of the machine spec.
+// Labels which already had the same value on the node are not recorded as copied,
+// except on the first sync, as a previous version copied them without recording them.
+func syncLabelsToNode(node *corev1.Node, machine *mapiv1beta1.Machine) {
+	firstSync := !hasManagedKeys(node, managedLabelsAnnotationKey)
+	managed := getManagedKeys(node, managedLabelsAnnotationKey)
+	for _, k := range managed.List() {
+		if _, ok := machine.Spec.Labels[k]; !ok {
+			klog.V(4).Infof("Removing label %s from node %q, it is no longer part of machine %q", k, node.GetName(), machine.GetName())
+			delete(node.Labels, k)
+			managed.Delete(k)
+		}
 	}
 
-	if len(machines) > 1 {
-		return nil, fmt.Errorf("failed getting machine: expected 1 machine, got %v", len(machines))
+	for k, v := range machine.Spec.Labels {
+		if current, ok := node.Labels[k]; ok && current == v {
+			if firstSync {
+				managed.Insert(k)
+			}
+			continue
+		}
+		klog.V(4).Infof("Copying label %s = %s", k, v)
+		node.Labels[k] = v
+		managed.Insert(k)
 	}
 
-	if len(machines) > 0 {
-		klog.V(3).Infof("Found machine %q for node %q with providerID %q", machines[0].GetName(), node.GetName(), node.Spec.ProviderID)
-		return machines[0].DeepCopy(), nil
-	}
-	return nil, nil
+	setManagedKeys(node, managedLabelsAnnotationKey, managed)
 }
 
-func (r *ReconcileNodeLink) findMachineFromNodeByIP(node *corev1.Node) (*mapiv1beta1.Machine, error) {
-	klog.V(3).Infof("Finding machine from node %q by IP", node.GetName())
-	var nodeInternalAddress string
-	for _, a := range node.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			nodeInternalAddress = a.Address
-			klog.V(3).Infof("Found internal IP for node %q: %q", node.GetName(), nodeInternalAddress)
-			break
-		}
-	}
+// syncAnnotationsToNode copies the annotations of the machine spec to the node and removes
+// the annotations it copied before which are no longer part of the machine spec.
+// Annotations owned by nodelink itself are never copied. Annotations which already had
+// the same value on the node are only recorded as copied on the first sync, like labels.
+func syncAnnotationsToNode(node *corev1.Node, machine *mapiv1beta1.Machine) {
+	firstSync := !hasManagedKeys(node, managedAnnotationsAnnotationKey)
 
-	if nodeInternalAddress == "" {
-		klog.Warningf("Node %q has no internal IP", node.GetName())
-		return nil, nil
-	}
+	reserved := sets.NewString(machineAnnotationKey, managedLabelsAnnotationKey, managedAnnotationsAnnotationKey, managedTaintsAnnotationKey)
 
-	machines, err := r.listMachinesByFieldFunc(machineInternalIPIndex, nodeInternalAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
+	desired := sets.NewString()
+	for k := range machine.Spec.Annotations {
+		if reserved.Has(k) {
+			klog.V(4).Infof("Skipping to copy annotation %s from machine %q, it is reserved", k, machine.GetName())
+			continue
+		}
+		desired.Insert(k)
 	}
 
-	if len(machines) > 1 {
-		return nil, fmt.Errorf("failed getting machine: expected 1 machine, got %v", len(machines))
+	managed := getManagedKeys(node, managedAnnotationsAnnotationKey)
+	for _, k := range managed.Difference(desired).List() {
+		managed.Delete(k)
+		if reserved.Has(k) {
+			continue
+		}
+		klog.V(4).Infof("Removing annotation %s from node %q, it is no longer part of machine %q", k, node.GetName(), machine.GetName())
+		delete(node.Annotations, k)
 	}
 
-	if len(machines) == 1 {
-		klog.V(3).Infof("Found machine %q for node %q with internal IP %q", machines[0].GetName(), node.GetName(), nodeInternalAddress)
-		return machines[0].DeepCopy(), nil
+	for _, k := range desired.List() {
+		v := machine.Spec.Annotations[k]
+		if current, ok := node.Annotations[k]; ok && current == v {
+			if firstSync {
+				managed.Insert(k)
+			}
+			continue
+		}
+		klog.V(4).Infof("Copying annotation %s = %s", k, v)
+		node.Annotations[k] = v
+		managed.Insert(k)
 	}
 
-	klog.V(3).Infof("Matching machine not found for node %q with internal IP %q", node.GetName(), nodeInternalAddress)
-	return nil, nil
+	setManagedKeys(node, managedAnnotationsAnnotationKey, managed)
 }
 
-// addTaintsToNode adds taints from machine object to the node object
-// Taints are to be an authoritative list on the machine spec per cluster-api comments.
-// However, we believe many components can directly taint a node and there is no direct source of truth that should enforce a single writer of taints
-func addTaintsToNode(node *corev1.Node, machine *mapiv1beta1.Machine) {
+// removeTaintsFromNode removes the taints which were added to the node from the machine spec
+// but are no longer part of it. Taints added by anything else are left untouched.
+func removeTaintsFromNode(node *corev1.Node, machine *mapiv1beta1.Machine) {
+	desired := sets.NewString()
 	for _, mTaint := range machine.Spec.Taints {
-		klog.V(4).Infof("Adding taint %v from machine %q to node %q", mTaint, machine.GetName(), node.GetName())
-		alreadyPresent := false
+		desired.Insert(taintKey(mTaint))
+	}
+
+	managed := getManagedKeys(node, managedTaintsAnnotationKey)
+	removed := managed.Difference(desired)
+	if removed.Len() > 0 {
+		taints := []corev1.Taint{}
 		for _, nTaint := range node.Spec.Taints {
-			if nTaint.Key == mTaint.Key && nTaint.Effect == mTaint.Effect {
-				klog.V(4).Infof("Skipping to add machine taint, %v, to the node. Node already has a taint with same key and effect", mTaint)
-				alreadyPresent = true
-				break
+			if removed.Has(taintKey(nTaint)) {
+				klog.V(4).Infof("Removing taint %v from node %q, it is no longer part of machine %q", nTaint, node.GetName(), machine.GetName())
+				continue
 			}
+			taints = append(taints, nTaint)
 		}
-		if !alreadyPresent {
-			node.Spec.Taints = append(node.Spec.Taints, mTaint)
+		node.Spec.Taints = taints
+	}
+
+	setManagedKeys(node, managedTaintsAnnotationKey, managed.Intersection(desired))
+}
+
+// taintKey identifies a taint by its key and effect, the same way addTaintsToNode does
+func taintKey(taint corev1.Taint) string {
+	return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+}
+
+// hasManagedKeys returns whether the given managed annotation of the node was recorded,
+// which it is not before nodelink synced the node for the first time
+func hasManagedKeys(node *corev1.Node, annotationKey string) bool {
+	_, ok := node.Annotations[annotationKey]
+	return ok
+}
+
+// getManagedKeys returns the keys recorded in the given managed annotation of the node
+func getManagedKeys(node *corev1.Node, annotationKey string) sets.String {
+	keys := sets.NewString()
+	for _, k := range strings.Split(node.Annotations[annotationKey], ",") {
+		if k != "" {
+			keys.Insert(k)
 		}
 	}
+	return keys
+}
+
+// setManagedKeys records the given keys in the given managed annotation of the node
+func setManagedKeys(node *corev1.Node, annotationKey string, keys sets.String) {
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[annotationKey] = strings.Join(keys.List(), ",")
 }
 
 func (r *ReconcileNodeLink) listNodesByField(key, value string) ([]corev1.Node, error) {
@@ -522,6 +712,35 @@ func (r *ReconcileNodeLink) listMachinesByField(key, value string) ([]mapiv1beta
 	return machineList.Items, nil
 }
 
+// nodeInfoFromNode returns the details of the node which are reported in the machine status
+func nodeInfoFromNode(node *corev1.Node) *mapiv1beta1.MachineNodeInfo {
+	info := &mapiv1beta1.MachineNodeInfo{
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		OSImage:        node.Status.NodeInfo.OSImage,
+		Architecture:   node.Status.NodeInfo.Architecture,
+	}
+
+	if cpu, ok := node.Status.Allocatable[corev1.ResourceCPU]; ok {
+		info.AllocatableCPU = &cpu
+	}
+	if memory, ok := node.Status.Allocatable[corev1.ResourceMemory]; ok {
+		info.AllocatableMemory = &memory
+	}
+
+	if ready := conditions.GetNodeCondition(node, corev1.NodeReady); ready != nil && !ready.LastTransitionTime.IsZero() {
+		lastTransitionTime := ready.LastTransitionTime
+		info.ReadyLastTransitionTime = &lastTransitionTime
+	}
+	return info
+}
+
+// hasNodeRef returns true if the nodeRef of the machine references the node
+func hasNodeRef(machine *mapiv1beta1.Machine, node *corev1.Node) bool {
+	return machine.Status.NodeRef != nil &&
+		machine.Status.NodeRef.Name == node.GetName() &&
+		machine.Status.NodeRef.UID == node.GetUID()
+}
+
 // isNodeReady returns true if a node is ready; false otherwise.
 func isNodeReady(node *corev1.Node) bool {
 	for _, c := range node.Status.Conditions {
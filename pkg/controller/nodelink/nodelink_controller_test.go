@@ -6,13 +6,20 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -106,57 +113,69 @@ func machine(name, providerID string, addresses []corev1.NodeAddress, taints []c
 
 type fakeReconciler struct {
 	ReconcileNodeLink
-	fakeNodeIndexer    map[string]corev1.Node
-	fakeMachineIndexer map[string]mapiv1beta1.Machine
+	// fake indexers map an index name and a value to the indexed objects
+	fakeNodeIndexer    map[string]map[string][]corev1.Node
+	fakeMachineIndexer map[string]map[string][]mapiv1beta1.Machine
 }
 
-func newFakeReconciler(client client.Client, machine *mapiv1beta1.Machine, node *corev1.Node) fakeReconciler {
-	r := fakeReconciler{
+func newFakeReconciler(client client.Client, machine *mapiv1beta1.Machine, node *corev1.Node) *fakeReconciler {
+	matchers, err := parseNodeMatchers(DefaultNodeMatchers)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	r := &fakeReconciler{
 		ReconcileNodeLink: ReconcileNodeLink{
-			client: client,
+			client:    client,
+			recorder:  record.NewFakeRecorder(10),
+			matchers:  matchers,
+			conflicts: sets.NewString(),
 		},
-		fakeNodeIndexer:    make(map[string]corev1.Node),
-		fakeMachineIndexer: make(map[string]mapiv1beta1.Machine),
+		fakeNodeIndexer:    make(map[string]map[string][]corev1.Node),
+		fakeMachineIndexer: make(map[string]map[string][]mapiv1beta1.Machine),
 	}
-	r.listNodesByFieldFunc = func(_, value string) ([]corev1.Node, error) {
-		_, ok := r.fakeNodeIndexer[value]
-		if ok {
-			return []corev1.Node{r.fakeNodeIndexer[value]}, nil
-		}
-		return nil, nil
+	r.listNodesByFieldFunc = func(key, value string) ([]corev1.Node, error) {
+		return r.fakeNodeIndexer[key][value], nil
 	}
-	r.listMachinesByFieldFunc = func(_, value string) ([]mapiv1beta1.Machine, error) {
-		_, ok := r.fakeMachineIndexer[value]
-		if ok {
-			return []mapiv1beta1.Machine{r.fakeMachineIndexer[value]}, nil
-		}
-		return nil, nil
+	r.listMachinesByFieldFunc = func(key, value string) ([]mapiv1beta1.Machine, error) {
+		return r.fakeMachineIndexer[key][value], nil
 	}
 	r.buildFakeNodeIndexer(*node)
 	r.buildFakeMachineIndexer(*machine)
 
-	r.nodeReadinessCache = make(map[string]bool)
+	r.nodeReadinessCache = newReadinessCache(defaultReadinessCacheTTL)
 	return r
 }
 
 func (r *fakeReconciler) buildFakeNodeIndexer(nodes ...corev1.Node) {
-	for i := range nodes {
-		if nodes[i].Spec.ProviderID != "" {
-			r.fakeNodeIndexer[nodes[i].Spec.ProviderID] = nodes[i]
+	for _, matcher := range nodeMatchers {
+		if r.fakeNodeIndexer[matcher.nodeIndex] == nil {
+			r.fakeNodeIndexer[matcher.nodeIndex] = make(map[string][]corev1.Node)
 		}
-		for j := range nodes[i].Status.Addresses {
-			r.fakeNodeIndexer[nodes[i].Status.Addresses[j].Address] = nodes[i]
+		for i := range nodes {
+			for _, value := range matcher.indexNode(&nodes[i]) {
+				r.fakeNodeIndexer[matcher.nodeIndex][value] = append(r.fakeNodeIndexer[matcher.nodeIndex][value], nodes[i])
+			}
 		}
 	}
 }
 
 func (r *fakeReconciler) buildFakeMachineIndexer(machines ...mapiv1beta1.Machine) {
-	for i := range machines {
-		if machines[i].Spec.ProviderID != nil {
-			r.fakeMachineIndexer[*machines[i].Spec.ProviderID] = machines[i]
+	indexers := map[string]client.IndexerFunc{
+		machineNodeRefIndex: indexMachineByNodeRef,
+	}
+	for _, matcher := range nodeMatchers {
+		indexers[matcher.machineIndex] = matcher.indexMachine
+	}
+
+	for index, indexMachine := range indexers {
+		if r.fakeMachineIndexer[index] == nil {
+			r.fakeMachineIndexer[index] = make(map[string][]mapiv1beta1.Machine)
 		}
-		for j := range machines[i].Status.Addresses {
-			r.fakeMachineIndexer[machines[i].Status.Addresses[j].Address] = machines[i]
+		for i := range machines {
+			for _, value := range indexMachine(&machines[i]) {
+				r.fakeMachineIndexer[index][value] = append(r.fakeMachineIndexer[index][value], machines[i])
+			}
 		}
 	}
 }
@@ -186,7 +205,7 @@ func TestFindMachineFromNodeByProviderID(t *testing.T) {
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, tc.machine), tc.machine, tc.node)
 
-		machine, err := r.findMachineFromNodeByProviderID(tc.node)
+		machine, err := r.findMachineFromNodeWith(providerIDMatcher, tc.node)
 		if err != nil {
 			t.Errorf("unexpected error finding machine from node by providerID: %v", err)
 		}
@@ -246,7 +265,7 @@ func TestFindMachineFromNodeByIP(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, tc.machine), tc.machine, tc.node)
-		machine, err := r.findMachineFromNodeByIP(tc.node)
+		machine, err := r.findMachineFromNodeWith(internalIPMatcher, tc.node)
 		if err != nil {
 			t.Errorf("unexpected error finding machine from node by IP: %v", err)
 		}
@@ -282,7 +301,7 @@ func TestFindNodeFromMachineByProviderID(t *testing.T) {
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, tc.node), tc.machine, tc.node)
 
-		node, err := r.findNodeFromMachineByProviderID(tc.machine)
+		node, err := r.findNodeFromMachineWith(providerIDMatcher, tc.machine)
 		if err != nil {
 			t.Errorf("unexpected error finding machine from node by providerID: %v", err)
 		}
@@ -347,7 +366,7 @@ func TestFindNodeFromMachineByIP(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, tc.node), tc.machine, tc.node)
-		node, err := r.findNodeFromMachineByIP(tc.machine)
+		node, err := r.findNodeFromMachineWith(internalIPMatcher, tc.machine)
 		if err != nil {
 			t.Errorf("unexpected error finding node from machine by IP: %v", err)
 		}
@@ -362,8 +381,10 @@ func TestAddTaintsToNode(t *testing.T) {
 	testCases := []struct {
 		description             string
 		nodeTaints              []corev1.Taint
+		nodeAnnotations         map[string]string
 		machineTaints           []corev1.Taint
 		expectedFinalNodeTaints []corev1.Taint
+		expectedManagedTaints   string
 	}{
 		{
 			description:             "no previous taint on node. Machine adds none",
@@ -376,6 +397,7 @@ func TestAddTaintsToNode(t *testing.T) {
 			nodeTaints:              []corev1.Taint{},
 			machineTaints:           []corev1.Taint{{Key: "dedicated", Value: "some-value", Effect: "NoSchedule"}},
 			expectedFinalNodeTaints: []corev1.Taint{{Key: "dedicated", Value: "some-value", Effect: "NoSchedule"}},
+			expectedManagedTaints:   "dedicated:NoSchedule",
 		},
 		{
 			description:   "already taint on node. Machine adds another",
@@ -383,22 +405,247 @@ func TestAddTaintsToNode(t *testing.T) {
 			machineTaints: []corev1.Taint{{Key: "dedicated", Value: "some-value", Effect: "NoSchedule"}},
 			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "some-value", Effect: "Schedule"},
 				{Key: "dedicated", Value: "some-value", Effect: "NoSchedule"}},
+			expectedManagedTaints: "dedicated:NoSchedule",
 		},
 		{
 			description:             "already taint on node. Machine adding same taint",
 			nodeTaints:              []corev1.Taint{{Key: "key1", Value: "v1", Effect: "Schedule"}},
+			nodeAnnotations:         map[string]string{managedTaintsAnnotationKey: ""},
 			machineTaints:           []corev1.Taint{{Key: "key1", Value: "v2", Effect: "Schedule"}},
 			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "Schedule"}},
 		},
+		{
+			description:             "taint added before managed taints were recorded. Machine adding same taint",
+			nodeTaints:              []corev1.Taint{{Key: "key1", Value: "v1", Effect: "Schedule"}},
+			machineTaints:           []corev1.Taint{{Key: "key1", Value: "v1", Effect: "Schedule"}},
+			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "Schedule"}},
+			expectedManagedTaints:   "key1:Schedule",
+		},
 	}
 
 	for _, test := range testCases {
 		machine := machine("", "", nil, test.machineTaints, nil)
 		node := node("", "", nil, test.nodeTaints)
+		node.Annotations = test.nodeAnnotations
 		addTaintsToNode(node, machine)
 		if !reflect.DeepEqual(node.Spec.Taints, test.expectedFinalNodeTaints) {
 			t.Errorf("Test case: %s. Expected: %v, got: %v", test.description, test.expectedFinalNodeTaints, node.Spec.Taints)
 		}
+		if got := node.Annotations[managedTaintsAnnotationKey]; got != test.expectedManagedTaints {
+			t.Errorf("Test case: %s. Expected managed taints: %q, got: %q", test.description, test.expectedManagedTaints, got)
+		}
+	}
+}
+
+func TestSyncLabelsToNode(t *testing.T) {
+	testCases := []struct {
+		description         string
+		nodeLabels          map[string]string
+		nodeAnnotations     map[string]string
+		machineLabels       map[string]string
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			description:         "node without managed labels. Machine adds one",
+			nodeLabels:          map[string]string{"existing": "v1"},
+			nodeAnnotations:     map[string]string{},
+			machineLabels:       map[string]string{"added": "v2"},
+			expectedLabels:      map[string]string{"existing": "v1", "added": "v2"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: "added"},
+		},
+		{
+			description:         "managed label removed from machine",
+			nodeLabels:          map[string]string{"existing": "v1", "removed": "v2", "kept": "v3"},
+			nodeAnnotations:     map[string]string{managedLabelsAnnotationKey: "kept,removed"},
+			machineLabels:       map[string]string{"kept": "v4"},
+			expectedLabels:      map[string]string{"existing": "v1", "kept": "v4"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: "kept"},
+		},
+		{
+			description:         "label already on the node is not recorded as managed",
+			nodeLabels:          map[string]string{"existing": "v1"},
+			nodeAnnotations:     map[string]string{managedLabelsAnnotationKey: ""},
+			machineLabels:       map[string]string{"existing": "v1"},
+			expectedLabels:      map[string]string{"existing": "v1"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: ""},
+		},
+		{
+			description:         "label copied before managed labels were recorded is recorded as managed",
+			nodeLabels:          map[string]string{"existing": "v1", "copied": "v2"},
+			nodeAnnotations:     map[string]string{},
+			machineLabels:       map[string]string{"copied": "v2"},
+			expectedLabels:      map[string]string{"existing": "v1", "copied": "v2"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: "copied"},
+		},
+		{
+			description:         "label changed on the node is recorded as managed",
+			nodeLabels:          map[string]string{"existing": "v1"},
+			nodeAnnotations:     map[string]string{},
+			machineLabels:       map[string]string{"existing": "v2"},
+			expectedLabels:      map[string]string{"existing": "v2"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: "existing"},
+		},
+		{
+			description:         "all managed labels removed from machine",
+			nodeLabels:          map[string]string{"existing": "v1", "removed": "v2"},
+			nodeAnnotations:     map[string]string{managedLabelsAnnotationKey: "removed"},
+			machineLabels:       nil,
+			expectedLabels:      map[string]string{"existing": "v1"},
+			expectedAnnotations: map[string]string{managedLabelsAnnotationKey: ""},
+		},
+	}
+
+	for _, test := range testCases {
+		machine := machine("", "", nil, nil, nil)
+		machine.Spec.Labels = test.machineLabels
+		node := node("", "", nil, nil)
+		node.Labels = test.nodeLabels
+		node.Annotations = test.nodeAnnotations
+		syncLabelsToNode(node, machine)
+		if !reflect.DeepEqual(node.Labels, test.expectedLabels) {
+			t.Errorf("Test case: %s. Expected labels: %v, got: %v", test.description, test.expectedLabels, node.Labels)
+		}
+		if !reflect.DeepEqual(node.Annotations, test.expectedAnnotations) {
+			t.Errorf("Test case: %s. Expected annotations: %v, got: %v", test.description, test.expectedAnnotations, node.Annotations)
+		}
+	}
+}
+
+// TestSyncLabelsToNodeAfterUpgrade checks that the labels copied by a version of nodelink
+// which didn't record them are removed once they are removed from the machine
+func TestSyncLabelsToNodeAfterUpgrade(t *testing.T) {
+	machine := machine("", "", nil, nil, nil)
+	machine.Spec.Labels = map[string]string{"kept": "v1", "removed": "v2"}
+	node := node("", "", nil, nil)
+	node.Labels = map[string]string{"existing": "v0", "kept": "v1", "removed": "v2"}
+	node.Annotations = map[string]string{}
+
+	syncLabelsToNode(node, machine)
+	if got := node.Annotations[managedLabelsAnnotationKey]; got != "kept,removed" {
+		t.Errorf("Expected managed labels: %q, got: %q", "kept,removed", got)
+	}
+
+	delete(machine.Spec.Labels, "removed")
+	syncLabelsToNode(node, machine)
+	expectedLabels := map[string]string{"existing": "v0", "kept": "v1"}
+	if !reflect.DeepEqual(node.Labels, expectedLabels) {
+		t.Errorf("Expected labels: %v, got: %v", expectedLabels, node.Labels)
+	}
+	if got := node.Annotations[managedLabelsAnnotationKey]; got != "kept" {
+		t.Errorf("Expected managed labels: %q, got: %q", "kept", got)
+	}
+}
+
+func TestSyncAnnotationsToNode(t *testing.T) {
+	testCases := []struct {
+		description         string
+		nodeAnnotations     map[string]string
+		machineAnnotations  map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			description:         "node without managed annotations. Machine adds one",
+			nodeAnnotations:     map[string]string{"existing": "v1"},
+			machineAnnotations:  map[string]string{"added": "v2"},
+			expectedAnnotations: map[string]string{"existing": "v1", "added": "v2", managedAnnotationsAnnotationKey: "added"},
+		},
+		{
+			description:         "managed annotation removed from machine",
+			nodeAnnotations:     map[string]string{"existing": "v1", "removed": "v2", managedAnnotationsAnnotationKey: "removed"},
+			machineAnnotations:  nil,
+			expectedAnnotations: map[string]string{"existing": "v1", managedAnnotationsAnnotationKey: ""},
+		},
+		{
+			description:         "annotation already on the node is not recorded as managed",
+			nodeAnnotations:     map[string]string{"existing": "v1", managedAnnotationsAnnotationKey: ""},
+			machineAnnotations:  map[string]string{"existing": "v1"},
+			expectedAnnotations: map[string]string{"existing": "v1", managedAnnotationsAnnotationKey: ""},
+		},
+		{
+			description:         "annotation copied before managed annotations were recorded is recorded as managed",
+			nodeAnnotations:     map[string]string{"existing": "v1", "copied": "v2"},
+			machineAnnotations:  map[string]string{"copied": "v2"},
+			expectedAnnotations: map[string]string{"existing": "v1", "copied": "v2", managedAnnotationsAnnotationKey: "copied"},
+		},
+		{
+			description:         "reserved annotations are not copied",
+			nodeAnnotations:     map[string]string{machineAnnotationKey: "namespace/name"},
+			machineAnnotations:  map[string]string{machineAnnotationKey: "other/name", managedLabelsAnnotationKey: "foo"},
+			expectedAnnotations: map[string]string{machineAnnotationKey: "namespace/name", managedAnnotationsAnnotationKey: ""},
+		},
+	}
+
+	for _, test := range testCases {
+		machine := machine("", "", nil, nil, nil)
+		machine.Spec.Annotations = test.machineAnnotations
+		node := node("", "", nil, nil)
+		node.Annotations = test.nodeAnnotations
+		syncAnnotationsToNode(node, machine)
+		if !reflect.DeepEqual(node.Annotations, test.expectedAnnotations) {
+			t.Errorf("Test case: %s. Expected: %v, got: %v", test.description, test.expectedAnnotations, node.Annotations)
+		}
+	}
+}
+
+func TestRemoveTaintsFromNode(t *testing.T) {
+	testCases := []struct {
+		description             string
+		nodeTaints              []corev1.Taint
+		nodeAnnotations         map[string]string
+		machineTaints           []corev1.Taint
+		expectedFinalNodeTaints []corev1.Taint
+		expectedAnnotations     map[string]string
+	}{
+		{
+			description:             "node without managed taints. Nothing is removed",
+			nodeTaints:              []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			nodeAnnotations:         map[string]string{},
+			machineTaints:           []corev1.Taint{},
+			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			expectedAnnotations:     map[string]string{managedTaintsAnnotationKey: ""},
+		},
+		{
+			description:             "taint already on the node is not recorded as managed",
+			nodeTaints:              []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			nodeAnnotations:         map[string]string{managedTaintsAnnotationKey: ""},
+			machineTaints:           []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			expectedAnnotations:     map[string]string{managedTaintsAnnotationKey: ""},
+		},
+		{
+			description:             "managed taint still on machine stays managed",
+			nodeTaints:              []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			nodeAnnotations:         map[string]string{managedTaintsAnnotationKey: "key1:NoSchedule"},
+			machineTaints:           []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoSchedule"}},
+			expectedAnnotations:     map[string]string{managedTaintsAnnotationKey: "key1:NoSchedule"},
+		},
+		{
+			description: "managed taint removed from machine",
+			nodeTaints: []corev1.Taint{
+				{Key: "key1", Value: "v1", Effect: "NoSchedule"},
+				{Key: "key1", Value: "v1", Effect: "NoExecute"},
+				{Key: "unmanaged", Value: "v2", Effect: "NoSchedule"},
+			},
+			nodeAnnotations:         map[string]string{managedTaintsAnnotationKey: "key1:NoExecute,key1:NoSchedule"},
+			machineTaints:           []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoExecute"}},
+			expectedFinalNodeTaints: []corev1.Taint{{Key: "key1", Value: "v1", Effect: "NoExecute"}, {Key: "unmanaged", Value: "v2", Effect: "NoSchedule"}},
+			expectedAnnotations:     map[string]string{managedTaintsAnnotationKey: "key1:NoExecute"},
+		},
+	}
+
+	for _, test := range testCases {
+		machine := machine("", "", nil, test.machineTaints, nil)
+		node := node("", "", nil, test.nodeTaints)
+		node.Annotations = test.nodeAnnotations
+		removeTaintsFromNode(node, machine)
+		if !reflect.DeepEqual(node.Spec.Taints, test.expectedFinalNodeTaints) {
+			t.Errorf("Test case: %s. Expected: %v, got: %v", test.description, test.expectedFinalNodeTaints, node.Spec.Taints)
+		}
+		if !reflect.DeepEqual(node.Annotations, test.expectedAnnotations) {
+			t.Errorf("Test case: %s. Expected annotations: %v, got: %v", test.description, test.expectedAnnotations, node.Annotations)
+		}
 	}
 }
 
@@ -759,6 +1006,9 @@ func TestUpdateNodeRef(t *testing.T) {
 
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, tc.machine), tc.machine, tc.node)
+		if ready, ok := tc.nodeReadinessCache[tc.node.GetName()]; ok {
+			r.nodeReadinessCache.set(tc.node, ready)
+		}
 		if tc.node.GetName() == "deleting" {
 			now := metav1.Now()
 			tc.node.DeletionTimestamp = &now
@@ -786,6 +1036,186 @@ func TestUpdateNodeRef(t *testing.T) {
 	}
 }
 
+func TestUpdateNodeRefNodeInfo(t *testing.T) {
+	testMachine := machine("machine", "", nil, nil, nil)
+	testNode := node("node", "", nil, nil)
+	testNode.Status.NodeInfo = corev1.NodeSystemInfo{
+		KubeletVersion: "v1.20.0",
+		OSImage:        "Red Hat Enterprise Linux CoreOS",
+		Architecture:   "amd64",
+	}
+	testNode.Status.Allocatable = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("3500m"),
+		corev1.ResourceMemory: resource.MustParse("15Gi"),
+		corev1.ResourcePods:   resource.MustParse("250"),
+	}
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, testMachine), testMachine, testNode)
+	machineKey := client.ObjectKey{Namespace: testMachine.GetNamespace(), Name: testMachine.GetName()}
+
+	got := &mapiv1beta1.Machine{}
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if err := r.updateNodeRef(got, testNode); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+
+	cpu := resource.MustParse("3500m")
+	memory := resource.MustParse("15Gi")
+	expected := &mapiv1beta1.MachineNodeInfo{
+		KubeletVersion:          "v1.20.0",
+		OSImage:                 "Red Hat Enterprise Linux CoreOS",
+		Architecture:            "amd64",
+		AllocatableCPU:          &cpu,
+		AllocatableMemory:       &memory,
+		ReadyLastTransitionTime: &knownDate,
+	}
+	if !equality.Semantic.DeepEqual(got.Status.NodeInfo, expected) {
+		t.Errorf("expected node info: %+v, got: %+v", expected, got.Status.NodeInfo)
+	}
+
+	// a change of the node info is written even if the readiness did not change
+	testNode.Status.NodeInfo.KubeletVersion = "v1.20.1"
+	if err := r.updateNodeRef(got, testNode); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if got.Status.NodeInfo == nil || got.Status.NodeInfo.KubeletVersion != "v1.20.1" {
+		t.Errorf("expected kubelet version to be updated, got: %+v", got.Status.NodeInfo)
+	}
+}
+
+func TestReconcileNodeConflict(t *testing.T) {
+	testNode := node("node", "providerID", nil, nil)
+	// machineA references the node, e.g. its IP address was reused by the node of machineB
+	machineA := machine("machineA", "", nil, nil, &corev1.ObjectReference{Kind: "Node", Name: testNode.GetName()})
+	machineB := machine("machineB", "providerID", nil, nil, nil)
+	deletingMachine := machine("deleting", "", nil, nil, &corev1.ObjectReference{Kind: "Node", Name: testNode.GetName()})
+	now := metav1.Now()
+	deletingMachine.DeletionTimestamp = &now
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, machineA, machineB, testNode), machineB, testNode)
+	r.buildFakeMachineIndexer(*machineA, *deletingMachine)
+	request := reconcile.Request{NamespacedName: client.ObjectKey{Name: testNode.GetName()}}
+
+	if _, err := r.Reconcile(ctx, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedMessage := `Node "node" is claimed by more than one machine: machineB, machineA`
+	for _, m := range []*mapiv1beta1.Machine{machineA, machineB} {
+		got := &mapiv1beta1.Machine{}
+		if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()}, got); err != nil {
+			t.Fatalf("unexpected error getting machine: %v", err)
+		}
+		if !reflect.DeepEqual(got.Status.NodeRef, m.Status.NodeRef) {
+			t.Errorf("expected machine %q nodeRef to be unchanged, got: %v", m.GetName(), got.Status.NodeRef)
+		}
+		condition := conditions.Get(got, mapiv1beta1.NodeLinkedCondition)
+		if condition == nil || condition.Reason != mapiv1beta1.NodeConflictReason || condition.Message != expectedMessage {
+			t.Errorf("expected machine %q to have a %s condition with message %q, got: %v", m.GetName(), mapiv1beta1.NodeConflictReason, expectedMessage, condition)
+		}
+	}
+	if r.conflicts.Len() != 1 {
+		t.Errorf("expected 1 unresolved conflict, got: %v", r.conflicts.List())
+	}
+
+	// once machineA no longer references the node, machineB is linked
+	for index := range r.fakeMachineIndexer {
+		delete(r.fakeMachineIndexer, index)
+	}
+	got := &mapiv1beta1.Machine{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: machineB.GetNamespace(), Name: machineB.GetName()}, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	r.buildFakeMachineIndexer(*got, *deletingMachine)
+
+	if _, err := r.Reconcile(ctx, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: machineB.GetNamespace(), Name: machineB.GetName()}, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if got.Status.NodeRef == nil || got.Status.NodeRef.Name != testNode.GetName() {
+		t.Errorf("expected machine to reference node %q, got: %v", testNode.GetName(), got.Status.NodeRef)
+	}
+	if r.conflicts.Len() != 0 {
+		t.Errorf("expected no unresolved conflicts, got: %v", r.conflicts.List())
+	}
+}
+
+func TestReconcileConcurrently(t *testing.T) {
+	// nodes are reconciled by concurrent workers as they would with --max-concurrent-reconciles.
+	// Every other node is claimed by two machines, so conflicts are tracked concurrently as well.
+	const pairs = 10
+	var objects []runtime.Object
+	var nodes []corev1.Node
+	var machines []mapiv1beta1.Machine
+	for i := 0; i < pairs; i++ {
+		providerID := fmt.Sprintf("providerID-%d", i)
+		testNode := node(fmt.Sprintf("node-%d", i), providerID, nil, nil)
+		testMachine := machine(fmt.Sprintf("machine-%d", i), providerID, nil, nil, nil)
+		objects = append(objects, testNode, testMachine)
+		nodes = append(nodes, *testNode)
+		machines = append(machines, *testMachine)
+		if i%2 == 1 {
+			duplicate := machine(fmt.Sprintf("duplicate-%d", i), providerID, nil, nil, nil)
+			objects = append(objects, duplicate)
+			machines = append(machines, *duplicate)
+		}
+	}
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, objects...), &machines[0], &nodes[0])
+	r.recorder = record.NewFakeRecorder(10 * pairs)
+	r.buildFakeNodeIndexer(nodes[1:]...)
+	r.buildFakeMachineIndexer(machines[1:]...)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, pairs)
+	for i := range nodes {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: name}}); err != nil {
+				errs <- err
+			}
+		}(nodes[i].GetName())
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < pairs; i++ {
+		got := &mapiv1beta1.Machine{}
+		if err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: fmt.Sprintf("machine-%d", i)}, got); err != nil {
+			t.Fatalf("unexpected error getting machine: %v", err)
+		}
+		if i%2 == 1 {
+			if got.Status.NodeRef != nil {
+				t.Errorf("expected conflicting machine %q not to reference a node, got: %v", got.GetName(), got.Status.NodeRef)
+			}
+			continue
+		}
+		if got.Status.NodeRef == nil || got.Status.NodeRef.Name != nodes[i].GetName() {
+			t.Errorf("expected machine %q to reference node %q, got: %v", got.GetName(), nodes[i].GetName(), got.Status.NodeRef)
+		}
+	}
+	if r.conflicts.Len() != pairs/2 {
+		t.Errorf("expected %d unresolved conflicts, got: %v", pairs/2, r.conflicts.List())
+	}
+	if r.nodeReadinessCache.len() != pairs/2 {
+		t.Errorf("expected the readiness of %d nodes to be cached, got %d", pairs/2, r.nodeReadinessCache.len())
+	}
+}
+
 func TestFindMachineFromNodeDoesNotPanicBZ1747246(t *testing.T) {
 	testMachine := machine("matchingInternalIP", "test", []corev1.NodeAddress{
 		{
@@ -0,0 +1,308 @@
+package nodelink
+
+import (
+	"fmt"
+	"strings"
+
+	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// nodeNameAnnotationKey can be set on a machine to explicitly name the node backed by it
+	nodeNameAnnotationKey = "machine.openshift.io/node-name"
+
+	machineNodeNameIndex    = "machineNodeNameIndex"
+	machineExternalIPIndex  = "machineExternalIPIndex"
+	machineInternalDNSIndex = "machineInternalDNSIndex"
+	machineExternalDNSIndex = "machineExternalDNSIndex"
+	machineHostnameIndex    = "machineHostnameIndex"
+	nodeNameIndex           = "nodeNameIndex"
+	nodeExternalIPIndex     = "nodeExternalIPIndex"
+	nodeInternalDNSIndex    = "nodeInternalDNSIndex"
+	nodeExternalDNSIndex    = "nodeExternalDNSIndex"
+	nodeHostnameIndex       = "nodeHostnameIndex"
+)
+
+const (
+	nodeMatcherNameProviderID = "ProviderID"
+	nodeMatcherNameAnnotation = "NodeNameAnnotation"
+	nodeMatcherNameHostname   = "Hostname"
+)
+
+// nodeMatcher links machines and nodes which share a value of a single attribute,
+// e.g. the providerID or an address of a given type.
+// The values are looked up through one cache index for nodes and one for machines.
+type nodeMatcher struct {
+	// name identifies the matcher in the configured priority order
+	name         string
+	nodeIndex    string
+	machineIndex string
+	indexNode    client.IndexerFunc
+	indexMachine client.IndexerFunc
+}
+
+var (
+	providerIDMatcher = nodeMatcher{
+		name:         nodeMatcherNameProviderID,
+		nodeIndex:    nodeProviderIDIndex,
+		machineIndex: machineProviderIDIndex,
+		indexNode:    indexNodeByProviderID,
+		indexMachine: indexMachineByProvider,
+	}
+	nodeNameAnnotationMatcher = nodeMatcher{
+		name:         nodeMatcherNameAnnotation,
+		nodeIndex:    nodeNameIndex,
+		machineIndex: machineNodeNameIndex,
+		indexNode:    indexNodeByName,
+		indexMachine: indexMachineByNodeNameAnnotation,
+	}
+	internalIPMatcher = nodeMatcher{
+		name:         string(corev1.NodeInternalIP),
+		nodeIndex:    nodeInternalIPIndex,
+		machineIndex: machineInternalIPIndex,
+		indexNode:    indexNodeByInternalIP,
+		indexMachine: indexMachineByInternalIP,
+	}
+	externalIPMatcher  = addressMatcher(corev1.NodeExternalIP, nodeExternalIPIndex, machineExternalIPIndex)
+	internalDNSMatcher = addressMatcher(corev1.NodeInternalDNS, nodeInternalDNSIndex, machineInternalDNSIndex)
+	externalDNSMatcher = addressMatcher(corev1.NodeExternalDNS, nodeExternalDNSIndex, machineExternalDNSIndex)
+	hostnameMatcher    = nodeMatcher{
+		name:         nodeMatcherNameHostname,
+		nodeIndex:    nodeHostnameIndex,
+		machineIndex: machineHostnameIndex,
+		indexNode:    indexNodeByHostname,
+		indexMachine: indexMachineByAddress(corev1.NodeHostName),
+	}
+
+	// nodeMatchers are all the available node matchers by name
+	nodeMatchers = map[string]nodeMatcher{
+		providerIDMatcher.name:         providerIDMatcher,
+		nodeNameAnnotationMatcher.name: nodeNameAnnotationMatcher,
+		internalIPMatcher.name:         internalIPMatcher,
+		externalIPMatcher.name:         externalIPMatcher,
+		internalDNSMatcher.name:        internalDNSMatcher,
+		externalDNSMatcher.name:        externalDNSMatcher,
+		hostnameMatcher.name:           hostnameMatcher,
+	}
+
+	// DefaultNodeMatchers is the priority order of node matchers used unless configured otherwise.
+	// Matching by external IP or DNS name is opt-in as several nodes may share them, e.g. behind NAT.
+	DefaultNodeMatchers = []string{
+		providerIDMatcher.name,
+		nodeNameAnnotationMatcher.name,
+		internalIPMatcher.name,
+	}
+)
+
+// NodeMatcherNames returns the names of all the available node matchers, sorted
+func NodeMatcherNames() []string {
+	return sets.StringKeySet(nodeMatchers).List()
+}
+
+// parseNodeMatchers returns the node matchers for the given names, in the same order
+func parseNodeMatchers(names []string) ([]nodeMatcher, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one node matcher is required")
+	}
+
+	seen := sets.NewString()
+	var matchers []nodeMatcher
+	for _, name := range names {
+		matcher, ok := nodeMatchers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown node matcher %q, must be one of: %s", name, strings.Join(NodeMatcherNames(), ", "))
+		}
+		if seen.Has(name) {
+			return nil, fmt.Errorf("node matcher %q is configured more than once", name)
+		}
+		seen.Insert(name)
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// addressMatcher returns a node matcher for the given address type
+func addressMatcher(addressType corev1.NodeAddressType, nodeIndex, machineIndex string) nodeMatcher {
+	return nodeMatcher{
+		name:         string(addressType),
+		nodeIndex:    nodeIndex,
+		machineIndex: machineIndex,
+		indexNode:    indexNodeByAddress(addressType),
+		indexMachine: indexMachineByAddress(addressType),
+	}
+}
+
+func indexNodeByAddress(addressType corev1.NodeAddressType) client.IndexerFunc {
+	return func(object client.Object) []string {
+		node, ok := object.(*corev1.Node)
+		if !ok {
+			klog.Warningf("Expected a node for indexing field, got: %T", object)
+			return nil
+		}
+		return addressesOfType(node.Status.Addresses, addressType)
+	}
+}
+
+func indexMachineByAddress(addressType corev1.NodeAddressType) client.IndexerFunc {
+	return func(object client.Object) []string {
+		machine, ok := object.(*mapiv1beta1.Machine)
+		if !ok {
+			klog.Warningf("Expected a machine for indexing field, got: %T", object)
+			return nil
+		}
+		return addressesOfType(machine.Status.Addresses, addressType)
+	}
+}
+
+func indexNodeByName(object client.Object) []string {
+	if node, ok := object.(*corev1.Node); ok {
+		return []string{node.GetName()}
+	}
+	klog.Warningf("Expected a node for indexing field, got: %T", object)
+	return nil
+}
+
+func indexMachineByNodeNameAnnotation(object client.Object) []string {
+	machine, ok := object.(*mapiv1beta1.Machine)
+	if !ok {
+		klog.Warningf("Expected a machine for indexing field, got: %T", object)
+		return nil
+	}
+	if nodeName := machine.GetAnnotations()[nodeNameAnnotationKey]; nodeName != "" {
+		return []string{nodeName}
+	}
+	return nil
+}
+
+// indexNodeByHostname indexes nodes by their name and their hostname addresses,
+// as the node name defaults to the hostname of the instance
+func indexNodeByHostname(object client.Object) []string {
+	node, ok := object.(*corev1.Node)
+	if !ok {
+		klog.Warningf("Expected a node for indexing field, got: %T", object)
+		return nil
+	}
+	keys := sets.NewString(node.GetName())
+	keys.Insert(addressesOfType(node.Status.Addresses, corev1.NodeHostName)...)
+	return keys.List()
+}
+
+func addressesOfType(addresses []corev1.NodeAddress, addressType corev1.NodeAddressType) []string {
+	var keys []string
+	for _, a := range addresses {
+		if a.Type == addressType && a.Address != "" {
+			keys = append(keys, a.Address)
+		}
+	}
+	return keys
+}
+
+// ambiguousMatchError is returned when a node matcher finds more than one candidate
+type ambiguousMatchError struct {
+	matcher    string
+	kind       string
+	candidates []string
+	// machines are the candidate machines when looking for the machine of a node
+	machines []mapiv1beta1.Machine
+	// nodes are the candidate nodes when looking for the node of a machine
+	nodes []corev1.Node
+}
+
+func (e *ambiguousMatchError) Error() string {
+	return fmt.Sprintf("%s matcher found more than one %s: %s", e.matcher, e.kind, strings.Join(e.candidates, ", "))
+}
+
+// nodesForMachine returns the nodes matching any of the matcher values of the machine
+func (r *ReconcileNodeLink) nodesForMachine(matcher nodeMatcher, machine *mapiv1beta1.Machine) ([]corev1.Node, error) {
+	seen := sets.NewString()
+	var nodes []corev1.Node
+	for _, value := range matcher.indexMachine(machine) {
+		candidates, err := r.listNodesByFieldFunc(matcher.nodeIndex, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting node list: %v", err)
+		}
+		for i := range candidates {
+			if !seen.Has(candidates[i].GetName()) {
+				seen.Insert(candidates[i].GetName())
+				nodes = append(nodes, candidates[i])
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// machinesForNode returns the machines matching any of the matcher values of the node
+func (r *ReconcileNodeLink) machinesForNode(matcher nodeMatcher, node *corev1.Node) ([]mapiv1beta1.Machine, error) {
+	seen := sets.NewString()
+	var machines []mapiv1beta1.Machine
+	for _, value := range matcher.indexNode(node) {
+		candidates, err := r.listMachinesByFieldFunc(matcher.machineIndex, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting machine list: %v", err)
+		}
+		for i := range candidates {
+			key := fmt.Sprintf("%s/%s", candidates[i].GetNamespace(), candidates[i].GetName())
+			if !seen.Has(key) {
+				seen.Insert(key)
+				machines = append(machines, candidates[i])
+			}
+		}
+	}
+	return machines, nil
+}
+
+// findNodeFromMachineWith returns the single node matching the machine with the given matcher.
+// It returns an ambiguousMatchError if more than one node matches.
+func (r *ReconcileNodeLink) findNodeFromMachineWith(matcher nodeMatcher, machine *mapiv1beta1.Machine) (*corev1.Node, error) {
+	klog.V(3).Infof("Finding node from machine %q by %s", machine.GetName(), matcher.name)
+	nodes, err := r.nodesForMachine(matcher, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) > 1 {
+		names := make([]string, 0, len(nodes))
+		for i := range nodes {
+			names = append(names, nodes[i].GetName())
+		}
+		return nil, &ambiguousMatchError{matcher: matcher.name, kind: "node", candidates: names, nodes: nodes}
+	}
+
+	if len(nodes) == 1 {
+		klog.V(3).Infof("Found node %q for machine %q by %s", nodes[0].GetName(), machine.GetName(), matcher.name)
+		return nodes[0].DeepCopy(), nil
+	}
+
+	klog.V(3).Infof("Matching node not found for machine %q by %s", machine.GetName(), matcher.name)
+	return nil, nil
+}
+
+// findMachineFromNodeWith returns the single machine matching the node with the given matcher.
+// It returns an ambiguousMatchError if more than one machine matches.
+func (r *ReconcileNodeLink) findMachineFromNodeWith(matcher nodeMatcher, node *corev1.Node) (*mapiv1beta1.Machine, error) {
+	klog.V(3).Infof("Finding machine from node %q by %s", node.GetName(), matcher.name)
+	machines, err := r.machinesForNode(matcher, node)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(machines) > 1 {
+		names := make([]string, 0, len(machines))
+		for i := range machines {
+			names = append(names, machines[i].GetName())
+		}
+		return nil, &ambiguousMatchError{matcher: matcher.name, kind: "machine", candidates: names, machines: machines}
+	}
+
+	if len(machines) == 1 {
+		klog.V(3).Infof("Found machine %q for node %q by %s", machines[0].GetName(), node.GetName(), matcher.name)
+		return machines[0].DeepCopy(), nil
+	}
+
+	klog.V(3).Infof("Matching machine not found for node %q by %s", node.GetName(), matcher.name)
+	return nil, nil
+}
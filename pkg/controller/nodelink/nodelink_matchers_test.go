@@ -0,0 +1,325 @@
+package nodelink
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestParseNodeMatchers(t *testing.T) {
+	testCases := []struct {
+		description   string
+		names         []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			description: "default node matchers",
+			names:       DefaultNodeMatchers,
+			expected:    []string{"ProviderID", "NodeNameAnnotation", "InternalIP"},
+		},
+		{
+			description: "custom priority order",
+			names:       []string{"Hostname", "ExternalDNS", "ProviderID"},
+			expected:    []string{"Hostname", "ExternalDNS", "ProviderID"},
+		},
+		{
+			description:   "no node matchers",
+			names:         nil,
+			expectedError: "at least one node matcher is required",
+		},
+		{
+			description:   "unknown node matcher",
+			names:         []string{"ProviderID", "MACAddress"},
+			expectedError: `unknown node matcher "MACAddress"`,
+		},
+		{
+			description:   "duplicated node matcher",
+			names:         []string{"ProviderID", "InternalIP", "ProviderID"},
+			expectedError: `node matcher "ProviderID" is configured more than once`,
+		},
+	}
+
+	for _, tc := range testCases {
+		matchers, err := parseNodeMatchers(tc.names)
+		if tc.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Test case: %s. Expected error to contain %q, got: %v", tc.description, tc.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test case: %s. Unexpected error: %v", tc.description, err)
+			continue
+		}
+
+		var names []string
+		for _, matcher := range matchers {
+			names = append(names, matcher.name)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("Test case: %s. Expected: %v, got: %v", tc.description, tc.expected, names)
+		}
+	}
+}
+
+func TestFindNodeFromMachineWithMatchers(t *testing.T) {
+	annotatedMachine := machine("annotated", "", nil, nil, nil)
+	annotatedMachine.Annotations = map[string]string{nodeNameAnnotationKey: "explicit"}
+
+	testCases := []struct {
+		description   string
+		matchers      []string
+		machine       *mapiv1beta1.Machine
+		nodes         []corev1.Node
+		expected      string
+		expectedError bool
+	}{
+		{
+			description: "external DNS is matched when configured",
+			matchers:    []string{"ProviderID", "ExternalDNS"},
+			machine:     machine("externalDNS", "", []corev1.NodeAddress{{Type: corev1.NodeExternalDNS, Address: "node.example.com"}}, nil, nil),
+			nodes: []corev1.Node{
+				*node("externalDNS", "", []corev1.NodeAddress{{Type: corev1.NodeExternalDNS, Address: "node.example.com"}}, nil),
+			},
+			expected: "externalDNS",
+		},
+		{
+			description: "external DNS is not matched unless configured",
+			matchers:    DefaultNodeMatchers,
+			machine:     machine("externalDNS", "", []corev1.NodeAddress{{Type: corev1.NodeExternalDNS, Address: "node.example.com"}}, nil, nil),
+			nodes: []corev1.Node{
+				*node("externalDNS", "", []corev1.NodeAddress{{Type: corev1.NodeExternalDNS, Address: "node.example.com"}}, nil),
+			},
+			expected: "",
+		},
+		{
+			description: "any internal IP of a dual-stack machine is matched",
+			matchers:    DefaultNodeMatchers,
+			machine: machine("dualStack", "", []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeInternalIP, Address: "fd00::1"},
+			}, nil, nil),
+			nodes: []corev1.Node{
+				*node("ipv6Only", "", []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "fd00::1"}}, nil),
+			},
+			expected: "ipv6Only",
+		},
+		{
+			description: "hostname is matched against the node name",
+			matchers:    []string{"Hostname"},
+			machine:     machine("hostname", "", []corev1.NodeAddress{{Type: corev1.NodeHostName, Address: "worker-0"}}, nil, nil),
+			nodes: []corev1.Node{
+				*node("worker-0", "", nil, nil),
+			},
+			expected: "worker-0",
+		},
+		{
+			description: "node name annotation is matched",
+			matchers:    DefaultNodeMatchers,
+			machine:     annotatedMachine,
+			nodes: []corev1.Node{
+				*node("explicit", "", nil, nil),
+			},
+			expected: "explicit",
+		},
+		{
+			description: "higher priority matcher wins",
+			matchers:    []string{"ProviderID", "InternalIP"},
+			machine:     machine("priority", "providerID", []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}, nil, nil),
+			nodes: []corev1.Node{
+				*node("byInternalIP", "", []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}, nil),
+				*node("byProviderID", "providerID", nil, nil),
+			},
+			expected: "byProviderID",
+		},
+		{
+			description: "more than one candidate is ambiguous",
+			matchers:    []string{"ProviderID", "ExternalIP", "InternalIP"},
+			machine: machine("ambiguous", "", []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			}, nil, nil),
+			nodes: []corev1.Node{
+				*node("behindNAT-0", "", []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "1.2.3.4"}}, nil),
+				*node("behindNAT-1", "", []corev1.NodeAddress{
+					{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+					{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				}, nil),
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme), tc.machine, &tc.nodes[0])
+		r.buildFakeNodeIndexer(tc.nodes[1:]...)
+		matchers, err := parseNodeMatchers(tc.matchers)
+		if err != nil {
+			t.Fatalf("Test case: %s. Unexpected error: %v", tc.description, err)
+		}
+		r.matchers = matchers
+
+		got, err := r.findNodeFromMachine(tc.machine)
+		if tc.expectedError {
+			if _, ok := err.(*ambiguousMatchError); !ok {
+				t.Errorf("Test case: %s. Expected an ambiguous match error, got: %v", tc.description, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test case: %s. Unexpected error: %v", tc.description, err)
+		}
+
+		var gotName string
+		if got != nil {
+			gotName = got.GetName()
+		}
+		if gotName != tc.expected {
+			t.Errorf("Test case: %s. Expected node %q, got: %q", tc.description, tc.expected, gotName)
+		}
+	}
+}
+
+func TestReconcileAmbiguousMatch(t *testing.T) {
+	addresses := []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	machineA := machine("machineA", "", addresses, nil, nil)
+	machineB := machine("machineB", "", addresses, nil, nil)
+	testNode := node("node", "", addresses, nil)
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, machineA, machineB, testNode), machineA, testNode)
+	r.buildFakeMachineIndexer(*machineB)
+
+	if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: testNode.GetName()}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, m := range []*mapiv1beta1.Machine{machineA, machineB} {
+		got := &mapiv1beta1.Machine{}
+		if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: m.GetNamespace(), Name: m.GetName()}, got); err != nil {
+			t.Fatalf("Unexpected error getting machine: %v", err)
+		}
+		if got.Status.NodeRef != nil {
+			t.Errorf("Expected machine %q to have no nodeRef, got: %v", m.GetName(), got.Status.NodeRef)
+		}
+		condition := conditions.Get(got, mapiv1beta1.NodeLinkedCondition)
+		if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != mapiv1beta1.NodeConflictReason {
+			t.Errorf("Expected machine %q to have a false %s condition, got: %v", m.GetName(), mapiv1beta1.NodeLinkedCondition, condition)
+		}
+	}
+
+	events := r.recorder.(*record.FakeRecorder).Events
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got: %d", len(events))
+	}
+	for i := 0; i < 2 && len(events) > 0; i++ {
+		if event := <-events; !strings.Contains(event, mapiv1beta1.NodeConflictReason) {
+			t.Errorf("Expected a %s event, got: %q", mapiv1beta1.NodeConflictReason, event)
+		}
+	}
+
+	// once the conflict is resolved the machine is linked again
+	machineB.Status.Addresses = nil
+	for index := range r.fakeMachineIndexer {
+		delete(r.fakeMachineIndexer, index)
+	}
+	got := &mapiv1beta1.Machine{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: machineA.GetNamespace(), Name: machineA.GetName()}, got); err != nil {
+		t.Fatalf("Unexpected error getting machine: %v", err)
+	}
+	r.buildFakeMachineIndexer(*got, *machineB)
+
+	if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: testNode.GetName()}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: machineA.GetNamespace(), Name: machineA.GetName()}, got); err != nil {
+		t.Fatalf("Unexpected error getting machine: %v", err)
+	}
+	if got.Status.NodeRef == nil || got.Status.NodeRef.Name != testNode.GetName() {
+		t.Errorf("Expected machine to reference node %q, got: %v", testNode.GetName(), got.Status.NodeRef)
+	}
+	if !conditions.IsTrue(got, mapiv1beta1.NodeLinkedCondition) {
+		t.Errorf("Expected machine to have a true %s condition", mapiv1beta1.NodeLinkedCondition)
+	}
+}
+
+func TestNodeRequestFromMachineAmbiguousMatch(t *testing.T) {
+	addresses := []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	testMachine := machine("machine", "", addresses, nil, nil)
+	nodeA := node("nodeA", "", addresses, nil)
+	nodeB := node("nodeB", "", addresses, nil)
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, testMachine), testMachine, nodeA)
+	r.buildFakeNodeIndexer(*nodeB)
+
+	expected := []reconcile.Request{
+		{NamespacedName: client.ObjectKey{Name: nodeA.GetName()}},
+		{NamespacedName: client.ObjectKey{Name: nodeB.GetName()}},
+	}
+	if requests := r.nodeRequestFromMachine(testMachine); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests: %v, got: %v", expected, requests)
+	}
+
+	// mapping the machine to its nodes must not modify it
+	got := &mapiv1beta1.Machine{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: testMachine.GetNamespace(), Name: testMachine.GetName()}, got); err != nil {
+		t.Fatalf("Unexpected error getting machine: %v", err)
+	}
+	if condition := conditions.Get(got, mapiv1beta1.NodeLinkedCondition); condition != nil {
+		t.Errorf("Expected no %s condition, got: %v", mapiv1beta1.NodeLinkedCondition, condition)
+	}
+}
+
+func TestReconcileAmbiguousNodeMatch(t *testing.T) {
+	addresses := []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	testMachine := machine("machine", "", addresses, nil, nil)
+	nodeA := node("nodeA", "", addresses, nil)
+	nodeB := node("nodeB", "", addresses, nil)
+
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, testMachine, nodeA, nodeB), testMachine, nodeA)
+	r.buildFakeNodeIndexer(*nodeB)
+
+	got := &mapiv1beta1.Machine{}
+	for _, n := range []*corev1.Node{nodeA, nodeB} {
+		if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: n.GetName()}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// keep the indexed machine up to date, as the cache would
+		if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: testMachine.GetNamespace(), Name: testMachine.GetName()}, got); err != nil {
+			t.Fatalf("Unexpected error getting machine: %v", err)
+		}
+		for index := range r.fakeMachineIndexer {
+			delete(r.fakeMachineIndexer, index)
+		}
+		r.buildFakeMachineIndexer(*got)
+	}
+
+	if got.Status.NodeRef != nil {
+		t.Errorf("Expected machine to have no nodeRef, got: %v", got.Status.NodeRef)
+	}
+	expected := "Unable to link machine to a node: InternalIP matcher found more than one node: nodeA, nodeB"
+	condition := conditions.Get(got, mapiv1beta1.NodeLinkedCondition)
+	if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != mapiv1beta1.AmbiguousNodeMatchReason || condition.Message != expected {
+		t.Errorf("Expected a false %s condition with message %q, got: %v", mapiv1beta1.NodeLinkedCondition, expected, condition)
+	}
+
+	// the event is only recorded when the condition changes
+	events := r.recorder.(*record.FakeRecorder).Events
+	if len(events) != 1 {
+		t.Errorf("Expected 1 event, got: %d", len(events))
+	}
+	if event := <-events; !strings.Contains(event, mapiv1beta1.AmbiguousNodeMatchReason) {
+		t.Errorf("Expected a %s event, got: %q", mapiv1beta1.AmbiguousNodeMatchReason, event)
+	}
+}
@@ -0,0 +1,91 @@
+package nodelink
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// defaultReadinessCacheTTL bounds for how long the readiness of a node is remembered.
+// Once it expires the nodeRef of the machine is refreshed even if the readiness did not change.
+const defaultReadinessCacheTTL = 30 * time.Minute
+
+// readinessCache remembers the readiness of nodes as last written to the nodeRef of their machine,
+// so that machines are only updated when the readiness of their node changes.
+// It is safe for concurrent use. Entries are keyed by node name and only match a node with the
+// same UID, so a node recreated under the same name is not mistaken for the old one.
+// Entries expire after the TTL, so nodes whose deletion was never observed do not stay forever.
+type readinessCache struct {
+	lock      sync.Mutex
+	ttl       time.Duration
+	now       func() time.Time
+	lastSweep time.Time
+	entries   map[string]readinessEntry
+}
+
+type readinessEntry struct {
+	uid     types.UID
+	ready   bool
+	expires time.Time
+}
+
+func newReadinessCache(ttl time.Duration) *readinessCache {
+	return &readinessCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]readinessEntry{},
+	}
+}
+
+// get returns the cached readiness of the node and whether it was found
+func (c *readinessCache) get(node *corev1.Node) (bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[node.GetName()]
+	if !ok || entry.uid != node.GetUID() || !c.now().Before(entry.expires) {
+		return false, false
+	}
+	return entry.ready, true
+}
+
+// set caches the readiness of the node and drops expired entries
+func (c *readinessCache) set(node *corev1.Node, ready bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	c.entries[node.GetName()] = readinessEntry{
+		uid:     node.GetUID(),
+		ready:   ready,
+		expires: now.Add(c.ttl),
+	}
+
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	for name, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, name)
+		}
+	}
+	c.lastSweep = now
+}
+
+// delete forgets the readiness of the named node
+func (c *readinessCache) delete(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.entries, nodeName)
+}
+
+// len returns the number of cached entries, including expired ones not swept yet
+func (c *readinessCache) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,179 @@
+package nodelink
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	mapiv1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReadinessCache(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newReadinessCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	testNode := node("node", "", nil, nil)
+	testNode.UID = "uid-1"
+	if _, ok := cache.get(testNode); ok {
+		t.Errorf("expected a cache miss for an unknown node")
+	}
+
+	cache.set(testNode, true)
+	if ready, ok := cache.get(testNode); !ok || !ready {
+		t.Errorf("expected the node to be cached as ready, got ready: %v, found: %v", ready, ok)
+	}
+
+	// a node recreated under the same name is a different node
+	recreated := testNode.DeepCopy()
+	recreated.UID = "uid-2"
+	if _, ok := cache.get(recreated); ok {
+		t.Errorf("expected a cache miss for a recreated node")
+	}
+
+	// entries expire after the TTL
+	now = now.Add(time.Minute)
+	if _, ok := cache.get(testNode); ok {
+		t.Errorf("expected a cache miss for an expired entry")
+	}
+
+	// expired entries are dropped when setting other nodes
+	cache.set(node("other", "", nil, nil), false)
+	if cache.len() != 1 {
+		t.Errorf("expected expired entries to be dropped, got %d entries", cache.len())
+	}
+
+	cache.delete("other")
+	if cache.len() != 0 {
+		t.Errorf("expected no entries, got %d", cache.len())
+	}
+}
+
+func TestReadinessCacheNodeChurn(t *testing.T) {
+	cache := newReadinessCache(time.Hour)
+	cache.lastSweep = time.Now()
+
+	// many short lived nodes whose deletion is never observed do not accumulate past the TTL
+	for i := 0; i < 100; i++ {
+		cache.set(node(fmt.Sprintf("node-%d", i), "", nil, nil), true)
+	}
+	if cache.len() != 100 {
+		t.Fatalf("expected 100 entries, got %d", cache.len())
+	}
+
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	cache.set(node("node-100", "", nil, nil), true)
+	if cache.len() != 1 {
+		t.Errorf("expected expired entries to be dropped, got %d entries", cache.len())
+	}
+}
+
+func TestReadinessCacheConcurrency(t *testing.T) {
+	cache := newReadinessCache(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n := node(fmt.Sprintf("node-%d", j%10), "", nil, nil)
+				n.UID = types.UID(fmt.Sprintf("uid-%d", i%2))
+				cache.set(n, j%2 == 0)
+				cache.get(n)
+				if j%7 == 0 {
+					cache.delete(n.GetName())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.len() > 10 {
+		t.Errorf("expected at most 10 entries, got %d", cache.len())
+	}
+}
+
+func TestUpdateNodeRefNodeChurn(t *testing.T) {
+	testMachine := machine("machine", "", nil, nil, nil)
+	testNode := node("node", "", nil, nil)
+	testNode.UID = "uid-1"
+	r := newFakeReconciler(fake.NewFakeClientWithScheme(scheme.Scheme, testMachine), testMachine, testNode)
+
+	machineKey := client.ObjectKey{Namespace: testMachine.GetNamespace(), Name: testMachine.GetName()}
+	got := &mapiv1beta1.Machine{}
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if err := r.updateNodeRef(got, testNode); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the node is deleted and recreated under the same name before the deletion was observed
+	recreated := testNode.DeepCopy()
+	recreated.UID = "uid-2"
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if err := r.updateNodeRef(got, recreated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.client.Get(context.TODO(), machineKey, got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if got.Status.NodeRef == nil || got.Status.NodeRef.UID != recreated.GetUID() {
+		t.Errorf("expected nodeRef to reference the recreated node, got: %v", got.Status.NodeRef)
+	}
+}
+
+func TestUpdateNodeRefControllerRestart(t *testing.T) {
+	testMachine := machine("machine", "", nil, nil, nil)
+	testNode := node("node", "", nil, nil)
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, testMachine)
+	machineKey := client.ObjectKey{Namespace: testMachine.GetNamespace(), Name: testMachine.GetName()}
+
+	// updateNodeRefAndGetResourceVersion returns the machine resource version after updating its nodeRef
+	updateNodeRefAndGetResourceVersion := func(r *fakeReconciler) string {
+		got := &mapiv1beta1.Machine{}
+		if err := c.Get(context.TODO(), machineKey, got); err != nil {
+			t.Fatalf("unexpected error getting machine: %v", err)
+		}
+		if err := r.updateNodeRef(got, testNode); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := c.Get(context.TODO(), machineKey, got); err != nil {
+			t.Fatalf("unexpected error getting machine: %v", err)
+		}
+		return got.GetResourceVersion()
+	}
+
+	r := newFakeReconciler(c, testMachine, testNode)
+	initial := updateNodeRefAndGetResourceVersion(r)
+	if rv := updateNodeRefAndGetResourceVersion(r); rv != initial {
+		t.Errorf("expected the machine not to be updated while the node readiness is unchanged")
+	}
+
+	// a restarted controller starts with an empty cache and refreshes the machine once
+	restarted := newFakeReconciler(c, testMachine, testNode)
+	afterRestart := updateNodeRefAndGetResourceVersion(restarted)
+	if afterRestart == initial {
+		t.Errorf("expected the machine to be updated after a restart")
+	}
+	if rv := updateNodeRefAndGetResourceVersion(restarted); rv != afterRestart {
+		t.Errorf("expected the machine not to be updated again after a restart")
+	}
+
+	// a readiness transition is always written
+	testNode.Status.Conditions[0].Status = corev1.ConditionFalse
+	if rv := updateNodeRefAndGetResourceVersion(restarted); rv == afterRestart {
+		t.Errorf("expected the machine to be updated when the node readiness changes")
+	}
+}
@@ -4,15 +4,21 @@ package vsphere
 // The lifetime of scope and reconciler is a machine actuator operation.
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	vspherev1 "github.com/openshift/machine-api-operator/pkg/apis/vsphereprovider/v1beta1"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
+	"github.com/openshift/machine-api-operator/pkg/util/ipam"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -21,16 +27,20 @@ const (
 	createEventAction   = "Create"
 	updateEventAction   = "Update"
 	deleteEventAction   = "Delete"
+	detachEventAction   = "Detach"
+	orphanEventAction   = "Orphan"
 	noEventAction       = ""
 	requeueAfterSeconds = 20
 )
 
 // Actuator is responsible for performing machine reconciliation.
 type Actuator struct {
-	client        runtimeclient.Client
-	apiReader     runtimeclient.Reader
-	eventRecorder record.EventRecorder
-	TaskIDCache   map[string]string
+	client         runtimeclient.Client
+	apiReader      runtimeclient.Reader
+	eventRecorder  record.EventRecorder
+	kubeClient     kubernetes.Interface
+	taskWatcher    *session.TaskWatcher
+	vmEventWatcher *session.VMEventWatcher
 }
 
 // ActuatorParams holds parameter information for Actuator.
@@ -38,22 +48,66 @@ type ActuatorParams struct {
 	Client        runtimeclient.Client
 	APIReader     runtimeclient.Reader
 	EventRecorder record.EventRecorder
-	TaskIDCache   map[string]string
+	// KubeClient drains the nodes of machines whose virtual machines are
+	// powered off to be resized.
+	KubeClient kubernetes.Interface
+	// TaskWatcher enqueues machines when their vSphere tasks are finished.
+	// Machines are requeued periodically until then when it is not set.
+	TaskWatcher *session.TaskWatcher
+	// VMEventWatcher enqueues machines when their virtual machines are
+	// changed out of band, e.g. powered off or removed in vCenter.
+	VMEventWatcher *session.VMEventWatcher
 }
 
 // NewActuator returns an actuator.
 func NewActuator(params ActuatorParams) *Actuator {
 	return &Actuator{
-		client:        params.Client,
-		apiReader:     params.APIReader,
-		eventRecorder: params.EventRecorder,
-		TaskIDCache:   params.TaskIDCache,
+		client:         params.Client,
+		apiReader:      params.APIReader,
+		eventRecorder:  params.EventRecorder,
+		kubeClient:     params.KubeClient,
+		taskWatcher:    params.TaskWatcher,
+		vmEventWatcher: params.VMEventWatcher,
 	}
 }
 
+// Sources returns the sources of the events sent when the vSphere tasks of
+// machines are finished or their virtual machines are changed out of band,
+// and is invoked by the machine controller.
+func (a *Actuator) Sources() []source.Source {
+	var sources []source.Source
+	if a.taskWatcher != nil {
+		sources = append(sources, a.taskWatcher.Source())
+	}
+	if a.vmEventWatcher != nil {
+		sources = append(sources, a.vmEventWatcher.Source())
+	}
+	return sources
+}
+
+// hasLiveCreateTask returns true if the machine read from the API server
+// records a create task, which the cached machine may be missing.
+func (a *Actuator) hasLiveCreateTask(ctx context.Context, machine *machinev1.Machine) (bool, error) {
+	liveMachine := &machinev1.Machine{}
+	if err := a.apiReader.Get(ctx, runtimeclient.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}, liveMachine); err != nil {
+		return false, err
+	}
+	providerStatus, err := vspherev1.ProviderStatusFromRawExtension(liveMachine.Status.ProviderStatus)
+	if err != nil {
+		return false, err
+	}
+	return getTask(providerStatus, vspherev1.CreateTaskOperation) != nil, nil
+}
+
 // Set corresponding event based on error. It also returns the original error
 // for convenience, so callers can do "return handleMachineError(...)".
+// Waiting for a task to finish is not an error worth an event.
 func (a *Actuator) handleMachineError(machine *machinev1.Machine, err error, eventAction string) error {
+	var requeueAfterError *machinecontroller.RequeueAfterError
+	if errors.As(err, &requeueAfterError) {
+		klog.Infof("%v: %v", machine.GetName(), err)
+		return err
+	}
 	klog.Errorf("%v error: %v", machine.GetName(), err)
 	if eventAction != noEventAction {
 		a.eventRecorder.Eventf(machine, corev1.EventTypeWarning, "Failed"+eventAction, "%v", err)
@@ -66,32 +120,35 @@ func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) error
 	klog.Infof("%s: actuator creating machine", machine.GetName())
 
 	scope, err := newMachineScope(machineScopeParams{
-		Context:   ctx,
-		client:    a.client,
-		machine:   machine,
-		apiReader: a.apiReader,
+		Context:        ctx,
+		client:         a.client,
+		machine:        machine,
+		apiReader:      a.apiReader,
+		kubeClient:     a.kubeClient,
+		taskWatcher:    a.taskWatcher,
+		vmEventWatcher: a.vmEventWatcher,
 	})
 	if err != nil {
 		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
 		return a.handleMachineError(machine, fmtErr, createEventAction)
 	}
 
-	// Ensure we're not reconciling a stale machine by checking our task-id.
-	// This is a workaround for a cache race condition.
-	if val, ok := a.TaskIDCache[machine.Name]; ok {
-		if val != scope.providerStatus.TaskRef {
+	// Ensure we're not reconciling a stale machine by checking the create task
+	// of the live machine. This is a workaround for a cache race condition.
+	if getTask(scope.providerStatus, vspherev1.CreateTaskOperation) == nil {
+		hasCreateTask, err := a.hasLiveCreateTask(ctx, machine)
+		if err != nil {
+			fmtErr := fmt.Errorf("%s: failed to get live machine: %w", machine.GetName(), err)
+			return a.handleMachineError(machine, fmtErr, createEventAction)
+		}
+		if hasCreateTask {
 			klog.Errorf("%s: machine object missing expected provider task ID, requeue", machine.GetName())
 			return &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second}
 		}
 	}
 
 	var retErr error
-	err = newReconciler(scope).create()
-	// save the taskRef in our cache in case of any error with patch.
-	if scope.providerStatus.TaskRef != "" {
-		a.TaskIDCache[machine.Name] = scope.providerStatus.TaskRef
-	}
-	if err != nil {
+	if err := newReconciler(scope).create(); err != nil {
 		fmtErr := fmt.Errorf(reconcilerFailFmt, machine.GetName(), createEventAction, err)
 		retErr = a.handleMachineError(machine, fmtErr, createEventAction)
 	} else {
@@ -108,10 +165,13 @@ func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) error
 func (a *Actuator) Exists(ctx context.Context, machine *machinev1.Machine) (bool, error) {
 	klog.Infof("%s: actuator checking if machine exists", machine.GetName())
 	scope, err := newMachineScope(machineScopeParams{
-		Context:   ctx,
-		client:    a.client,
-		machine:   machine,
-		apiReader: a.apiReader,
+		Context:        ctx,
+		client:         a.client,
+		machine:        machine,
+		apiReader:      a.apiReader,
+		kubeClient:     a.kubeClient,
+		taskWatcher:    a.taskWatcher,
+		vmEventWatcher: a.vmEventWatcher,
 	})
 	if err != nil {
 		return false, fmt.Errorf(scopeFailFmt, machine.GetName(), err)
@@ -121,14 +181,14 @@ func (a *Actuator) Exists(ctx context.Context, machine *machinev1.Machine) (bool
 
 func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error {
 	klog.Infof("%s: actuator updating machine", machine.GetName())
-	// Cleanup TaskIDCache so we don't continually grow
-	delete(a.TaskIDCache, machine.Name)
-
 	scope, err := newMachineScope(machineScopeParams{
-		Context:   ctx,
-		client:    a.client,
-		machine:   machine,
-		apiReader: a.apiReader,
+		Context:        ctx,
+		client:         a.client,
+		machine:        machine,
+		apiReader:      a.apiReader,
+		kubeClient:     a.kubeClient,
+		taskWatcher:    a.taskWatcher,
+		vmEventWatcher: a.vmEventWatcher,
 	})
 	if err != nil {
 		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
@@ -148,15 +208,14 @@ func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error
 
 func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error {
 	klog.Infof("%s: actuator deleting machine", machine.GetName())
-	// Cleanup TaskIDCache so we don't continually grow
-	// Cleanup here as well in case Update() was never successfully called.
-	delete(a.TaskIDCache, machine.Name)
-
 	scope, err := newMachineScope(machineScopeParams{
-		Context:   ctx,
-		client:    a.client,
-		machine:   machine,
-		apiReader: a.apiReader,
+		Context:        ctx,
+		client:         a.client,
+		machine:        machine,
+		apiReader:      a.apiReader,
+		kubeClient:     a.kubeClient,
+		taskWatcher:    a.taskWatcher,
+		vmEventWatcher: a.vmEventWatcher,
 	})
 	if err != nil {
 		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
@@ -172,3 +231,40 @@ func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error
 	a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, deleteEventAction, "Deleted machine %v", machine.GetName())
 	return scope.PatchMachine()
 }
+
+// Orphan keeps the addresses claimed for the virtual machine of an orphaned
+// machine, which is still running with them, and is invoked by the machine controller.
+// It doesn't need a vCenter session, so that machines can always be orphaned.
+func (a *Actuator) Orphan(ctx context.Context, machine *machinev1.Machine) error {
+	klog.Infof("%s: actuator orphaning machine", machine.GetName())
+	if err := ipam.NewAllocator(a.client, a.apiReader).Orphan(ctx, machine); err != nil {
+		fmtErr := fmt.Errorf(reconcilerFailFmt, machine.GetName(), orphanEventAction, err)
+		return a.handleMachineError(machine, fmtErr, noEventAction)
+	}
+	return nil
+}
+
+// Detach removes the cluster tags from the virtual machine of an orphaned machine
+// and is invoked by the machine controller.
+func (a *Actuator) Detach(ctx context.Context, machine *machinev1.Machine) error {
+	klog.Infof("%s: actuator detaching machine", machine.GetName())
+	scope, err := newMachineScope(machineScopeParams{
+		Context:        ctx,
+		client:         a.client,
+		machine:        machine,
+		apiReader:      a.apiReader,
+		kubeClient:     a.kubeClient,
+		taskWatcher:    a.taskWatcher,
+		vmEventWatcher: a.vmEventWatcher,
+	})
+	if err != nil {
+		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
+		return a.handleMachineError(machine, fmtErr, detachEventAction)
+	}
+	if err := newReconciler(scope).detach(); err != nil {
+		fmtErr := fmt.Errorf(reconcilerFailFmt, machine.GetName(), detachEventAction, err)
+		return a.handleMachineError(machine, fmtErr, detachEventAction)
+	}
+	a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, detachEventAction, "Detached machine %v", machine.GetName())
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -229,11 +230,10 @@ func TestMachineEvents(t *testing.T) {
 			event: "test: failed to create scope for machine: test: machine scope require a context",
 		},
 		{
-			name: "Delete machine event failed, reconciler's delete failed",
+			name: "Delete machine event not created while the vm is being destroyed",
 			operation: func(actuator *Actuator, machine *machinev1.Machine) {
 				actuator.Delete(ctx, machine)
 			},
-			event: "test: reconciler failed to Delete machine: destroying vm in progress, reconciling",
 		},
 		{
 			name: "Delete machine event succeed",
@@ -292,18 +292,25 @@ func TestMachineEvents(t *testing.T) {
 			}
 			gs.Eventually(getMachine, timeout).Should(Succeed())
 
-			taskIDCache := make(map[string]string)
 			params := ActuatorParams{
 				Client:        k8sClient,
 				EventRecorder: eventRecorder,
 				APIReader:     k8sClient,
-				TaskIDCache:   taskIDCache,
 			}
 
 			actuator := NewActuator(params)
 			tc.operation(actuator, machine)
 
 			eventList := &v1.EventList{}
+			if tc.event == "" {
+				listEvents := func() ([]v1.Event, error) {
+					err := k8sClient.List(ctx, eventList, client.InNamespace(machine.Namespace))
+					return eventList.Items, err
+				}
+				gs.Consistently(listEvents, time.Second).Should(BeEmpty())
+				return
+			}
+
 			waitForEvent := func() error {
 				err := k8sClient.List(ctx, eventList, client.InNamespace(machine.Namespace))
 				if err != nil {
@@ -326,3 +333,75 @@ func TestMachineEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestActuatorHasLiveCreateTask(t *testing.T) {
+	testCases := []struct {
+		name           string
+		providerStatus *vsphereapi.VSphereMachineProviderStatus
+		expected       bool
+	}{
+		{
+			name:           "no provider status",
+			providerStatus: nil,
+			expected:       false,
+		},
+		{
+			name: "create task",
+			providerStatus: &vsphereapi.VSphereMachineProviderStatus{
+				Tasks: []vsphereapi.VSphereMachineTask{
+					{Operation: vsphereapi.CreateTaskOperation, TaskRef: "task-1"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "deprecated task reference",
+			providerStatus: &vsphereapi.VSphereMachineProviderStatus{
+				TaskRef: "task-1",
+			},
+			expected: true,
+		},
+		{
+			name: "delete task only",
+			providerStatus: &vsphereapi.VSphereMachineProviderStatus{
+				Tasks: []vsphereapi.VSphereMachineTask{
+					{Operation: vsphereapi.DeleteTaskOperation, TaskRef: "task-1"},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+			}
+			if tc.providerStatus != nil {
+				rawProviderStatus, err := vsphereapi.RawExtensionFromProviderStatus(tc.providerStatus)
+				if err != nil {
+					t.Fatal(err)
+				}
+				machine.Status.ProviderStatus = rawProviderStatus
+			}
+
+			// The cached machine, given to the actuator, has no task.
+			cachedMachine := machine.DeepCopy()
+			cachedMachine.Status.ProviderStatus = nil
+
+			actuator := NewActuator(ActuatorParams{
+				APIReader: fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+			})
+			hasCreateTask, err := actuator.hasLiveCreateTask(context.TODO(), cachedMachine)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasCreateTask != tc.expected {
+				t.Errorf("Expected: %v, got: %v", tc.expected, hasCreateTask)
+			}
+		})
+	}
+}
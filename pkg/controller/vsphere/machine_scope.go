@@ -12,6 +12,7 @@ import (
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
 	apicorev1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -23,9 +24,12 @@ const (
 // machineScopeParams defines the input parameters used to create a new MachineScope.
 type machineScopeParams struct {
 	context.Context
-	client    runtimeclient.Client
-	apiReader runtimeclient.Reader
-	machine   *machinev1.Machine
+	client         runtimeclient.Client
+	apiReader      runtimeclient.Reader
+	machine        *machinev1.Machine
+	kubeClient     kubernetes.Interface
+	taskWatcher    *session.TaskWatcher
+	vmEventWatcher *session.VMEventWatcher
 }
 
 // machineScope defines a scope defined around a machine and its cluster.
@@ -44,6 +48,12 @@ type machineScope struct {
 	providerSpec       *apivsphere.VSphereMachineProviderSpec
 	providerStatus     *apivsphere.VSphereMachineProviderStatus
 	machineToBePatched runtimeclient.Patch
+	// kubernetes client draining the node of the machine, if set
+	kubeClient kubernetes.Interface
+	// enqueues the machine when its tasks are finished, if set
+	taskWatcher *session.TaskWatcher
+	// enqueues the machine when its vm is changed out of band, if set
+	vmEventWatcher *session.VMEventWatcher
 }
 
 // newMachineScope creates a new machineScope from the supplied parameters.
@@ -94,6 +104,9 @@ func newMachineScope(params machineScopeParams) (*machineScope, error) {
 		providerStatus:     providerStatus,
 		vSphereConfig:      vSphereConfig,
 		machineToBePatched: runtimeclient.MergeFrom(params.machine.DeepCopy()),
+		kubeClient:         params.kubeClient,
+		taskWatcher:        params.taskWatcher,
+		vmEventWatcher:     params.vmEventWatcher,
 	}, nil
 }
 
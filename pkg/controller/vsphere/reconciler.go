@@ -5,7 +5,12 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
+	"path"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
@@ -14,16 +19,24 @@ import (
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/ipam"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vapi/vcenter"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	corev1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"k8s.io/kubectl/pkg/drain"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -33,6 +46,17 @@ const (
 	providerIDPrefix      = "vsphere://"
 	regionKey             = "region"
 	zoneKey               = "zone"
+	// contentLibrarySnapshotName is the name of the snapshot taken of the vms
+	// deployed from content library items, which machines are linked to.
+	contentLibrarySnapshotName = "content-library"
+	// maxSCSIUnits is the number of units of a wide SCSI controller.
+	maxSCSIUnits = 16
+	// taskWatchRequeueAfter is the requeue period of a machine whose task is
+	// watched, in case the task watcher misses the end of the task.
+	taskWatchRequeueAfter = 5 * time.Minute
+	// resizeShutdownTimeout is how long the guest of a vm restarted to be
+	// resized is given to shut down, before the vm is powered off.
+	resizeShutdownTimeout = 5 * time.Minute
 )
 
 // These are the guestinfo variables used by Ignition.
@@ -43,6 +67,21 @@ const (
 	GuestInfoHostname         = "guestinfo.hostname"
 )
 
+// These are the guestinfo variables used to configure static IP addresses.
+// GuestInfoNetworkKargs is read by Afterburn to configure the network of the
+// initramfs with dracut kernel arguments, before Ignition fetches its config.
+// The per device variables, formatted with the device index, hold the whole
+// configuration for guests which configure their network from guestinfo.
+const (
+	GuestInfoNetworkKargs = "guestinfo.afterburn.initrd.network-kargs"
+
+	GuestInfoNetworkIPAddrs       = "guestinfo.network.device%d.ipaddrs"
+	GuestInfoNetworkGateway4      = "guestinfo.network.device%d.gateway4"
+	GuestInfoNetworkGateway6      = "guestinfo.network.device%d.gateway6"
+	GuestInfoNetworkNameservers   = "guestinfo.network.device%d.nameservers"
+	GuestInfoNetworkSearchDomains = "guestinfo.network.device%d.searchdomains"
+)
+
 // Reconciler runs the logic to reconciles a machine resource towards its desired state
 type Reconciler struct {
 	*machineScope
@@ -60,11 +99,15 @@ func (r *Reconciler) create() error {
 		return fmt.Errorf("%v: failed validating machine provider spec: %w", r.machine.GetName(), err)
 	}
 
-	// We only clone the VM template if we have no taskRef.
-	if r.providerStatus.TaskRef == "" {
+	// We only clone the VM template if we have no create task.
+	createTask := getTask(r.providerStatus, vspherev1.CreateTaskOperation)
+	if createTask == nil {
 		if !r.machineScope.session.IsVC() {
 			return fmt.Errorf("%v: not connected to a vCenter", r.machine.GetName())
 		}
+		if err := r.resolveIPAddressPools(true); err != nil {
+			return fmt.Errorf("%v: failed to claim IP addresses: %w", r.machine.GetName(), err)
+		}
 		klog.Infof("%v: cloning", r.machine.GetName())
 		task, err := clone(r.machineScope)
 		if err != nil {
@@ -75,17 +118,21 @@ func (r *Reconciler) create() error {
 			})
 			conditionFailed := conditionFailed()
 			conditionFailed.Message = err.Error()
-			statusError := setProviderStatus(task, conditionFailed, r.machineScope, nil)
+			statusError := setProviderStatus(conditionFailed, r.machineScope, nil)
 			if statusError != nil {
 				return fmt.Errorf("Failed to set provider status: %w", err)
 			}
 			return err
 		}
-		return setProviderStatus(task, conditionSuccess(), r.machineScope, nil)
+		setTask(r.providerStatus, vspherev1.CreateTaskOperation, task)
+		if err := setProviderStatus(conditionSuccess(), r.machineScope, nil); err != nil {
+			return err
+		}
+		r.watchTask(getTask(r.providerStatus, vspherev1.CreateTaskOperation))
+		return nil
 	}
 
-	moTask, err := r.session.GetTask(r.Context, r.providerStatus.TaskRef)
-	if err != nil {
+	if err := r.refreshTask(createTask); err != nil {
 		metrics.RegisterFailedInstanceCreate(&metrics.MachineLabels{
 			Name:      r.machine.Name,
 			Namespace: r.machine.Namespace,
@@ -94,13 +141,7 @@ func (r *Reconciler) create() error {
 		return err
 	}
 
-	if moTask == nil {
-		// Possible eventual consistency problem from vsphere
-		// TODO: change error message here to indicate this might be expected.
-		return fmt.Errorf("Unexpected moTask nil")
-	}
-
-	if taskIsFinished, err := taskIsFinished(moTask); err != nil {
+	if taskIsFinished, err := taskIsFinished(createTask); err != nil {
 		metrics.RegisterFailedInstanceCreate(&metrics.MachineLabels{
 			Name:      r.machine.Name,
 			Namespace: r.machine.Namespace,
@@ -108,7 +149,7 @@ func (r *Reconciler) create() error {
 		})
 		return err
 	} else if !taskIsFinished {
-		return fmt.Errorf("task %v has not finished", moTask.Reference().Value)
+		return r.waitForTask(createTask)
 	}
 	// If taskIsFinished then next reconcile should result in update.
 	return nil
@@ -120,20 +161,21 @@ func (r *Reconciler) update() error {
 		return fmt.Errorf("%v: failed validating machine provider spec: %w", r.machine.GetName(), err)
 	}
 
-	if r.providerStatus.TaskRef != "" {
-		moTask, err := r.session.GetTask(r.Context, r.providerStatus.TaskRef)
-		if err != nil {
-			if !isRetrieveMONotFound(r.providerStatus.TaskRef, err) {
-				metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
-					Name:      r.machine.Name,
-					Namespace: r.machine.Namespace,
-					Reason:    "GetTask finished with error",
-				})
-				return err
-			}
+	r.watchVMEvents()
+
+	if createTask := getTask(r.providerStatus, vspherev1.CreateTaskOperation); createTask != nil {
+		err := r.refreshTask(createTask)
+		if err != nil && !isRetrieveMONotFound(createTask.TaskRef, err) {
+			metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
+				Name:      r.machine.Name,
+				Namespace: r.machine.Namespace,
+				Reason:    "GetTask finished with error",
+			})
+			return err
 		}
-		if moTask != nil {
-			if taskIsFinished, err := taskIsFinished(moTask); err != nil {
+		// A task which is no longer known to vSphere is long finished.
+		if err == nil {
+			if taskIsFinished, err := taskIsFinished(createTask); err != nil {
 				metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
 					Name:      r.machine.Name,
 					Namespace: r.machine.Namespace,
@@ -141,7 +183,7 @@ func (r *Reconciler) update() error {
 				})
 				return err
 			} else if !taskIsFinished {
-				return fmt.Errorf("task %v has not finished", moTask.Reference().Value)
+				return r.waitForTask(createTask)
 			}
 		}
 	}
@@ -165,7 +207,7 @@ func (r *Reconciler) update() error {
 		Ref:     vmRef,
 	}
 
-	if err := vm.reconcileTags(r.Context, r.session, r.machine); err != nil {
+	if err := vm.reconcileTags(r.Context, r.session, r.machine, r.providerSpec.Tags, r.providerStatus.Tags); err != nil {
 		metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
 			Name:      r.machine.Name,
 			Namespace: r.machine.Namespace,
@@ -173,8 +215,21 @@ func (r *Reconciler) update() error {
 		})
 		return fmt.Errorf("failed to reconcile tags: %w", err)
 	}
+	r.providerStatus.Tags = r.providerSpec.Tags
+
+	if err := r.reconcileResources(vm); err != nil {
+		var requeueAfterError *machinecontroller.RequeueAfterError
+		if !errors.As(err, &requeueAfterError) {
+			metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
+				Name:      r.machine.Name,
+				Namespace: r.machine.Namespace,
+				Reason:    "ReconcileResources finished with error",
+			})
+		}
+		return fmt.Errorf("failed to reconcile resources: %w", err)
+	}
 
-	if err := r.reconcileMachineWithCloudState(vm, r.providerStatus.TaskRef); err != nil {
+	if err := r.reconcileMachineWithCloudState(vm); err != nil {
 		metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
 			Name:      r.machine.Name,
 			Namespace: r.machine.Namespace,
@@ -204,25 +259,36 @@ func (r *Reconciler) exists() (bool, error) {
 }
 
 func (r *Reconciler) delete() error {
-	if r.providerStatus.TaskRef != "" {
-		// TODO: We need to use a separate status field for the create and the
-		// delete taskref.
-		moTask, err := r.session.GetTask(r.Context, r.providerStatus.TaskRef)
-		if err != nil {
-			if !isRetrieveMONotFound(r.providerStatus.TaskRef, err) {
-				return err
+	// A vm which is still being cloned is only destroyed once the clone is
+	// finished. A failed clone doesn't prevent the deletion of the machine.
+	if createTask := getTask(r.providerStatus, vspherev1.CreateTaskOperation); createTask != nil {
+		err := r.refreshTask(createTask)
+		if err != nil && !isRetrieveMONotFound(createTask.TaskRef, err) {
+			return err
+		}
+		if err == nil {
+			if taskIsFinished, _ := taskIsFinished(createTask); !taskIsFinished {
+				return r.waitForTask(createTask)
 			}
 		}
-		if moTask != nil {
-			if taskIsFinished, err := taskIsFinished(moTask); err != nil {
+	}
+
+	if deleteTask := getTask(r.providerStatus, vspherev1.DeleteTaskOperation); deleteTask != nil {
+		err := r.refreshTask(deleteTask)
+		if err != nil && !isRetrieveMONotFound(deleteTask.TaskRef, err) {
+			return err
+		}
+		if err == nil {
+			if taskIsFinished, err := taskIsFinished(deleteTask); err != nil {
+				// The vm is destroyed again below if it still exists.
 				metrics.RegisterFailedInstanceDelete(&metrics.MachineLabels{
 					Name:      r.machine.Name,
 					Namespace: r.machine.Namespace,
 					Reason:    "Task finished with error",
 				})
-				return err
+				klog.Errorf("%v: destroy task %v failed: %v", r.machine.GetName(), deleteTask.TaskRef, err)
 			} else if !taskIsFinished {
-				return fmt.Errorf("task %v has not finished", moTask.Reference().Value)
+				return r.waitForTask(deleteTask)
 			}
 		}
 	}
@@ -238,6 +304,11 @@ func (r *Reconciler) delete() error {
 			return err
 		}
 		klog.Infof("%v: vm does not exist", r.machine.GetName())
+		// The addresses are released once the vm is gone, so that they can't
+		// be allocated to another machine while still in use.
+		if r.usesIPAddressPools() {
+			return r.ipAddressAllocator().Release(r.Context, r.machine)
+		}
 		return nil
 	}
 
@@ -263,18 +334,112 @@ func (r *Reconciler) delete() error {
 		return fmt.Errorf("%v: failed to destroy vm: %w", r.machine.GetName(), err)
 	}
 
-	if err := setProviderStatus(task.Reference().Value, conditionSuccess(), r.machineScope, vm); err != nil {
+	setTask(r.providerStatus, vspherev1.DeleteTaskOperation, task.Reference().Value)
+	if err := setProviderStatus(conditionSuccess(), r.machineScope, vm); err != nil {
 		return fmt.Errorf("Failed to set provider status: %w", err)
 	}
 
-	// TODO: consider returning an error to specify retry time here
-	return fmt.Errorf("destroying vm in progress, reconciling")
+	return r.waitForTask(getTask(r.providerStatus, vspherev1.DeleteTaskOperation))
+}
+
+// detach removes the cluster tags from the virtual machine, leaving it running
+func (r *Reconciler) detach() error {
+	vmRef, err := findVM(r.machineScope)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		klog.Infof("%v: vm does not exist", r.machine.GetName())
+		return nil
+	}
+
+	vm := &virtualMachine{
+		Context: r.Context,
+		Obj:     object.NewVirtualMachine(r.machineScope.session.Client.Client, vmRef),
+		Ref:     vmRef,
+	}
+
+	if err := vm.detachTags(r.Context, r.session, r.machine); err != nil {
+		return fmt.Errorf("failed to detach tags: %w", err)
+	}
+	return nil
+}
+
+// usesIPAddressPools returns true if a network device of the machine claims its address from a pool
+func (r *Reconciler) usesIPAddressPools() bool {
+	for _, device := range r.providerSpec.Network.Devices {
+		if device.IPAddressPool != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reconciler) ipAddressAllocator() *ipam.Allocator {
+	return ipam.NewAllocator(r.client, r.apiReader)
+}
+
+// resolveIPAddressPools sets the static configuration of the network devices
+// using an IPAddressPool from the addresses claimed for them. When allocate is
+// true, a free address of the pool is claimed for devices without one.
+func (r *Reconciler) resolveIPAddressPools(allocate bool) error {
+	if !r.usesIPAddressPools() {
+		return nil
+	}
+	allocator := r.ipAddressAllocator()
+
+	for i := range r.providerSpec.Network.Devices {
+		device := &r.providerSpec.Network.Devices[i]
+		if device.IPAddressPool == nil {
+			continue
+		}
+
+		var allocation *ipam.Allocation
+		var err error
+		if allocate {
+			allocation, err = allocator.Claim(r.Context, r.machine, i, device.IPAddressPool.Name)
+		} else {
+			allocation, err = allocator.Get(r.Context, r.machine, i)
+		}
+		if err != nil {
+			if errors.Is(err, ipam.ErrInvalidPool) {
+				return machinecontroller.InvalidMachineConfiguration("network device %d: %v", i, err)
+			}
+			return fmt.Errorf("network device %d: %w", i, err)
+		}
+		if allocation == nil {
+			continue
+		}
+
+		applyIPAddressAllocation(device, allocation)
+	}
+	return nil
+}
+
+// applyIPAddressAllocation sets the allocated address on the device, along with
+// the network configuration of its pool for the fields the device leaves unset.
+func applyIPAddressAllocation(device *vspherev1.NetworkDeviceSpec, allocation *ipam.Allocation) {
+	pool := allocation.Pool.Spec
+	device.IPAddrs = []string{allocation.CIDR()}
+
+	if gateway := net.ParseIP(pool.Gateway); gateway != nil {
+		if gateway.To4() != nil && device.Gateway4 == "" {
+			device.Gateway4 = pool.Gateway
+		} else if gateway.To4() == nil && device.Gateway6 == "" {
+			device.Gateway6 = pool.Gateway
+		}
+	}
+	if len(device.Nameservers) == 0 {
+		device.Nameservers = pool.Nameservers
+	}
+	if len(device.SearchDomains) == 0 {
+		device.SearchDomains = pool.SearchDomains
+	}
 }
 
 // reconcileMachineWithCloudState reconcile machineSpec and status with the latest cloud state
-func (r *Reconciler) reconcileMachineWithCloudState(vm *virtualMachine, taskRef string) error {
+func (r *Reconciler) reconcileMachineWithCloudState(vm *virtualMachine) error {
 	klog.V(3).Infof("%v: reconciling machine with cloud state", r.machine.GetName())
-	// TODO: reconcile task
 
 	if err := r.reconcileRegionAndZoneLabels(vm); err != nil {
 		// Not treating this is as a fatal error for now.
@@ -286,17 +451,27 @@ func (r *Reconciler) reconcileMachineWithCloudState(vm *virtualMachine, taskRef
 		return err
 	}
 
+	if err := r.resolveIPAddressPools(false); err != nil {
+		// Not treating this as a fatal error, the guest reports its addresses anyway.
+		klog.Errorf("Failed to resolve claimed IP addresses: %v", err)
+	}
+
 	klog.V(3).Infof("%v: reconciling network", r.machine.GetName())
 	if err := r.reconcileNetwork(vm); err != nil {
 		return err
 	}
 
+	klog.V(3).Infof("%v: reconciling data disks", r.machine.GetName())
+	if err := r.reconcileDataDisks(vm); err != nil {
+		return err
+	}
+
 	klog.V(3).Infof("%v: reconciling powerstate annotation", r.machine.GetName())
 	if err := r.reconcilePowerStateAnnontation(vm); err != nil {
 		return err
 	}
 
-	return setProviderStatus(taskRef, conditionSuccess(), r.machineScope, vm)
+	return setProviderStatus(conditionSuccess(), r.machineScope, vm)
 }
 
 // reconcileRegionAndZoneLabels reconciles the labels on the Machine containing
@@ -368,7 +543,12 @@ func (r *Reconciler) reconcileNetwork(vm *virtualMachine) error {
 	}
 
 	var ipAddrs []corev1.NodeAddress
-	for _, netStatus := range currentNetworkStatusList {
+	for i, netStatus := range currentNetworkStatusList {
+		// Until the guest reports its addresses, rely on the static addresses
+		// of the device, if any.
+		if len(netStatus.IPAddrs) == 0 {
+			netStatus.IPAddrs = staticIPAddrs(r.providerSpec.Network.Devices[i])
+		}
 		for _, ip := range netStatus.IPAddrs {
 			ipAddrs = append(ipAddrs, corev1.NodeAddress{
 				Type:    corev1.NodeInternalIP,
@@ -396,6 +576,67 @@ func (r *Reconciler) reconcileNetwork(vm *virtualMachine) error {
 	return nil
 }
 
+// staticIPAddrs returns the static IP addresses of the device, without their prefix length.
+func staticIPAddrs(device vspherev1.NetworkDeviceSpec) []string {
+	var ipAddrs []string
+	for _, addr := range device.IPAddrs {
+		if ip, _, err := net.ParseCIDR(addr); err == nil {
+			ipAddrs = append(ipAddrs, ip.String())
+		}
+	}
+	return ipAddrs
+}
+
+// reconcileDataDisks refreshes the status of the data disks with the disks
+// attached to the VM where they were placed when it was cloned.
+func (r *Reconciler) reconcileDataDisks(vm *virtualMachine) error {
+	if len(r.providerStatus.DataDisks) == 0 {
+		return nil
+	}
+
+	devices, err := vm.Obj.Device(vm.Context)
+	if err != nil {
+		return fmt.Errorf("error getting devices: %w", err)
+	}
+
+	for i := range r.providerStatus.DataDisks {
+		diskStatus := &r.providerStatus.DataDisks[i]
+		diskStatus.SizeGiB = 0
+		diskStatus.FileName = ""
+
+		disk := findDisk(devices, diskStatus.ControllerBusNumber, diskStatus.UnitNumber)
+		if disk == nil {
+			klog.Warningf("%v: data disk %q not found on controller %d unit %d",
+				r.machine.GetName(), diskStatus.Name, diskStatus.ControllerBusNumber, diskStatus.UnitNumber)
+			continue
+		}
+
+		diskStatus.SizeGiB = int32(disk.CapacityInKB / 1024 / 1024)
+		if backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+			diskStatus.FileName = backing.GetVirtualDeviceFileBackingInfo().FileName
+		}
+	}
+	return nil
+}
+
+// findDisk returns the disk attached to the given unit of the SCSI controller
+// with the given bus number, or nil if there is none.
+func findDisk(devices object.VirtualDeviceList, busNumber, unitNumber int32) *types.VirtualDisk {
+	for _, dev := range devices.SelectByType((*types.VirtualSCSIController)(nil)) {
+		controller := dev.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+		if controller.BusNumber != busNumber {
+			continue
+		}
+		for _, d := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+			disk := d.(*types.VirtualDisk)
+			if disk.ControllerKey == controller.Key && disk.UnitNumber != nil && *disk.UnitNumber == unitNumber {
+				return disk
+			}
+		}
+	}
+	return nil
+}
+
 func (r *Reconciler) reconcilePowerStateAnnontation(vm *virtualMachine) error {
 	if vm == nil {
 		return errors.New("provided VM is nil")
@@ -415,6 +656,257 @@ func (r *Reconciler) reconcilePowerStateAnnontation(vm *virtualMachine) error {
 	return nil
 }
 
+// reconcileResources resizes the CPUs and memory of the vm to the provider spec,
+// as far as the resize policy allows, and reports the differences which remain
+// in the MachineResize condition. Each step of a resize is a vSphere task, which
+// the machine is requeued for.
+func (r *Reconciler) reconcileResources(vm *virtualMachine) error {
+	resized := false
+	if task := getTask(r.providerStatus, vspherev1.ResizeTaskOperation); task != nil {
+		wasFinished := task.State == vspherev1.TaskSucceeded || task.State == vspherev1.TaskFailed
+		err := r.refreshTask(task)
+		if err != nil && !isRetrieveMONotFound(task.TaskRef, err) {
+			return err
+		}
+		// A task which is no longer known to vSphere is long finished.
+		if err == nil {
+			if taskIsFinished, err := taskIsFinished(task); err != nil {
+				// The resize is evaluated again below from the state of the vm.
+				klog.Errorf("%v: resize task %v failed: %v", r.machine.GetName(), task.TaskRef, err)
+			} else if !taskIsFinished {
+				return r.waitForTask(task)
+			} else {
+				resized = !wasFinished
+			}
+		}
+	}
+
+	var moVM mo.VirtualMachine
+	if err := vm.Obj.Properties(vm.Context, vm.Ref, []string{"config", "runtime.powerState"}, &moVM); err != nil {
+		return fmt.Errorf("error getting vm configuration: %w", err)
+	}
+	if moVM.Config == nil {
+		return nil
+	}
+
+	condition := findProviderCondition(r.providerStatus.Conditions, vspherev1.MachineResize)
+	powerState := moVM.Runtime.PowerState
+	policy := r.providerSpec.ResizePolicy
+	spec, drift := getResizeSpec(r.providerSpec, moVM.Config)
+
+	// A resize which powered off the vm is finished first, so that the vm is
+	// never left powered off and its node cordoned, whatever the resize policy is now.
+	if condition != nil && condition.Reason == vspherev1.MachineResizeInProgress {
+		if err := r.continueResize(vm, condition, powerState, spec, drift); err != nil {
+			return err
+		}
+	}
+
+	if len(drift) > 0 {
+		canHotAdd := powerState == types.VirtualMachinePowerStatePoweredOn && canHotAddResources(spec, moVM.Config)
+
+		switch {
+		case policy == "" || policy == vspherev1.NoResize,
+			policy == vspherev1.HotAddResize && powerState == types.VirtualMachinePowerStatePoweredOn && !canHotAdd:
+			message := fmt.Sprintf("Virtual machine differs from the provider spec: %s", strings.Join(drift, ", "))
+			klog.Warningf("%v: %s, not resized with resize policy %q", r.machine.GetName(), message, policy)
+			r.setResizeCondition(corev1.ConditionFalse, vspherev1.MachineResizeNotAllowed, message)
+			return nil
+		case powerState == types.VirtualMachinePowerStatePoweredOn && !canHotAdd:
+			if err := r.drainNode(); err != nil {
+				return err
+			}
+			r.setResizeCondition(corev1.ConditionFalse, vspherev1.MachineResizeInProgress,
+				fmt.Sprintf("Virtual machine is powered off to be resized: %s", strings.Join(drift, ", ")))
+			// The shutdown of the guest is timed from the start of the resize.
+			findProviderCondition(r.providerStatus.Conditions, vspherev1.MachineResize).LastProbeTime = metav1.Now()
+			klog.Infof("%v: shutting down vm to resize it", r.machine.GetName())
+			if err := vm.Obj.ShutdownGuest(vm.Context); err != nil {
+				klog.Warningf("%v: failed to shut down guest, powering off vm: %v", r.machine.GetName(), err)
+				return r.startResizeTask(vm, "power off", vm.Obj.PowerOff)
+			}
+			return fmt.Errorf("waiting for vm to shut down: %w", &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second})
+		}
+
+		klog.Infof("%v: resizing vm: %s", r.machine.GetName(), strings.Join(drift, ", "))
+		return r.startResizeTask(vm, "resize", func(ctx context.Context) (*object.Task, error) {
+			return vm.Obj.Reconfigure(ctx, spec)
+		})
+	}
+
+	if resized || condition != nil {
+		r.setResizeCondition(corev1.ConditionTrue, vspherev1.MachineResizeSucceeded, "Virtual machine matches the provider spec")
+	}
+	return nil
+}
+
+// continueResize runs the next step of a resize which powered off the vm. It returns
+// nil once the vm is powered on again and its node is schedulable.
+func (r *Reconciler) continueResize(vm *virtualMachine, condition *vspherev1.VSphereMachineProviderCondition, powerState types.VirtualMachinePowerState, spec types.VirtualMachineConfigSpec, drift []string) error {
+	policy := r.providerSpec.ResizePolicy
+	resizeAllowed := policy != "" && policy != vspherev1.NoResize
+
+	if powerState == types.VirtualMachinePowerStatePoweredOn {
+		if len(drift) > 0 && policy == vspherev1.RestartResize {
+			if time.Since(condition.LastProbeTime.Time) < resizeShutdownTimeout {
+				klog.Infof("%v: waiting for vm to shut down", r.machine.GetName())
+				return fmt.Errorf("waiting for vm to shut down: %w", &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second})
+			}
+			klog.Warningf("%v: vm did not shut down within %v, powering it off", r.machine.GetName(), resizeShutdownTimeout)
+			return r.startResizeTask(vm, "power off", vm.Obj.PowerOff)
+		}
+		return r.uncordonNode()
+	}
+
+	if len(drift) > 0 && resizeAllowed {
+		klog.Infof("%v: resizing vm: %s", r.machine.GetName(), strings.Join(drift, ", "))
+		return r.startResizeTask(vm, "resize", func(ctx context.Context) (*object.Task, error) {
+			return vm.Obj.Reconfigure(ctx, spec)
+		})
+	}
+
+	// The vm is powered on again once resized, or when the resize policy no longer allows it.
+	klog.Infof("%v: powering on vm powered off to be resized", r.machine.GetName())
+	return r.startResizeTask(vm, "power on", vm.Obj.PowerOn)
+}
+
+// startResizeTask starts a task of a resize and requeues the machine until it is finished.
+func (r *Reconciler) startResizeTask(vm *virtualMachine, action string, start func(context.Context) (*object.Task, error)) error {
+	task, err := start(vm.Context)
+	if err != nil {
+		return fmt.Errorf("failed to %s vm: %w", action, err)
+	}
+	setTask(r.providerStatus, vspherev1.ResizeTaskOperation, task.Reference().Value)
+	return r.waitForTask(getTask(r.providerStatus, vspherev1.ResizeTaskOperation))
+}
+
+// getResizeSpec returns the config spec resizing a vm with the given config to
+// the provider spec, along with a description of the differences.
+// Unset provider spec fields are left as they are on the vm.
+func getResizeSpec(providerSpec *vspherev1.VSphereMachineProviderSpec, config *types.VirtualMachineConfigInfo) (types.VirtualMachineConfigSpec, []string) {
+	var spec types.VirtualMachineConfigSpec
+	var drift []string
+	hardware := config.Hardware
+
+	if providerSpec.NumCPUs != 0 && providerSpec.NumCPUs != hardware.NumCPU {
+		spec.NumCPUs = providerSpec.NumCPUs
+		drift = append(drift, fmt.Sprintf("numCPUs %d instead of %d", hardware.NumCPU, providerSpec.NumCPUs))
+	}
+
+	numCoresPerSocket := providerSpec.NumCoresPerSocket
+	// The cores per socket of the vm are kept when they still divide the CPUs.
+	if numCoresPerSocket == 0 && spec.NumCPUs != 0 && hardware.NumCoresPerSocket != 0 && spec.NumCPUs%hardware.NumCoresPerSocket != 0 {
+		numCoresPerSocket = spec.NumCPUs
+	}
+	if numCoresPerSocket != 0 && numCoresPerSocket != hardware.NumCoresPerSocket {
+		spec.NumCoresPerSocket = numCoresPerSocket
+		drift = append(drift, fmt.Sprintf("numCoresPerSocket %d instead of %d", hardware.NumCoresPerSocket, numCoresPerSocket))
+	}
+
+	if providerSpec.MemoryMiB != 0 && providerSpec.MemoryMiB != int64(hardware.MemoryMB) {
+		spec.MemoryMB = providerSpec.MemoryMiB
+		drift = append(drift, fmt.Sprintf("memoryMiB %d instead of %d", hardware.MemoryMB, providerSpec.MemoryMiB))
+	}
+
+	return spec, drift
+}
+
+// canHotAddResources returns true if the resize spec only adds CPUs or memory
+// for which hot-add is enabled on the vm.
+func canHotAddResources(spec types.VirtualMachineConfigSpec, config *types.VirtualMachineConfigInfo) bool {
+	if spec.NumCoresPerSocket != 0 {
+		return false
+	}
+	if spec.NumCPUs != 0 && (spec.NumCPUs < config.Hardware.NumCPU || config.CpuHotAddEnabled == nil || !*config.CpuHotAddEnabled) {
+		return false
+	}
+	if spec.MemoryMB != 0 && (spec.MemoryMB < int64(config.Hardware.MemoryMB) || config.MemoryHotAddEnabled == nil || !*config.MemoryHotAddEnabled) {
+		return false
+	}
+	return true
+}
+
+func (r *Reconciler) setResizeCondition(status corev1.ConditionStatus, reason vspherev1.VSphereMachineProviderConditionReason, message string) {
+	r.providerStatus.Conditions = setVSphereMachineProviderConditions(vspherev1.VSphereMachineProviderCondition{
+		Type:    vspherev1.MachineResize,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}, r.providerStatus.Conditions)
+}
+
+// waitForVMTask starts a vm task and waits for it to finish.
+func waitForVMTask(ctx context.Context, start func(context.Context) (*object.Task, error)) error {
+	task, err := start(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// drainNode cordons and drains the node of the machine, before its vm is
+// powered off to be resized.
+func (r *Reconciler) drainNode() error {
+	drainer, node, err := r.nodeDrainer()
+	if err != nil || node == nil {
+		return err
+	}
+
+	if err := drain.RunCordonOrUncordon(drainer, node, true); err != nil {
+		return fmt.Errorf("failed to cordon node %q: %w", node.Name, err)
+	}
+	if err := drain.RunNodeDrain(drainer, node.Name); err != nil {
+		// The vm is only resized once the node is drained.
+		klog.Warningf("%v: failed to drain node %q: %v", r.machine.GetName(), node.Name, err)
+		return fmt.Errorf("failed to drain node %q: %w", node.Name, &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfterSeconds * time.Second})
+	}
+	klog.Infof("%v: drained node %q", r.machine.GetName(), node.Name)
+	return nil
+}
+
+// uncordonNode makes the node of the machine schedulable again, once its vm is resized.
+func (r *Reconciler) uncordonNode() error {
+	drainer, node, err := r.nodeDrainer()
+	if err != nil || node == nil {
+		return err
+	}
+
+	if err := drain.RunCordonOrUncordon(drainer, node, false); err != nil {
+		return fmt.Errorf("failed to uncordon node %q: %w", node.Name, err)
+	}
+	return nil
+}
+
+// nodeDrainer returns a drainer for the node of the machine, or a nil node if
+// the machine has no node.
+func (r *Reconciler) nodeDrainer() (*drain.Helper, *corev1.Node, error) {
+	if r.kubeClient == nil || r.machine.Status.NodeRef == nil {
+		return nil, nil, nil
+	}
+
+	node, err := r.kubeClient.CoreV1().Nodes().Get(r.Context, r.machine.Status.NodeRef.Name, metav1.GetOptions{})
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("unable to get node %q: %w", r.machine.Status.NodeRef.Name, err)
+	}
+
+	drainer := &drain.Helper{
+		Ctx:                 r.Context,
+		Client:              r.kubeClient,
+		Force:               true,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		GracePeriodSeconds:  -1,
+		// Pods which are not evicted in time are evicted on the next reconcile.
+		Timeout: 20 * time.Second,
+		Out:     writer{klog.Info},
+		ErrOut:  writer{klog.Error},
+	}
+	return drainer, node, nil
+}
+
 func validateMachine(machine machinev1.Machine) error {
 	if machine.Labels[machinev1.MachineClusterIDLabel] == "" {
 		return machinecontroller.InvalidMachineConfiguration("%v: missing %q label", machine.GetName(), machinev1.MachineClusterIDLabel)
@@ -424,6 +916,11 @@ func validateMachine(machine machinev1.Machine) error {
 }
 
 func findVM(s *machineScope) (types.ManagedObjectReference, error) {
+	// adopted VMs were not cloned for the machine, so they can only be found by the providerID
+	if _, adopt := s.machine.Annotations[machinecontroller.AdoptInstanceAnnotation]; adopt {
+		return findVMByProviderID(s)
+	}
+
 	uuid := string(s.machine.UID)
 
 	vm, err := s.GetSession().FindVM(s.Context, uuid, s.machine.Name)
@@ -441,6 +938,28 @@ func findVM(s *machineScope) (types.ManagedObjectReference, error) {
 	return vm.Reference(), nil
 }
 
+// findVMByProviderID finds the VM with the BIOS UUID referenced by the providerID of the machine
+func findVMByProviderID(s *machineScope) (types.ManagedObjectReference, error) {
+	var providerID string
+	if s.machine.Spec.ProviderID != nil {
+		providerID = *s.machine.Spec.ProviderID
+	}
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return types.ManagedObjectReference{}, machinecontroller.InvalidMachineConfiguration("%v: providerID %q must start with %q to adopt a vm", s.machine.GetName(), providerID, providerIDPrefix)
+	}
+	uuid := strings.TrimPrefix(providerID, providerIDPrefix)
+
+	ref, err := s.GetSession().FindRefByBIOSUUID(s.Context, uuid)
+	if err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	if ref == nil {
+		return types.ManagedObjectReference{}, errNotFound{uuid: uuid}
+	}
+
+	return ref.Reference(), nil
+}
+
 // errNotFound is returned by the findVM function when a VM is not found.
 type errNotFound struct {
 	instanceUUID bool
@@ -473,14 +992,33 @@ func clone(s *machineScope) (string, error) {
 		return "", err
 	}
 
-	vmTemplate, err := s.GetSession().FindVM(*s, "", s.providerSpec.Template)
+	var folderPath, resourcepoolPath string
+	if s.providerSpec.Workspace != nil {
+		folderPath = s.providerSpec.Workspace.Folder
+		resourcepoolPath = s.providerSpec.Workspace.ResourcePool
+	}
+
+	folder, err := s.GetSession().Finder.FolderOrDefault(s, folderPath)
 	if err != nil {
-		const multipleFoundMsg = "multiple templates found, specify one in config"
-		const notFoundMsg = "template not found, specify valid value"
-		defaultError := fmt.Errorf("unable to get template %q: %w", s.providerSpec.Template, err)
+		const multipleFoundMsg = "multiple folders found, specify one in config"
+		const notFoundMsg = "folder not found, specify valid value"
+		defaultError := fmt.Errorf("unable to get folder for %q: %w", folderPath, err)
+		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+	}
+
+	resourcepool, err := s.GetSession().Finder.ResourcePoolOrDefault(s, resourcepoolPath)
+	if err != nil {
+		const multipleFoundMsg = "multiple resource pools found, specify one in config"
+		const notFoundMsg = "resource pool not found, specify valid value"
+		defaultError := fmt.Errorf("unable to get resource pool for %q: %w", resourcepool, err)
 		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
 	}
 
+	vmTemplate, err := getTemplate(s, folder, resourcepool)
+	if err != nil {
+		return "", err
+	}
+
 	var snapshotRef *types.ManagedObjectReference
 
 	// If a linked clone is requested then a MoRef for a snapshot must be
@@ -515,35 +1053,9 @@ func clone(s *machineScope) (string, error) {
 		diskMoveType = linkCloneDiskMoveType
 	}
 
-	var folderPath, datastorePath, resourcepoolPath string
-	if s.providerSpec.Workspace != nil {
-		folderPath = s.providerSpec.Workspace.Folder
-		datastorePath = s.providerSpec.Workspace.Datastore
-		resourcepoolPath = s.providerSpec.Workspace.ResourcePool
-	}
-
-	folder, err := s.GetSession().Finder.FolderOrDefault(s, folderPath)
-	if err != nil {
-		const multipleFoundMsg = "multiple folders found, specify one in config"
-		const notFoundMsg = "folder not found, specify valid value"
-		defaultError := fmt.Errorf("unable to get folder for %q: %w", folderPath, err)
-		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
-	}
-
-	datastore, err := s.GetSession().Finder.DatastoreOrDefault(s, datastorePath)
-	if err != nil {
-		const multipleFoundMsg = "multiple datastores found, specify one in config"
-		const notFoundMsg = "datastore not found, specify valid value"
-		defaultError := fmt.Errorf("unable to get datastore for %q: %w", datastorePath, err)
-		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
-	}
-
-	resourcepool, err := s.GetSession().Finder.ResourcePoolOrDefault(s, resourcepoolPath)
-	if err != nil {
-		const multipleFoundMsg = "multiple resource pools found, specify one in config"
-		const notFoundMsg = "resource pool not found, specify valid value"
-		defaultError := fmt.Errorf("unable to get resource pool for %q: %w", resourcepool, err)
-		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+	datastore, storageProfile, err := getDatastore(s, vmTemplate, folder, resourcepool)
+	if err != nil {
+		return "", err
 	}
 
 	numCPUs := s.providerSpec.NumCPUs
@@ -570,6 +1082,19 @@ func clone(s *machineScope) (string, error) {
 		deviceSpecs = append(deviceSpecs, diskSpec)
 	}
 
+	// Data disks are new disks, so they can be added to linked clones too.
+	dataDiskSpecs, dataDiskStatuses, err := getDataDiskSpecs(s, devices)
+	if err != nil {
+		return "", fmt.Errorf("error getting data disk specs: %w", err)
+	}
+	// The storage policy only applies to the data disks placed with the VM.
+	for i, diskSpec := range dataDiskSpecs {
+		if s.providerSpec.DataDisks[i].Datastore == "" {
+			diskSpec.GetVirtualDeviceConfigSpec().Profile = storageProfile
+		}
+	}
+	deviceSpecs = append(deviceSpecs, dataDiskSpecs...)
+
 	klog.V(3).Infof("Getting network devices")
 	networkDevices, err := getNetworkDevices(s, devices)
 	if err != nil {
@@ -581,6 +1106,11 @@ func clone(s *machineScope) (string, error) {
 	extraConfig := []types.BaseOptionValue{}
 
 	extraConfig = append(extraConfig, IgnitionConfig(userData)...)
+	networkConfig, err := NetworkConfig(s.providerSpec.Network)
+	if err != nil {
+		return "", machinecontroller.InvalidMachineConfiguration("invalid static network configuration: %v", err)
+	}
+	extraConfig = append(extraConfig, networkConfig...)
 	extraConfig = append(extraConfig, &types.OptionValue{
 		Key:   GuestInfoHostname,
 		Value: s.machine.GetName(),
@@ -599,12 +1129,15 @@ func clone(s *machineScope) (string, error) {
 			NumCPUs:           numCPUs,
 			NumCoresPerSocket: numCoresPerSocket,
 			MemoryMB:          s.providerSpec.MemoryMiB,
+			VmProfile:         storageProfile,
 		},
 		Location: types.VirtualMachineRelocateSpec{
 			Datastore:    types.NewReference(datastore.Reference()),
 			Folder:       types.NewReference(folder.Reference()),
 			Pool:         types.NewReference(resourcepool.Reference()),
 			DiskMoveType: diskMoveType,
+			Disk:         getDiskLocators(devices, datastore, storageProfile),
+			Profile:      storageProfile,
 		},
 		PowerOn:  true,
 		Snapshot: snapshotRef,
@@ -616,9 +1149,459 @@ func clone(s *machineScope) (string, error) {
 	}
 	taskVal := task.Reference().Value
 	klog.V(3).Infof("%v: running task: %+v", s.machine.GetName(), taskVal)
+	s.providerStatus.DataDisks = dataDiskStatuses
 	return taskVal, nil
 }
 
+// getTemplate returns the virtual machine or template which the machine is
+// cloned from.
+func getTemplate(s *machineScope, folder *object.Folder, resourcepool *object.ResourcePool) (*object.VirtualMachine, error) {
+	if s.providerSpec.ContentLibraryItem != nil {
+		return getContentLibraryTemplate(s, folder, resourcepool)
+	}
+
+	vmTemplate, err := s.GetSession().FindVM(*s, "", s.providerSpec.Template)
+	if err != nil {
+		const multipleFoundMsg = "multiple templates found, specify one in config"
+		const notFoundMsg = "template not found, specify valid value"
+		defaultError := fmt.Errorf("unable to get template %q: %w", s.providerSpec.Template, err)
+		return nil, handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+	}
+	return vmTemplate, nil
+}
+
+// contentLibraryDeployLocks holds a mutex per vm deployed from a content
+// library item, so that an item is deployed only once to a folder when
+// several machines are created from it at the same time.
+var contentLibraryDeployLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: map[string]*sync.Mutex{}}
+
+// lockContentLibraryDeploy locks the mutex of the vm path and returns the
+// function unlocking it.
+func lockContentLibraryDeploy(vmPath string) func() {
+	contentLibraryDeployLocks.Lock()
+	mu, ok := contentLibraryDeployLocks.locks[vmPath]
+	if !ok {
+		mu = &sync.Mutex{}
+		contentLibraryDeployLocks.locks[vmPath] = mu
+	}
+	contentLibraryDeployLocks.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// getContentLibraryTemplate returns the virtual machine deployed from the
+// content library item of the machine in its folder, deploying the item first
+// if needed. Unless full clones are requested, a snapshot of the virtual
+// machine is taken so that machines are linked clones of it.
+func getContentLibraryTemplate(s *machineScope, folder *object.Folder, resourcepool *object.ResourcePool) (*object.VirtualMachine, error) {
+	var item *library.Item
+	if err := s.GetSession().WithRestClient(s.Context, func(c *rest.Client) error {
+		var err error
+		item, err = findContentLibraryItem(s, library.NewManager(c), s.providerSpec.ContentLibraryItem)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	vmPath := path.Join(folder.InventoryPath, contentLibraryTemplateName(item))
+	vmTemplate, err := findContentLibraryTemplate(s, item, vmPath)
+	if err != nil {
+		return nil, err
+	}
+	if vmTemplate == nil {
+		if vmTemplate, err = deployContentLibraryTemplate(s, item, vmPath, folder, resourcepool); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.providerSpec.CloneMode == vspherev1.FullClone {
+		return vmTemplate, nil
+	}
+
+	snapshotted, err := hasSnapshot(s, vmTemplate)
+	if err != nil || snapshotted {
+		return vmTemplate, err
+	}
+
+	unlock := lockContentLibraryDeploy(vmPath)
+	defer unlock()
+
+	// Another machine may have taken the snapshot while waiting for the lock.
+	if snapshotted, err := hasSnapshot(s, vmTemplate); err != nil || snapshotted {
+		return vmTemplate, err
+	}
+	klog.V(3).Infof("%v: creating snapshot of vm %s for linked clones", s.machine.GetName(), vmTemplate.Name())
+	if err := waitForVMTask(s.Context, func(ctx context.Context) (*object.Task, error) {
+		return vmTemplate.CreateSnapshot(ctx, contentLibrarySnapshotName, "", false, false)
+	}); err != nil {
+		return nil, fmt.Errorf("error creating snapshot of vm %s: %w", vmTemplate.Name(), err)
+	}
+	return vmTemplate, nil
+}
+
+// findContentLibraryTemplate returns the vm deployed from the content library
+// item at the vm path, or nil if the item is not deployed there.
+func findContentLibraryTemplate(s *machineScope, item *library.Item, vmPath string) (*object.VirtualMachine, error) {
+	vmTemplate, err := s.GetSession().Finder.VirtualMachine(s, vmPath)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get vm %q deployed from content library item %q: %w", vmPath, item.Name, err)
+	}
+	return vmTemplate, nil
+}
+
+// deployContentLibraryTemplate deploys the content library item to the vm
+// path, unless another machine deployed it in the meantime. The deployed vm is
+// tagged with the cluster ID.
+func deployContentLibraryTemplate(s *machineScope, item *library.Item, vmPath string, folder *object.Folder, resourcepool *object.ResourcePool) (*object.VirtualMachine, error) {
+	unlock := lockContentLibraryDeploy(vmPath)
+	defer unlock()
+
+	vmTemplate, err := findContentLibraryTemplate(s, item, vmPath)
+	if err != nil || vmTemplate != nil {
+		return vmTemplate, err
+	}
+
+	klog.Infof("%v: deploying content library item %q to vm %q", s.machine.GetName(), item.Name, vmPath)
+	var ref *types.ManagedObjectReference
+	if err := s.GetSession().WithRestClient(s.Context, func(c *rest.Client) error {
+		ref, err = deployContentLibraryItem(s, c, item, path.Base(vmPath), folder, resourcepool)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	vmTemplate = object.NewVirtualMachine(s.GetSession().Client.Client, *ref)
+	vmTemplate.InventoryPath = vmPath
+
+	vm := &virtualMachine{
+		Context: s.Context,
+		Obj:     vmTemplate,
+		Ref:     *ref,
+	}
+	if err := vm.reconcileTags(s.Context, s.GetSession(), s.machine, nil, nil); err != nil {
+		return nil, fmt.Errorf("unable to tag vm %q deployed from content library item %q: %w", vmPath, item.Name, err)
+	}
+
+	logSupersededContentLibraryTemplates(s, item, vmPath)
+	return vmTemplate, nil
+}
+
+// logSupersededContentLibraryTemplates logs the vms deployed from previous
+// versions of the content library item next to the vm path. They are not
+// removed, as existing machines may be linked clones of them, and are left to
+// the administrator to clean up.
+func logSupersededContentLibraryTemplates(s *machineScope, item *library.Item, vmPath string) {
+	prefix := contentLibraryTemplatePrefix(item)
+	vms, err := s.GetSession().Finder.VirtualMachineList(s, path.Join(path.Dir(vmPath), prefix+"*"))
+	if err != nil {
+		if !isNotFound(err) {
+			klog.Errorf("%v: unable to list vms deployed from content library item %q: %v", s.machine.GetName(), item.Name, err)
+		}
+		return
+	}
+
+	for _, vm := range vms {
+		name := vm.Name()
+		if name == path.Base(vmPath) || (name != prefix && !strings.HasPrefix(name, prefix+"-v")) {
+			continue
+		}
+		klog.Warningf("%v: vm %q was deployed from a previous version of content library item %q, it can be removed once no machine is cloned from it",
+			s.machine.GetName(), name, item.Name)
+	}
+}
+
+// hasSnapshot returns whether the vm has a snapshot.
+func hasSnapshot(s *machineScope, vm *object.VirtualMachine) (bool, error) {
+	var o mo.VirtualMachine
+	if err := vm.Properties(s.Context, vm.Reference(), []string{"snapshot"}, &o); err != nil {
+		return false, fmt.Errorf("error getting snapshot information for vm %s: %w", vm.Name(), err)
+	}
+	return o.Snapshot != nil, nil
+}
+
+// findContentLibraryItem returns the item matching the name or ID of the
+// content library item, searched in the matching libraries.
+func findContentLibraryItem(ctx context.Context, m *library.Manager, ref *vspherev1.ContentLibraryItem) (*library.Item, error) {
+	libraries, err := m.GetLibraries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list content libraries: %w", err)
+	}
+
+	var found []library.Item
+	libraryFound := false
+	for _, l := range libraries {
+		if ref.Library != "" && l.ID != ref.Library && l.Name != ref.Library {
+			continue
+		}
+		libraryFound = true
+
+		items, err := m.GetLibraryItems(ctx, l.ID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list items of content library %q: %w", l.Name, err)
+		}
+		for i := range items {
+			if items[i].ID == ref.Item {
+				return &items[i], nil
+			}
+			if items[i].Name == ref.Item {
+				found = append(found, items[i])
+			}
+		}
+	}
+
+	if !libraryFound && ref.Library != "" {
+		return nil, machinecontroller.InvalidMachineConfiguration("content library %q not found, specify valid value", ref.Library)
+	}
+	switch len(found) {
+	case 0:
+		return nil, machinecontroller.InvalidMachineConfiguration("content library item %q not found, specify valid value", ref.Item)
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("multiple content library items %q found, specify a library in config", ref.Item)
+	}
+}
+
+// contentLibraryTemplateName returns the name of the vm deployed from the
+// content library item. It changes with the content of the item so that an
+// updated item is deployed again.
+func contentLibraryTemplateName(item *library.Item) string {
+	name := contentLibraryTemplatePrefix(item)
+	if item.ContentVersion != "" {
+		name = fmt.Sprintf("%s-v%s", name, item.ContentVersion)
+	}
+	return name
+}
+
+// contentLibraryTemplatePrefix returns the name shared by the vms deployed
+// from all the versions of the content library item.
+func contentLibraryTemplatePrefix(item *library.Item) string {
+	return fmt.Sprintf("%s-%.8s", item.Name, item.ID)
+}
+
+// deployContentLibraryItem deploys the OVF or VM template content library item
+// to a powered off vm in the folder and resource pool. The vm is placed in the
+// workspace datastore when set.
+func deployContentLibraryItem(s *machineScope, c *rest.Client, item *library.Item, name string, folder *object.Folder, resourcepool *object.ResourcePool) (*types.ManagedObjectReference, error) {
+	var datastoreID string
+	if s.providerSpec.Workspace != nil && s.providerSpec.Workspace.Datastore != "" {
+		datastore, err := s.GetSession().Finder.Datastore(s, s.providerSpec.Workspace.Datastore)
+		if err != nil {
+			const multipleFoundMsg = "multiple datastores found, specify one in config"
+			const notFoundMsg = "datastore not found, specify valid value"
+			defaultError := fmt.Errorf("unable to get datastore for %q: %w", s.providerSpec.Workspace.Datastore, err)
+			return nil, handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+		}
+		datastoreID = datastore.Reference().Value
+	}
+
+	m := vcenter.NewManager(c)
+	var ref *types.ManagedObjectReference
+	var err error
+	switch item.Type {
+	case library.ItemTypeOVF:
+		ref, err = m.DeployLibraryItem(s, item.ID, vcenter.Deploy{
+			DeploymentSpec: vcenter.DeploymentSpec{
+				Name:               name,
+				AcceptAllEULA:      true,
+				DefaultDatastoreID: datastoreID,
+			},
+			Target: vcenter.Target{
+				FolderID:       folder.Reference().Value,
+				ResourcePoolID: resourcepool.Reference().Value,
+			},
+		})
+	case library.ItemTypeVMTX:
+		deploy := vcenter.DeployTemplate{
+			Name: name,
+			Placement: &vcenter.Placement{
+				Folder:       folder.Reference().Value,
+				ResourcePool: resourcepool.Reference().Value,
+			},
+		}
+		if datastoreID != "" {
+			deploy.DiskStorage = &vcenter.DiskStorage{Datastore: datastoreID}
+			deploy.VMHomeStorage = &vcenter.DiskStorage{Datastore: datastoreID}
+		}
+		ref, err = m.DeployTemplateLibraryItem(s, item.ID, deploy)
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration("content library item %q has unsupported type %q, expected %q or %q",
+			item.Name, item.Type, library.ItemTypeOVF, library.ItemTypeVMTX)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to deploy content library item %q: %w", item.Name, err)
+	}
+	return ref, nil
+}
+
+// getDatastore returns the datastore in which the VM is cloned, along with the
+// storage policy to apply to the VM home and disks, if any.
+func getDatastore(s *machineScope, vmTemplate *object.VirtualMachine, folder *object.Folder, resourcepool *object.ResourcePool) (*object.Datastore, []types.BaseVirtualMachineProfileSpec, error) {
+	var datastorePath, datastoreClusterPath, storagePolicyName string
+	if s.providerSpec.Workspace != nil {
+		datastorePath = s.providerSpec.Workspace.Datastore
+		datastoreClusterPath = s.providerSpec.Workspace.DatastoreCluster
+		storagePolicyName = s.providerSpec.Workspace.StoragePolicyName
+	}
+
+	var candidates []*object.Datastore
+	switch {
+	case datastoreClusterPath != "":
+		recommended, err := getRecommendedDatastores(s, vmTemplate, folder, resourcepool, datastoreClusterPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		candidates = recommended
+	case datastorePath == "" && storagePolicyName != "":
+		// Any datastore of the datacenter may be compatible with the policy.
+		datastores, err := s.GetSession().Finder.DatastoreList(s, "*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to list datastores: %w", err)
+		}
+		if err := sortDatastoresByFreeSpace(s, datastores); err != nil {
+			return nil, nil, err
+		}
+		candidates = datastores
+	default:
+		datastore, err := s.GetSession().Finder.DatastoreOrDefault(s, datastorePath)
+		if err != nil {
+			const multipleFoundMsg = "multiple datastores found, specify one in config"
+			const notFoundMsg = "datastore not found, specify valid value"
+			defaultError := fmt.Errorf("unable to get datastore for %q: %w", datastorePath, err)
+			return nil, nil, handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+		}
+		candidates = []*object.Datastore{datastore}
+	}
+
+	if storagePolicyName == "" {
+		return candidates[0], nil, nil
+	}
+
+	profileID, err := s.GetSession().GetStoragePolicyID(s, storagePolicyName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get storage policy %q: %w", storagePolicyName, err)
+	}
+	if profileID == "" {
+		return nil, nil, machinecontroller.InvalidMachineConfiguration("storage policy %q not found, specify valid value", storagePolicyName)
+	}
+
+	compatible, err := s.GetSession().CompatibleDatastores(s, profileID, candidates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get datastores compatible with storage policy %q: %w", storagePolicyName, err)
+	}
+	if len(compatible) == 0 {
+		return nil, nil, machinecontroller.InvalidMachineConfiguration("no datastore compatible with storage policy %q found", storagePolicyName)
+	}
+	klog.V(3).Infof("%v: using datastore %v compatible with storage policy %q", s.machine.GetName(), compatible[0].Reference().Value, storagePolicyName)
+
+	return compatible[0], []types.BaseVirtualMachineProfileSpec{
+		&types.VirtualMachineDefinedProfileSpec{ProfileId: profileID},
+	}, nil
+}
+
+// getRecommendedDatastores returns the datastores of the datastore cluster
+// recommended by Storage DRS for the clone of the template, best first.
+func getRecommendedDatastores(s *machineScope, vmTemplate *object.VirtualMachine, folder *object.Folder, resourcepool *object.ResourcePool, datastoreClusterPath string) ([]*object.Datastore, error) {
+	pod, err := s.GetSession().Finder.DatastoreCluster(s, datastoreClusterPath)
+	if err != nil {
+		const multipleFoundMsg = "multiple datastore clusters found, specify one in config"
+		const notFoundMsg = "datastore cluster not found, specify valid value"
+		defaultError := fmt.Errorf("unable to get datastore cluster for %q: %w", datastoreClusterPath, err)
+		return nil, handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+	}
+
+	podRef := pod.Reference()
+	poolRef := resourcepool.Reference()
+	placementSpec := types.StoragePlacementSpec{
+		Type:         string(types.StoragePlacementSpecPlacementTypeClone),
+		Vm:           types.NewReference(vmTemplate.Reference()),
+		CloneName:    s.machine.GetName(),
+		Folder:       types.NewReference(folder.Reference()),
+		ResourcePool: &poolRef,
+		CloneSpec: &types.VirtualMachineCloneSpec{
+			Location: types.VirtualMachineRelocateSpec{
+				Pool: &poolRef,
+			},
+		},
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			StoragePod: &podRef,
+			InitialVmConfig: []types.VmPodConfigForPlacement{
+				{StoragePod: podRef},
+			},
+		},
+	}
+
+	client := s.GetSession().Client.Client
+	result, err := object.NewStorageResourceManager(client).RecommendDatastores(s, placementSpec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Storage DRS recommendations for datastore cluster %q: %w", datastoreClusterPath, err)
+	}
+
+	var datastores []*object.Datastore
+	for _, recommendation := range result.Recommendations {
+		for _, action := range recommendation.Action {
+			if placement, ok := action.(*types.StoragePlacementAction); ok {
+				datastores = append(datastores, object.NewDatastore(client, placement.Destination))
+			}
+		}
+	}
+	if len(datastores) == 0 {
+		return nil, machinecontroller.InvalidMachineConfiguration("no datastore recommended by Storage DRS for datastore cluster %q", datastoreClusterPath)
+	}
+	return datastores, nil
+}
+
+// sortDatastoresByFreeSpace sorts the datastores by decreasing free space.
+func sortDatastoresByFreeSpace(s *machineScope, datastores []*object.Datastore) error {
+	if len(datastores) == 0 {
+		return nil
+	}
+
+	refs := make([]types.ManagedObjectReference, 0, len(datastores))
+	for _, ds := range datastores {
+		refs = append(refs, ds.Reference())
+	}
+
+	var summaries []mo.Datastore
+	pc := property.DefaultCollector(s.GetSession().Client.Client)
+	if err := pc.Retrieve(s, refs, []string{"summary"}, &summaries); err != nil {
+		return fmt.Errorf("unable to get datastores summary: %w", err)
+	}
+	freeSpace := map[string]int64{}
+	for _, summary := range summaries {
+		freeSpace[summary.Reference().Value] = summary.Summary.FreeSpace
+	}
+
+	sort.SliceStable(datastores, func(i, j int) bool {
+		return freeSpace[datastores[i].Reference().Value] > freeSpace[datastores[j].Reference().Value]
+	})
+	return nil
+}
+
+// getDiskLocators returns the locators applying the storage policy to the
+// disks of the template, or nil when there is no storage policy.
+func getDiskLocators(devices object.VirtualDeviceList, datastore *object.Datastore, storageProfile []types.BaseVirtualMachineProfileSpec) []types.VirtualMachineRelocateSpecDiskLocator {
+	if len(storageProfile) == 0 {
+		return nil
+	}
+
+	var locators []types.VirtualMachineRelocateSpecDiskLocator
+	for _, disk := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+		locators = append(locators, types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId:    disk.GetVirtualDevice().Key,
+			Datastore: datastore.Reference(),
+			Profile:   storageProfile,
+		})
+	}
+	return locators
+}
+
 func getDiskSpec(s *machineScope, devices object.VirtualDeviceList) (types.BaseVirtualDeviceConfigSpec, error) {
 	disks := devices.SelectByType((*types.VirtualDisk)(nil))
 	if len(disks) != 1 {
@@ -640,6 +1623,151 @@ func getDiskSpec(s *machineScope, devices object.VirtualDeviceList) (types.BaseV
 	}, nil
 }
 
+// getDataDiskSpecs returns the specs creating the data disks of the machine on
+// the SCSI controllers of the template, along with the status recording where
+// each disk is attached.
+func getDataDiskSpecs(s *machineScope, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, []vspherev1.VSphereDiskStatus, error) {
+	if len(s.providerSpec.DataDisks) == 0 {
+		return nil, nil, nil
+	}
+
+	controllers := devices.SelectByType((*types.VirtualSCSIController)(nil))
+	if len(controllers) == 0 {
+		return nil, nil, machinecontroller.InvalidMachineConfiguration("template has no SCSI controller to attach data disks to")
+	}
+
+	// Default to the controller of the first disk of the template.
+	defaultController := controllers[0].(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+	if disks := devices.SelectByType((*types.VirtualDisk)(nil)); len(disks) > 0 {
+		if c, ok := devices.FindByKey(disks[0].GetVirtualDevice().ControllerKey).(types.BaseVirtualSCSIController); ok {
+			defaultController = c.GetVirtualSCSIController()
+		}
+	}
+
+	// Units already used on each controller, by the template or a previous data disk.
+	usedUnits := map[int32]map[int32]bool{}
+	for _, dev := range controllers {
+		c := dev.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+		// The SCSI controller sits on its own unit.
+		usedUnits[c.Key] = map[int32]bool{c.ScsiCtlrUnitNumber: true}
+	}
+	for _, dev := range devices {
+		d := dev.GetVirtualDevice()
+		if units, ok := usedUnits[d.ControllerKey]; ok && d.UnitNumber != nil {
+			units[*d.UnitNumber] = true
+		}
+	}
+
+	var diskSpecs []types.BaseVirtualDeviceConfigSpec
+	var diskStatuses []vspherev1.VSphereDiskStatus
+	for i := range s.providerSpec.DataDisks {
+		dataDisk := &s.providerSpec.DataDisks[i]
+
+		controller := defaultController
+		if dataDisk.ControllerBusNumber != nil {
+			controller = nil
+			for _, dev := range controllers {
+				c := dev.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+				if c.BusNumber == *dataDisk.ControllerBusNumber {
+					controller = c
+					break
+				}
+			}
+			if controller == nil {
+				return nil, nil, machinecontroller.InvalidMachineConfiguration(
+					"data disk %q: template has no SCSI controller with bus number %d", dataDisk.Name, *dataDisk.ControllerBusNumber)
+			}
+		}
+
+		unit, err := getDataDiskUnitNumber(dataDisk, usedUnits[controller.Key])
+		if err != nil {
+			return nil, nil, err
+		}
+		usedUnits[controller.Key][unit] = true
+
+		backing := &types.VirtualDiskFlatVer2BackingInfo{
+			DiskMode:        string(types.VirtualDiskModePersistent),
+			ThinProvisioned: types.NewBool(true),
+		}
+		switch dataDisk.ProvisioningType {
+		case "", vspherev1.ThinProvisioned:
+		case vspherev1.ThickProvisioned:
+			backing.ThinProvisioned = types.NewBool(false)
+		case vspherev1.EagerlyZeroedProvisioned:
+			backing.ThinProvisioned = types.NewBool(false)
+			backing.EagerlyScrub = types.NewBool(true)
+		default:
+			return nil, nil, machinecontroller.InvalidMachineConfiguration(
+				"data disk %q: unknown provisioning type %q", dataDisk.Name, dataDisk.ProvisioningType)
+		}
+
+		// Without a datastore the disk is created in the directory of the VM.
+		if dataDisk.Datastore != "" {
+			datastore, err := s.GetSession().Finder.Datastore(s.Context, dataDisk.Datastore)
+			if err != nil {
+				const multipleFoundMsg = "multiple datastores found for data disk, specify one in config"
+				const notFoundMsg = "datastore of data disk not found, specify valid value"
+				defaultError := fmt.Errorf("unable to get datastore for data disk %q: %w", dataDisk.Name, err)
+				return nil, nil, handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
+			}
+			backing.FileName = fmt.Sprintf("[%s]", datastore.Name())
+			backing.Datastore = types.NewReference(datastore.Reference())
+		}
+
+		disk := &types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				// Assign a temporary negative device key, the actual one is
+				// generated when the device is created.
+				Key:           -int32(i) - 1,
+				ControllerKey: controller.Key,
+				UnitNumber:    types.NewInt32(unit),
+				Backing:       backing,
+			},
+			CapacityInKB: int64(dataDisk.SizeGiB) * 1024 * 1024,
+		}
+
+		diskSpecs = append(diskSpecs, &types.VirtualDeviceConfigSpec{
+			Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+			FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+			Device:        disk,
+		})
+		diskStatuses = append(diskStatuses, vspherev1.VSphereDiskStatus{
+			Name:                dataDisk.Name,
+			ControllerBusNumber: controller.BusNumber,
+			UnitNumber:          unit,
+		})
+		klog.V(3).Infof("%v: adding data disk %q of %dGiB on controller %d unit %d",
+			s.machine.GetName(), dataDisk.Name, dataDisk.SizeGiB, controller.BusNumber, unit)
+	}
+
+	return diskSpecs, diskStatuses, nil
+}
+
+// getDataDiskUnitNumber returns the unit number requested for the data disk if
+// it is free, or the first free unit number of the controller otherwise.
+func getDataDiskUnitNumber(dataDisk *vspherev1.VSphereDisk, usedUnits map[int32]bool) (int32, error) {
+	if dataDisk.UnitNumber != nil {
+		unit := *dataDisk.UnitNumber
+		if unit < 0 || unit >= maxSCSIUnits {
+			return 0, machinecontroller.InvalidMachineConfiguration(
+				"data disk %q: unit number %d is out of range [0, %d)", dataDisk.Name, unit, maxSCSIUnits)
+		}
+		if usedUnits[unit] {
+			return 0, machinecontroller.InvalidMachineConfiguration(
+				"data disk %q: unit number %d is already in use", dataDisk.Name, unit)
+		}
+		return unit, nil
+	}
+
+	for unit := int32(0); unit < maxSCSIUnits; unit++ {
+		if !usedUnits[unit] {
+			return unit, nil
+		}
+	}
+	return 0, machinecontroller.InvalidMachineConfiguration(
+		"data disk %q: no unit number left on the controller", dataDisk.Name)
+}
+
 func getNetworkDevices(s *machineScope, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, error) {
 	var networkDevices []types.BaseVirtualDeviceConfigSpec
 	// Remove any existing NICs
@@ -699,28 +1827,135 @@ func newVMFlagInfo() *types.VirtualMachineFlagInfo {
 	}
 }
 
-func taskIsFinished(task *mo.Task) (bool, error) {
+// getTask returns the last task run for the given operation, or nil if there is none.
+// The task recorded in the deprecated TaskRef field is moved to the create task,
+// as it is the task of the machines created before the tasks were tracked per operation.
+func getTask(status *vspherev1.VSphereMachineProviderStatus, operation vspherev1.VSphereMachineTaskOperation) *vspherev1.VSphereMachineTask {
+	if status.TaskRef != "" {
+		if len(status.Tasks) == 0 {
+			status.Tasks = []vspherev1.VSphereMachineTask{{
+				Operation: vspherev1.CreateTaskOperation,
+				TaskRef:   status.TaskRef,
+			}}
+		}
+		status.TaskRef = ""
+	}
+
+	for i := range status.Tasks {
+		if status.Tasks[i].Operation == operation {
+			return &status.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// setTask records the task run for the given operation, replacing the previous one.
+func setTask(status *vspherev1.VSphereMachineProviderStatus, operation vspherev1.VSphereMachineTaskOperation, taskRef string) {
+	task := vspherev1.VSphereMachineTask{
+		Operation: operation,
+		TaskRef:   taskRef,
+		State:     vspherev1.TaskQueued,
+	}
+	if previous := getTask(status, operation); previous != nil {
+		*previous = task
+		return
+	}
+	status.Tasks = append(status.Tasks, task)
+}
+
+// refreshTask records the current state of the task, until it is finished.
+func (r *Reconciler) refreshTask(task *vspherev1.VSphereMachineTask) error {
+	if task.State == vspherev1.TaskSucceeded || task.State == vspherev1.TaskFailed {
+		return nil
+	}
+
+	moTask, err := r.session.GetTask(r.Context, task.TaskRef)
+	if err != nil {
+		return err
+	}
+	if moTask == nil {
+		// Possible eventual consistency problem from vsphere
+		// TODO: change error message here to indicate this might be expected.
+		return fmt.Errorf("Unexpected moTask nil")
+	}
+
+	task.State = vspherev1.VSphereMachineTaskState(moTask.Info.State)
+	task.Error = ""
+	if moTask.Info.Error != nil {
+		task.Error = moTask.Info.Error.LocalizedMessage
+	}
+	return nil
+}
+
+// watchTask enqueues the machine once the task is finished, if the reconciler
+// has a task watcher.
+func (r *Reconciler) watchTask(task *vspherev1.VSphereMachineTask) {
+	if r.taskWatcher == nil {
+		return
+	}
+	r.taskWatcher.Watch(r.session, task.TaskRef, &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: r.machine.Namespace,
+			Name:      r.machine.Name,
+		},
+	})
+}
+
+// watchVMEvents subscribes to the vCenter events of the vms of the cluster,
+// if the reconciler has a vm event watcher. The events are recorded on, and
+// enqueue, the machines of the namespace named after their vms.
+func (r *Reconciler) watchVMEvents() {
+	if r.vmEventWatcher == nil {
+		return
+	}
+	c := r.client
+	namespace := r.machine.Namespace
+	r.vmEventWatcher.Subscribe(r.session, r.machine.Labels[machinev1.MachineClusterIDLabel], func(ctx context.Context, vmName string) (runtimeclient.Object, error) {
+		machine := &machinev1.Machine{}
+		if err := c.Get(ctx, runtimeclient.ObjectKey{Namespace: namespace, Name: vmName}, machine); err != nil {
+			if apimachineryerrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return machine, nil
+	})
+}
+
+// waitForTask returns an error requeueing the machine until the task is finished.
+// With a task watcher the machine is enqueued as soon as the task finishes, and
+// the requeue is only a fallback in case waiting for the task fails.
+func (r *Reconciler) waitForTask(task *vspherev1.VSphereMachineTask) error {
+	requeueAfter := requeueAfterSeconds * time.Second
+	if r.taskWatcher != nil {
+		r.watchTask(task)
+		requeueAfter = taskWatchRequeueAfter
+	}
+	return fmt.Errorf("task %v has not finished: %w", task.TaskRef, &machinecontroller.RequeueAfterError{RequeueAfter: requeueAfter})
+}
+
+func taskIsFinished(task *vspherev1.VSphereMachineTask) (bool, error) {
 	if task == nil {
 		return true, nil
 	}
 
 	// Otherwise the course of action is determined by the state of the task.
-	klog.V(3).Infof("task: %v, state: %v, description-id: %v", task.Reference().Value, task.Info.State, task.Info.DescriptionId)
-	switch task.Info.State {
-	case types.TaskInfoStateQueued:
+	klog.V(3).Infof("task: %v, operation: %v, state: %v", task.TaskRef, task.Operation, task.State)
+	switch task.State {
+	case vspherev1.TaskQueued:
 		return false, nil
-	case types.TaskInfoStateRunning:
+	case vspherev1.TaskRunning:
 		return false, nil
-	case types.TaskInfoStateSuccess:
+	case vspherev1.TaskSucceeded:
 		return true, nil
-	case types.TaskInfoStateError:
-		return true, errors.New(task.Info.Error.LocalizedMessage)
+	case vspherev1.TaskFailed:
+		return true, errors.New(task.Error)
 	default:
-		return false, fmt.Errorf("task: %v, unknown state %v", task.Reference().Value, task.Info.State)
+		return false, fmt.Errorf("task: %v, unknown state %v", task.TaskRef, task.State)
 	}
 }
 
-func setProviderStatus(taskRef string, condition vspherev1.VSphereMachineProviderCondition, scope *machineScope, vm *virtualMachine) error {
+func setProviderStatus(condition vspherev1.VSphereMachineProviderCondition, scope *machineScope, vm *virtualMachine) error {
 	klog.Infof("%s: Updating provider status", scope.machine.Name)
 
 	if vm != nil {
@@ -737,10 +1972,6 @@ func setProviderStatus(taskRef string, condition vspherev1.VSphereMachineProvide
 		}
 	}
 
-	if taskRef != "" {
-		scope.providerStatus.TaskRef = taskRef
-	}
-
 	scope.providerStatus.Conditions = setVSphereMachineProviderConditions(condition, scope.providerStatus.Conditions)
 
 	return nil
@@ -867,7 +2098,9 @@ func (vm *virtualMachine) getPowerState() (types.VirtualMachinePowerState, error
 
 // reconcileTags ensures that the required tags are present on the virtual machine, eg the Cluster ID
 // that is used by the installer on cluster deletion to ensure ther are no leaked resources.
-func (vm *virtualMachine) reconcileTags(ctx context.Context, session *session.Session, machine *machinev1.Machine) error {
+// The user-defined tags are attached too, creating their categories and tags if they are missing,
+// and the previously attached user-defined tags which are no longer wanted are detached.
+func (vm *virtualMachine) reconcileTags(ctx context.Context, session *session.Session, machine *machinev1.Machine, userTags, previousTags []vspherev1.VSphereTag) error {
 	if err := session.WithRestClient(vm.Context, func(c *rest.Client) error {
 		klog.Infof("%v: Reconciling attached tags", machine.GetName())
 
@@ -888,7 +2121,7 @@ func (vm *virtualMachine) reconcileTags(ctx context.Context, session *session.Se
 			}
 		}
 
-		return nil
+		return vm.reconcileUserTags(ctx, m, machine, userTags, previousTags)
 	}); err != nil {
 		return err
 	}
@@ -896,6 +2129,159 @@ func (vm *virtualMachine) reconcileTags(ctx context.Context, session *session.Se
 	return nil
 }
 
+// reconcileUserTags attaches the user-defined tags to the virtual machine
+// and detaches the previous ones which were removed from the provider spec.
+func (vm *virtualMachine) reconcileUserTags(ctx context.Context, m *tags.Manager, machine *machinev1.Machine, userTags, previousTags []vspherev1.VSphereTag) error {
+	if len(userTags) == 0 && len(previousTags) == 0 {
+		return nil
+	}
+
+	attachedTags, err := m.GetAttachedTags(ctx, vm.Ref)
+	if err != nil {
+		return err
+	}
+	attachedIDs := sets.NewString()
+	for _, tag := range attachedTags {
+		attachedIDs.Insert(tag.ID)
+	}
+
+	wanted := map[vspherev1.VSphereTag]bool{}
+	for _, userTag := range userTags {
+		wanted[userTag] = true
+
+		tag, err := getOrCreateTag(ctx, m, userTag)
+		if err != nil {
+			return err
+		}
+		if attachedIDs.Has(tag.ID) {
+			continue
+		}
+		klog.Infof("%v: Attaching %s/%s tag to vm", machine.GetName(), userTag.Category, userTag.Name)
+		if err := m.AttachTag(ctx, tag.ID, vm.Ref); err != nil {
+			return fmt.Errorf("failed to attach tag %s/%s: %w", userTag.Category, userTag.Name, err)
+		}
+	}
+
+	for _, previousTag := range previousTags {
+		if wanted[previousTag] {
+			continue
+		}
+		tag, err := findTag(ctx, m, previousTag)
+		if err != nil {
+			return err
+		}
+		if tag == nil || !attachedIDs.Has(tag.ID) {
+			continue
+		}
+		klog.Infof("%v: Detaching %s/%s tag from vm", machine.GetName(), previousTag.Category, previousTag.Name)
+		if err := m.DetachTag(ctx, tag.ID, vm.Ref); err != nil {
+			return fmt.Errorf("failed to detach tag %s/%s: %w", previousTag.Category, previousTag.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// getOrCreateTag returns the given tag, creating it and its category if they don't exist.
+// Categories are created with a multiple cardinality, so that several of their tags can be attached to a virtual machine.
+func getOrCreateTag(ctx context.Context, m *tags.Manager, userTag vspherev1.VSphereTag) (*tags.Tag, error) {
+	category, err := findCategory(ctx, m, userTag.Category)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		klog.Infof("Creating %s tag category", userTag.Category)
+		id, err := m.CreateCategory(ctx, &tags.Category{
+			Name:            userTag.Category,
+			Cardinality:     "MULTIPLE",
+			AssociableTypes: []string{"VirtualMachine"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tag category %s: %w", userTag.Category, err)
+		}
+		category = &tags.Category{ID: id, Name: userTag.Category}
+	}
+
+	tag, err := findTagInCategory(ctx, m, category, userTag.Name)
+	if err != nil || tag != nil {
+		return tag, err
+	}
+
+	klog.Infof("Creating %s/%s tag", userTag.Category, userTag.Name)
+	id, err := m.CreateTag(ctx, &tags.Tag{
+		Name:       userTag.Name,
+		CategoryID: category.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag %s/%s: %w", userTag.Category, userTag.Name, err)
+	}
+	return &tags.Tag{ID: id, Name: userTag.Name, CategoryID: category.ID}, nil
+}
+
+// findTag returns the given tag, or nil if it or its category doesn't exist
+func findTag(ctx context.Context, m *tags.Manager, userTag vspherev1.VSphereTag) (*tags.Tag, error) {
+	category, err := findCategory(ctx, m, userTag.Category)
+	if err != nil || category == nil {
+		return nil, err
+	}
+	return findTagInCategory(ctx, m, category, userTag.Name)
+}
+
+func findCategory(ctx context.Context, m *tags.Manager, name string) (*tags.Category, error) {
+	categories, err := m.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		if categories[i].Name == name {
+			return &categories[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func findTagInCategory(ctx context.Context, m *tags.Manager, category *tags.Category, name string) (*tags.Tag, error) {
+	categoryTags, err := m.GetTagsForCategory(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categoryTags {
+		if categoryTags[i].Name == name {
+			return &categoryTags[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// detachTags removes the tags attached by reconcileTags from the virtual machine,
+// so that the installer no longer considers it part of the cluster on cluster deletion.
+func (vm *virtualMachine) detachTags(ctx context.Context, session *session.Session, machine *machinev1.Machine) error {
+	return session.WithRestClient(vm.Context, func(c *rest.Client) error {
+		klog.Infof("%v: Detaching tags", machine.GetName())
+
+		m := tags.NewManager(c)
+
+		clusterID := machine.Labels[machinev1.MachineClusterIDLabel]
+
+		attachedTags, err := m.GetAttachedTags(ctx, vm.Ref)
+		if err != nil {
+			return err
+		}
+
+		for _, tag := range attachedTags {
+			if tag.Name != clusterID {
+				continue
+			}
+			klog.Infof("%v: Detaching %s tag from vm", machine.GetName(), clusterID)
+			if err := m.DetachTag(ctx, tag.ID, vm.Ref); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 // checkAttachedTag returns true if tag is already attached to a vm or tag doesn't exist
 func (vm *virtualMachine) checkAttachedTag(ctx context.Context, tagName string, m *tags.Manager) (bool, error) {
 	// cluster ID tag doesn't exists in UPI, we should skip tag attachment if it's not found
@@ -1021,6 +2407,68 @@ func IgnitionConfig(data []byte) []types.BaseOptionValue {
 	}
 }
 
+// NetworkConfig returns a slice of option values that set the static network
+// configuration of the given network spec in the guest. Devices without static
+// addresses are left to DHCP.
+func NetworkConfig(network vspherev1.NetworkSpec) ([]types.BaseOptionValue, error) {
+	var config []types.BaseOptionValue
+	var kargs []string
+	nameservers := sets.NewString()
+
+	setOption := func(key string, i int, values ...string) {
+		if len(values) == 0 || values[0] == "" {
+			return
+		}
+		config = append(config, &types.OptionValue{
+			Key:   fmt.Sprintf(key, i),
+			Value: strings.Join(values, ","),
+		})
+	}
+
+	for i, device := range network.Devices {
+		for _, addr := range device.IPAddrs {
+			ip, ipNet, err := net.ParseCIDR(addr)
+			if err != nil {
+				return nil, fmt.Errorf("device %d: %w", i, err)
+			}
+			prefix, _ := ipNet.Mask.Size()
+
+			// dracut ip=<client-IP>:[<peer>]:<gateway-IP>:<netmask>:<client_hostname>:<interface>:none
+			if ip.To4() != nil {
+				kargs = append(kargs, fmt.Sprintf("ip=%s::%s:%s:::none", ip, device.Gateway4, net.IP(ipNet.Mask)))
+			} else {
+				gateway := device.Gateway6
+				if gateway != "" {
+					gateway = "[" + gateway + "]"
+				}
+				kargs = append(kargs, fmt.Sprintf("ip=[%s]::%s:%d:::none", ip, gateway, prefix))
+			}
+		}
+
+		for _, nameserver := range device.Nameservers {
+			if !nameservers.Has(nameserver) {
+				kargs = append(kargs, fmt.Sprintf("nameserver=%s", nameserver))
+				nameservers.Insert(nameserver)
+			}
+		}
+
+		setOption(GuestInfoNetworkIPAddrs, i, device.IPAddrs...)
+		setOption(GuestInfoNetworkGateway4, i, device.Gateway4)
+		setOption(GuestInfoNetworkGateway6, i, device.Gateway6)
+		setOption(GuestInfoNetworkNameservers, i, device.Nameservers...)
+		setOption(GuestInfoNetworkSearchDomains, i, device.SearchDomains...)
+	}
+
+	if len(kargs) > 0 {
+		config = append([]types.BaseOptionValue{&types.OptionValue{
+			Key:   GuestInfoNetworkKargs,
+			Value: strings.Join(kargs, " "),
+		}}, config...)
+	}
+
+	return config, nil
+}
+
 // EncodeIgnitionConfig attempts to decode the given data until it looks to be
 // plain-text, then returns a base64 encoded version of that plain-text.
 func EncodeIgnitionConfig(data []byte) string {
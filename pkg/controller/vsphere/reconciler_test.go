@@ -21,8 +21,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"path"
 	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
@@ -31,16 +34,25 @@ import (
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vapi/vcenter"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 
+	_ "github.com/vmware/govmomi/pbm/simulator"
 	_ "github.com/vmware/govmomi/vapi/simulator"
 )
 
@@ -214,6 +226,28 @@ func TestClone(t *testing.T) {
 			},
 			expectedError: errors.New("error getting disk spec for \"\": can't resize template disk down, initial capacity is larger: 5242880KiB > 4194304KiB"),
 		},
+		{
+			testCase: "fail on invalid static IP address",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				Workspace: &vsphereapi.Workspace{
+					Server: server.URL.Host,
+				},
+				Network: vsphereapi.NetworkSpec{
+					Devices: []vsphereapi.NetworkDeviceSpec{
+						{NetworkName: "VM Network", IPAddrs: []string{"192.168.1.10"}},
+					},
+				},
+				DiskGiB:  defaultSizeGiB,
+				Template: vm.Name,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			expectedError: errors.New("invalid static network configuration: device 0: invalid CIDR address: 192.168.1.10"),
+		},
 		{
 			testCase: "fail on invalid resource pool",
 			providerSpec: vsphereapi.VSphereMachineProviderSpec{
@@ -441,167 +475,677 @@ func TestClone(t *testing.T) {
 	}
 }
 
-func TestGetPowerState(t *testing.T) {
+func TestCloneWithDataDisks(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
 	defer server.Close()
 
-	simulatorVM := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
-	ref := simulatorVM.VirtualMachine.Reference()
+	credentialsSecretUsername := fmt.Sprintf("%s.username", server.URL.Host)
+	credentialsSecretPassword := fmt.Sprintf("%s.password", server.URL.Host)
+	password, _ := server.URL.User.Password()
+	namespace := "test"
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	template := object.NewVirtualMachine(session.Client.Client, vm.Reference())
+
+	credentialsSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			credentialsSecretUsername: []byte(server.URL.User.Username()),
+			credentialsSecretPassword: []byte(password),
+		},
+	}
+
+	userDataSecretName := "vsphere-ignition"
+	userDataSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      userDataSecretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			userDataSecretKey: []byte("{}"),
+		},
+	}
 
 	testCases := []struct {
-		testCase string
-		vm       func(t *testing.T) *virtualMachine
-		expected types.VirtualMachinePowerState
+		testCase    string
+		cloneMode   vsphereapi.CloneMode
+		setup       func() error
+		machineName string
 	}{
 		{
-			testCase: "powered off",
-			vm: func(t *testing.T) *virtualMachine {
-				vm := &virtualMachine{
-					Context: context.TODO(),
-					Obj:     object.NewVirtualMachine(session.Client.Client, ref),
-					Ref:     ref,
-				}
-				_, err := vm.Obj.PowerOff(vm.Context)
-				if err != nil {
-					t.Fatal(err)
-				}
-				return vm
-			},
-			expected: types.VirtualMachinePowerStatePoweredOff,
+			testCase:    "full clone",
+			cloneMode:   vsphereapi.FullClone,
+			machineName: "full-clone",
 		},
 		{
-			testCase: "powered on",
-			vm: func(t *testing.T) *virtualMachine {
-				vm := &virtualMachine{
-					Context: context.TODO(),
-					Obj:     object.NewVirtualMachine(session.Client.Client, ref),
-					Ref:     ref,
-				}
-				_, err := vm.Obj.PowerOn(vm.Context)
+			testCase:  "linked clone",
+			cloneMode: vsphereapi.LinkedClone,
+			setup: func() error {
+				task, err := template.CreateSnapshot(context.TODO(), "snapshot", "", false, false)
 				if err != nil {
-					t.Fatal(err)
+					return err
 				}
-				return vm
+				return task.Wait(context.TODO())
 			},
-
-			expected: types.VirtualMachinePowerStatePoweredOn,
+			machineName: "linked-clone",
 		},
 	}
+
 	for _, tc := range testCases {
 		t.Run(tc.testCase, func(t *testing.T) {
-			got, err := tc.vm(t).getPowerState()
+			if tc.setup != nil {
+				if err := tc.setup(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tc.machineName,
+						Namespace: namespace,
+					},
+				},
+				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+					CredentialsSecret: &corev1.LocalObjectReference{
+						Name: "test",
+					},
+					Workspace: &vsphereapi.Workspace{
+						Server: server.URL.Host,
+					},
+					DiskGiB:   1,
+					Template:  vm.Name,
+					CloneMode: tc.cloneMode,
+					UserDataSecret: &corev1.LocalObjectReference{
+						Name: userDataSecretName,
+					},
+					DataDisks: []vsphereapi.VSphereDisk{
+						{Name: "containers", SizeGiB: 100},
+						{Name: "logs", SizeGiB: 10, Datastore: "LocalDS_0", ProvisioningType: vsphereapi.ThickProvisioned},
+					},
+				},
+				session:        session,
+				providerStatus: &vsphereapi.VSphereMachineProviderStatus{},
+				client:         fake.NewFakeClientWithScheme(scheme.Scheme, &credentialsSecret, &userDataSecret),
+			}
+
+			taskRef, err := clone(machineScope)
+			if err != nil {
+				t.Fatalf("clone() was not expected to return error: %v", err)
+			}
+			task := object.NewTask(session.Client.Client, types.ManagedObjectReference{Type: "Task", Value: taskRef})
+			if err := task.Wait(context.TODO()); err != nil {
+				t.Fatalf("Clone task failed: %v", err)
+			}
+
+			expectedPlacement := []vsphereapi.VSphereDiskStatus{
+				{Name: "containers", ControllerBusNumber: 0, UnitNumber: 1},
+				{Name: "logs", ControllerBusNumber: 0, UnitNumber: 2},
+			}
+			if !reflect.DeepEqual(machineScope.providerStatus.DataDisks, expectedPlacement) {
+				t.Fatalf("Expected data disks status %+v, got %+v", expectedPlacement, machineScope.providerStatus.DataDisks)
+			}
+
+			cloneVM, err := session.Finder.VirtualMachine(context.TODO(), tc.machineName)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if got != tc.expected {
-				t.Errorf("Got: %v, expected: %v", got, tc.expected)
+			r := &Reconciler{machineScope: machineScope}
+			if err := r.reconcileDataDisks(&virtualMachine{Context: context.TODO(), Obj: cloneVM, Ref: cloneVM.Reference()}); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expectedSizeGiB := range []int32{100, 10} {
+				diskStatus := machineScope.providerStatus.DataDisks[i]
+				if diskStatus.SizeGiB != expectedSizeGiB {
+					t.Errorf("Expected data disk %q of %dGiB, got %dGiB", diskStatus.Name, expectedSizeGiB, diskStatus.SizeGiB)
+				}
+				if diskStatus.FileName == "" {
+					t.Errorf("Expected data disk %q to have a backing file", diskStatus.Name)
+				}
 			}
 		})
 	}
 }
 
-func TestTaskIsFinished(t *testing.T) {
+func TestCloneFromContentLibrary(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
 	defer server.Close()
 
-	obj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
-	// Validate VM is powered on
-	if obj.Runtime.PowerState != poweredOnState {
-		t.Fatal(obj.Runtime.PowerState)
+	credentialsSecretUsername := fmt.Sprintf("%s.username", server.URL.Host)
+	credentialsSecretPassword := fmt.Sprintf("%s.password", server.URL.Host)
+	password, _ := server.URL.User.Password()
+	namespace := "test"
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	datastore := simulator.Map.Any("Datastore").(*simulator.Datastore)
+	folder, err := session.Finder.DefaultFolder(context.TODO())
+	if err != nil {
+		t.Fatal(err)
 	}
-	vm := object.NewVirtualMachine(session.Client.Client, obj.Reference())
-	task, err := vm.PowerOff(context.TODO())
+
+	var item *library.Item
+	if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+		m := library.NewManager(c)
+		libraryID, err := m.CreateLibrary(context.TODO(), library.Library{
+			Name:    "library",
+			Type:    "LOCAL",
+			Storage: []library.StorageBackings{{DatastoreID: datastore.Reference().Value, Type: "DATASTORE"}},
+		})
+		if err != nil {
+			return err
+		}
+		itemID, err := vcenter.NewManager(c).CreateTemplate(context.TODO(), vcenter.Template{
+			Name:      "rhcos",
+			Library:   libraryID,
+			SourceVM:  vm.Reference().Value,
+			Placement: &vcenter.Placement{Folder: folder.Reference().Value},
+		})
+		if err != nil {
+			return err
+		}
+		item, err = m.GetLibraryItem(context.TODO(), itemID)
+		if err != nil {
+			return err
+		}
+
+		tagsMgr := tags.NewManager(c)
+		categoryID, err := tagsMgr.CreateCategory(context.TODO(), &tags.Category{
+			AssociableTypes: []string{"VirtualMachine"},
+			Cardinality:     "SINGLE",
+			Name:            "CLUSTERID_CATEGORY",
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tagsMgr.CreateTag(context.TODO(), &tags.Tag{
+			CategoryID: categoryID,
+			Name:       "CLUSTERID",
+		})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	templatePath := path.Join(folder.InventoryPath, contentLibraryTemplateName(item))
+
+	// A machine is a linked clone of the vm deployed from a previous version
+	// of the item, which is kept once the current version is deployed.
+	supersededName := contentLibraryTemplatePrefix(item) + "-vold"
+	if err := waitForVMTask(context.TODO(), func(ctx context.Context) (*object.Task, error) {
+		return object.NewVirtualMachine(session.Client.Client, vm.Reference()).Clone(ctx, folder, supersededName, types.VirtualMachineCloneSpec{})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	superseded, err := session.Finder.VirtualMachine(context.TODO(), path.Join(folder.InventoryPath, supersededName))
 	if err != nil {
 		t.Fatal(err)
 	}
-	var moTask mo.Task
-	moRef := types.ManagedObjectReference{
-		Type:  "Task",
-		Value: task.Reference().Value,
+	if err := waitForVMTask(context.TODO(), func(ctx context.Context) (*object.Task, error) {
+		return superseded.CreateSnapshot(ctx, contentLibrarySnapshotName, "", false, false)
+	}); err != nil {
+		t.Fatal(err)
 	}
-	if err := session.RetrieveOne(context.TODO(), moRef, []string{"info"}, &moTask); err != nil {
+	snapshot, err := superseded.FindSnapshot(context.TODO(), contentLibrarySnapshotName)
+	if err != nil {
 		t.Fatal(err)
 	}
-
-	testCases := []struct {
-		testCase    string
-		moTask      func() *mo.Task
-		expectError bool
-		finished    bool
-	}{
-		{
-			testCase: "existing taskRef",
-			moTask: func() *mo.Task {
-				return &moTask
+	if err := waitForVMTask(context.TODO(), func(ctx context.Context) (*object.Task, error) {
+		return superseded.Clone(ctx, folder, "old-linked-clone", types.VirtualMachineCloneSpec{
+			Location: types.VirtualMachineRelocateSpec{
+				DiskMoveType: string(types.VirtualMachineRelocateDiskMoveOptionsCreateNewChildDiskBacking),
 			},
-			expectError: false,
-			finished:    true,
+			Snapshot: snapshot,
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	credentialsSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: namespace,
 		},
-		{
-			testCase: "nil task",
-			moTask: func() *mo.Task {
-				return nil
-			},
-			expectError: false,
-			finished:    true,
+		Data: map[string][]byte{
+			credentialsSecretUsername: []byte(server.URL.User.Username()),
+			credentialsSecretPassword: []byte(password),
+		},
+	}
+
+	userDataSecretName := "vsphere-ignition"
+	userDataSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      userDataSecretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			userDataSecretKey: []byte("{}"),
 		},
+	}
+
+	testCases := []struct {
+		testCase         string
+		item             vsphereapi.ContentLibraryItem
+		cloneMode        vsphereapi.CloneMode
+		machineName      string
+		expectedError    error
+		expectedSnapshot bool
+	}{
 		{
-			testCase: "task succeeded is finished",
-			moTask: func() *mo.Task {
-				moTask.Info.State = types.TaskInfoStateSuccess
-				return &moTask
-			},
-			expectError: false,
-			finished:    true,
+			testCase:    "full clone deploys the item",
+			item:        vsphereapi.ContentLibraryItem{Library: "library", Item: "rhcos"},
+			cloneMode:   vsphereapi.FullClone,
+			machineName: "full-clone",
 		},
 		{
-			testCase: "task error is finished",
-			moTask: func() *mo.Task {
-				moTask.Info.State = types.TaskInfoStateError
-				moTask.Info.Error = &types.LocalizedMethodFault{}
-				return &moTask
-			},
-			expectError: true,
-			finished:    true,
+			testCase:         "linked clone reuses the deployed item",
+			item:             vsphereapi.ContentLibraryItem{Item: item.ID},
+			machineName:      "linked-clone",
+			expectedSnapshot: true,
 		},
 		{
-			testCase: "task running is not finished",
-			moTask: func() *mo.Task {
-				moTask.Info.State = types.TaskInfoStateRunning
-				return &moTask
-			},
-			expectError: false,
-			finished:    false,
+			testCase:      "unknown library",
+			item:          vsphereapi.ContentLibraryItem{Library: "unknown", Item: "rhcos"},
+			expectedError: machinecontroller.InvalidMachineConfiguration("content library \"unknown\" not found, specify valid value"),
 		},
 		{
-			testCase: "task with unknown state errors",
-			moTask: func() *mo.Task {
-				moTask.Info.State = types.TaskInfoState("unknown")
-				return &moTask
-			},
-			expectError: true,
-			finished:    false,
+			testCase:      "unknown item",
+			item:          vsphereapi.ContentLibraryItem{Library: "library", Item: "unknown"},
+			expectedError: machinecontroller.InvalidMachineConfiguration("content library item \"unknown\" not found, specify valid value"),
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.testCase, func(t *testing.T) {
-			finished, err := taskIsFinished(tc.moTask())
-			if (err != nil) != tc.expectError {
-				t.Errorf("Expected error: %v, got: %v", tc.expectError, err)
+			item := tc.item
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tc.machineName,
+						Namespace: namespace,
+						Labels:    map[string]string{machinev1.MachineClusterIDLabel: "CLUSTERID"},
+					},
+				},
+				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+					CredentialsSecret: &corev1.LocalObjectReference{
+						Name: "test",
+					},
+					Workspace: &vsphereapi.Workspace{
+						Server: server.URL.Host,
+					},
+					DiskGiB:            1,
+					ContentLibraryItem: &item,
+					CloneMode:          tc.cloneMode,
+					UserDataSecret: &corev1.LocalObjectReference{
+						Name: userDataSecretName,
+					},
+				},
+				session:        session,
+				providerStatus: &vsphereapi.VSphereMachineProviderStatus{},
+				client:         fake.NewFakeClientWithScheme(scheme.Scheme, &credentialsSecret, &userDataSecret),
 			}
-			if finished != tc.finished {
-				t.Errorf("Expected finished: %v, got: %v", tc.finished, finished)
+
+			taskRef, err := clone(machineScope)
+			if tc.expectedError != nil {
+				if err == nil || err.Error() != tc.expectedError.Error() {
+					t.Fatalf("Expected error %v, got: %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("clone() was not expected to return error: %v", err)
+			}
+			task := object.NewTask(session.Client.Client, types.ManagedObjectReference{Type: "Task", Value: taskRef})
+			if err := task.Wait(context.TODO()); err != nil {
+				t.Fatalf("Clone task failed: %v", err)
 			}
-		})
-	}
-}
 
-func TestGetNetworkDevices(t *testing.T) {
-	model, session, server := initSimulator(t)
-	defer model.Remove()
+			templates, err := session.Finder.VirtualMachineList(context.TODO(), templatePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(templates) != 1 {
+				t.Fatalf("Expected the item to be deployed once, got %d vms", len(templates))
+			}
+			var template mo.VirtualMachine
+			if err := templates[0].Properties(context.TODO(), templates[0].Reference(), []string{"snapshot"}, &template); err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectedSnapshot != (template.Snapshot != nil) {
+				t.Errorf("Expected the deployed item to have a snapshot: %v", tc.expectedSnapshot)
+			}
+
+			if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+				attached, err := tags.NewManager(c).GetAttachedTags(context.TODO(), templates[0].Reference())
+				if err != nil {
+					return err
+				}
+				if len(attached) != 1 || attached[0].Name != "CLUSTERID" {
+					t.Errorf("Expected the deployed item to be tagged with the cluster ID, got: %v", attached)
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{supersededName, "old-linked-clone"} {
+				if _, err := session.Finder.VirtualMachine(context.TODO(), path.Join(folder.InventoryPath, name)); err != nil {
+					t.Errorf("Expected vm %q to be kept, got: %v", name, err)
+				}
+			}
+
+			if _, err := session.Finder.VirtualMachine(context.TODO(), tc.machineName); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestGetPowerState(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	simulatorVM := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	ref := simulatorVM.VirtualMachine.Reference()
+
+	testCases := []struct {
+		testCase string
+		vm       func(t *testing.T) *virtualMachine
+		expected types.VirtualMachinePowerState
+	}{
+		{
+			testCase: "powered off",
+			vm: func(t *testing.T) *virtualMachine {
+				vm := &virtualMachine{
+					Context: context.TODO(),
+					Obj:     object.NewVirtualMachine(session.Client.Client, ref),
+					Ref:     ref,
+				}
+				_, err := vm.Obj.PowerOff(vm.Context)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return vm
+			},
+			expected: types.VirtualMachinePowerStatePoweredOff,
+		},
+		{
+			testCase: "powered on",
+			vm: func(t *testing.T) *virtualMachine {
+				vm := &virtualMachine{
+					Context: context.TODO(),
+					Obj:     object.NewVirtualMachine(session.Client.Client, ref),
+					Ref:     ref,
+				}
+				_, err := vm.Obj.PowerOn(vm.Context)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return vm
+			},
+
+			expected: types.VirtualMachinePowerStatePoweredOn,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			got, err := tc.vm(t).getPowerState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.expected {
+				t.Errorf("Got: %v, expected: %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTaskIsFinished(t *testing.T) {
+	testCases := []struct {
+		testCase    string
+		task        *vsphereapi.VSphereMachineTask
+		expectError bool
+		finished    bool
+	}{
+		{
+			testCase:    "nil task",
+			task:        nil,
+			expectError: false,
+			finished:    true,
+		},
+		{
+			testCase:    "task queued is not finished",
+			task:        &vsphereapi.VSphereMachineTask{State: vsphereapi.TaskQueued},
+			expectError: false,
+			finished:    false,
+		},
+		{
+			testCase:    "task succeeded is finished",
+			task:        &vsphereapi.VSphereMachineTask{State: vsphereapi.TaskSucceeded},
+			expectError: false,
+			finished:    true,
+		},
+		{
+			testCase:    "task error is finished",
+			task:        &vsphereapi.VSphereMachineTask{State: vsphereapi.TaskFailed, Error: "failed"},
+			expectError: true,
+			finished:    true,
+		},
+		{
+			testCase:    "task running is not finished",
+			task:        &vsphereapi.VSphereMachineTask{State: vsphereapi.TaskRunning},
+			expectError: false,
+			finished:    false,
+		},
+		{
+			testCase:    "task with unknown state errors",
+			task:        &vsphereapi.VSphereMachineTask{State: "unknown"},
+			expectError: true,
+			finished:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			finished, err := taskIsFinished(tc.task)
+			if (err != nil) != tc.expectError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectError, err)
+			}
+			if finished != tc.finished {
+				t.Errorf("Expected finished: %v, got: %v", tc.finished, finished)
+			}
+		})
+	}
+}
+
+func TestRefreshTask(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	obj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vm := object.NewVirtualMachine(session.Client.Client, obj.Reference())
+	powerOffTask, err := vm.PowerOff(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := powerOffTask.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	// Powering off a powered off vm fails
+	failedTask, err := vm.PowerOff(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := failedTask.Wait(context.TODO()); err == nil {
+		t.Fatal("Expected the second power off task to fail")
+	}
+
+	testCases := []struct {
+		testCase      string
+		task          vsphereapi.VSphereMachineTask
+		expectedState vsphereapi.VSphereMachineTaskState
+		expectError   bool
+	}{
+		{
+			testCase:      "queued task is refreshed",
+			task:          vsphereapi.VSphereMachineTask{TaskRef: powerOffTask.Reference().Value, State: vsphereapi.TaskQueued},
+			expectedState: vsphereapi.TaskSucceeded,
+		},
+		{
+			testCase:      "failed task records its error",
+			task:          vsphereapi.VSphereMachineTask{TaskRef: failedTask.Reference().Value, State: vsphereapi.TaskRunning},
+			expectedState: vsphereapi.TaskFailed,
+		},
+		{
+			testCase:      "finished task is not refreshed",
+			task:          vsphereapi.VSphereMachineTask{TaskRef: "unknown", State: vsphereapi.TaskSucceeded},
+			expectedState: vsphereapi.TaskSucceeded,
+		},
+		{
+			testCase:      "unknown task errors",
+			task:          vsphereapi.VSphereMachineTask{TaskRef: "unknown", State: vsphereapi.TaskQueued},
+			expectedState: vsphereapi.TaskQueued,
+			expectError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			r := &Reconciler{machineScope: &machineScope{Context: context.TODO(), session: session}}
+			task := tc.task
+			err := r.refreshTask(&task)
+			if (err != nil) != tc.expectError {
+				t.Errorf("Expected error: %v, got: %v", tc.expectError, err)
+			}
+			if task.State != tc.expectedState {
+				t.Errorf("Expected state: %v, got: %v", tc.expectedState, task.State)
+			}
+			if (task.State == vsphereapi.TaskFailed) != (task.Error != "") {
+				t.Errorf("Expected an error message only for a failed task, got: %q", task.Error)
+			}
+		})
+	}
+}
+
+func TestWaitForTask(t *testing.T) {
+	model, authSession, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	obj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vm := object.NewVirtualMachine(authSession.Client.Client, obj.Reference())
+	task, err := vm.PowerOff(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskRef := task.Reference().Value
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testCases := []struct {
+		testCase             string
+		taskWatcher          *session.TaskWatcher
+		expectedRequeueAfter time.Duration
+	}{
+		{
+			testCase:             "machine is requeued without a task watcher",
+			expectedRequeueAfter: requeueAfterSeconds * time.Second,
+		},
+		{
+			testCase:             "task is watched",
+			taskWatcher:          session.NewTaskWatcher(ctx),
+			expectedRequeueAfter: taskWatchRequeueAfter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			r := &Reconciler{machineScope: &machineScope{
+				Context:     context.TODO(),
+				session:     authSession,
+				taskWatcher: tc.taskWatcher,
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+				},
+			}}
+
+			err := r.waitForTask(&vsphereapi.VSphereMachineTask{TaskRef: taskRef, State: vsphereapi.TaskRunning})
+			var requeueAfterError *machinecontroller.RequeueAfterError
+			if !errors.As(err, &requeueAfterError) {
+				t.Fatalf("Expected a requeue after error, got: %v", err)
+			}
+			if requeueAfterError.RequeueAfter != tc.expectedRequeueAfter {
+				t.Errorf("Expected requeue after %v, got: %v", tc.expectedRequeueAfter, requeueAfterError.RequeueAfter)
+			}
+
+			if tc.taskWatcher == nil {
+				return
+			}
+			src := tc.taskWatcher.Source()
+			if _, err := inject.StopChannelInto(ctx.Done(), src); err != nil {
+				t.Fatal(err)
+			}
+			evt := make(chan event.GenericEvent, 1)
+			err = src.Start(ctx, handler.Funcs{
+				GenericFunc: func(e event.GenericEvent, _ workqueue.RateLimitingInterface) {
+					evt <- e
+				},
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case e := <-evt:
+				if e.Object.GetNamespace() != "test" || e.Object.GetName() != "test" {
+					t.Errorf("Expected an event for machine test/test, got: %v/%v", e.Object.GetNamespace(), e.Object.GetName())
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("Timed out waiting for the task event")
+			}
+		})
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	status := &vsphereapi.VSphereMachineProviderStatus{
+		TaskRef: "task-1",
+	}
+
+	createTask := getTask(status, vsphereapi.CreateTaskOperation)
+	if createTask == nil || createTask.TaskRef != "task-1" {
+		t.Fatalf("Expected the deprecated task reference to be the create task, got: %+v", createTask)
+	}
+	if status.TaskRef != "" {
+		t.Errorf("Expected the deprecated task reference to be cleared, got: %q", status.TaskRef)
+	}
+	if deleteTask := getTask(status, vsphereapi.DeleteTaskOperation); deleteTask != nil {
+		t.Errorf("Expected no delete task, got: %+v", deleteTask)
+	}
+
+	setTask(status, vsphereapi.DeleteTaskOperation, "task-2")
+	setTask(status, vsphereapi.CreateTaskOperation, "task-3")
+
+	expectedTasks := []vsphereapi.VSphereMachineTask{
+		{Operation: vsphereapi.CreateTaskOperation, TaskRef: "task-3", State: vsphereapi.TaskQueued},
+		{Operation: vsphereapi.DeleteTaskOperation, TaskRef: "task-2", State: vsphereapi.TaskQueued},
+	}
+	if !reflect.DeepEqual(status.Tasks, expectedTasks) {
+		t.Errorf("Expected tasks %+v, got %+v", expectedTasks, status.Tasks)
+	}
+}
+
+func TestGetNetworkDevices(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
 	defer server.Close()
 
 	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
@@ -670,107 +1214,458 @@ func TestGetNetworkDevices(t *testing.T) {
 				providerSpec: tc.providerSpec,
 				session:      session,
 			}
-			networkDevices, err := getNetworkDevices(machineScope, devices)
+			networkDevices, err := getNetworkDevices(machineScope, devices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.expected(networkDevices) {
+				t.Errorf("Got unexpected networkDevices len (%v) or operations (%v)",
+					len(networkDevices),
+					printOperations(networkDevices))
+			}
+		})
+	}
+}
+
+func TestGetDiskSpec(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	objVM := object.NewVirtualMachine(session.Client.Client, managedObj.Reference())
+
+	testCases := []struct {
+		name                 string
+		expectedError        error
+		devices              func() object.VirtualDeviceList
+		diskSize             int32
+		expectedCapacityInKB int64
+	}{
+		{
+			name: "Succefully get disk spec with disk size 1",
+			devices: func() object.VirtualDeviceList {
+				devices, err := objVM.Device(context.TODO())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return devices
+			},
+			diskSize:             1,
+			expectedCapacityInKB: 1048576,
+		},
+		{
+			name: "Succefully get disk spec with disk size 3",
+			devices: func() object.VirtualDeviceList {
+				devices, err := objVM.Device(context.TODO())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return devices
+			},
+			diskSize:             3,
+			expectedCapacityInKB: 3145728,
+		},
+		{
+			name: "Fail on invalid disk count",
+			devices: func() object.VirtualDeviceList {
+				devices, err := objVM.Device(context.TODO())
+				if err != nil {
+					t.Fatal(err)
+				}
+				devices = append(devices, &types.VirtualDisk{})
+				return devices
+			},
+			expectedError:        errors.New("invalid disk count: 2"),
+			diskSize:             1,
+			expectedCapacityInKB: 1048576,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+					DiskGiB: tc.diskSize,
+				},
+				session: session,
+			}
+			diskSpec, err := getDiskSpec(machineScope, tc.devices())
+
+			if tc.expectedError == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				virtualDeviceConfigSpec := diskSpec.(*types.VirtualDeviceConfigSpec)
+				disk := virtualDeviceConfigSpec.Device.(*types.VirtualDisk)
+
+				if disk.CapacityInKB != tc.expectedCapacityInKB {
+					t.Fatalf("Expected disk capacity to be %v, got %v", disk.CapacityInKB, tc.expectedCapacityInKB)
+				}
+
+				if diskSpec.GetVirtualDeviceConfigSpec().Operation != types.VirtualDeviceConfigSpecOperationEdit {
+					t.Fatalf("Expected operation type to be %s, got %v", types.VirtualDeviceConfigSpecOperationEdit, diskSpec.GetVirtualDeviceConfigSpec().Operation)
+				}
+			} else {
+				if err == nil {
+					t.Fatal("getDiskSpec was expected to return an error")
+				}
+				if tc.expectedError.Error() != err.Error() {
+					t.Fatalf("Expected error %v , got %v", tc.expectedError, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDatastore(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vmTemplate := object.NewVirtualMachine(session.Client.Client, managedObj.Reference())
+	datastoreRef := simulator.Map.Any("Datastore").Reference()
+
+	folder, err := session.Finder.DefaultFolder(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourcepool, err := session.Finder.DefaultResourcePool(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policyID, err := session.GetStoragePolicyID(context.TODO(), "vSAN Default Storage Policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policyID == "" {
+		t.Fatal("Expected the simulator to have the vSAN default storage policy")
+	}
+
+	testCases := []struct {
+		name            string
+		workspace       *vsphereapi.Workspace
+		expectedProfile string
+		expectedError   string
+		invalidConfig   bool
+		setup           func(t *testing.T)
+	}{
+		{
+			name: "default datastore",
+		},
+		{
+			name:      "datastore",
+			workspace: &vsphereapi.Workspace{Datastore: "LocalDS_0"},
+		},
+		{
+			name:            "storage policy",
+			workspace:       &vsphereapi.Workspace{StoragePolicyName: "vSAN Default Storage Policy"},
+			expectedProfile: policyID,
+		},
+		{
+			name:            "datastore and storage policy",
+			workspace:       &vsphereapi.Workspace{Datastore: "LocalDS_0", StoragePolicyName: "vSAN Default Storage Policy"},
+			expectedProfile: policyID,
+		},
+		{
+			name:      "datastore cluster",
+			workspace: &vsphereapi.Workspace{DatastoreCluster: "datastore-cluster"},
+			setup: func(t *testing.T) {
+				// Move the datastore into a datastore cluster with Storage DRS enabled.
+				folders, err := session.Datacenter.Folders(context.TODO())
+				if err != nil {
+					t.Fatal(err)
+				}
+				pod, err := folders.DatastoreFolder.CreateStoragePod(context.TODO(), "datastore-cluster")
+				if err != nil {
+					t.Fatal(err)
+				}
+				task, err := pod.MoveInto(context.TODO(), []types.ManagedObjectReference{datastoreRef})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := task.Wait(context.TODO()); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name:            "datastore cluster and storage policy",
+			workspace:       &vsphereapi.Workspace{DatastoreCluster: "datastore-cluster", StoragePolicyName: "vSAN Default Storage Policy"},
+			expectedProfile: policyID,
+		},
+		{
+			name:          "unknown storage policy",
+			workspace:     &vsphereapi.Workspace{StoragePolicyName: "unknown"},
+			expectedError: "storage policy \"unknown\" not found, specify valid value",
+			invalidConfig: true,
+		},
+		{
+			name:          "unknown datastore cluster",
+			workspace:     &vsphereapi.Workspace{DatastoreCluster: "unknown"},
+			expectedError: "datastore cluster not found, specify valid value",
+			invalidConfig: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setup != nil {
+				tc.setup(t)
+			}
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "machine",
+					},
+				},
+				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+					Workspace: tc.workspace,
+				},
+				session: session,
+			}
+
+			datastore, profile, err := getDatastore(machineScope, vmTemplate, folder, resourcepool)
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatal("getDatastore was expected to return an error")
+				}
+				if err.Error() != tc.expectedError {
+					t.Fatalf("Expected error %q, got %q", tc.expectedError, err.Error())
+				}
+				var machineErr *machinecontroller.MachineError
+				if tc.invalidConfig && (!errors.As(err, &machineErr) || machineErr.Reason != machinev1.InvalidConfigurationMachineError) {
+					t.Fatalf("Expected an invalid configuration error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if datastore.Reference() != datastoreRef {
+				t.Errorf("Expected datastore %v, got %v", datastoreRef, datastore.Reference())
+			}
+
+			var profileID string
+			if len(profile) > 0 {
+				profileID = profile[0].(*types.VirtualMachineDefinedProfileSpec).ProfileId
+			}
+			if profileID != tc.expectedProfile {
+				t.Errorf("Expected storage profile %q, got %q", tc.expectedProfile, profileID)
+			}
+		})
+	}
+}
+
+func TestGetDataDiskSpecs(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	objVM := object.NewVirtualMachine(session.Client.Client, managedObj.Reference())
+	devices, err := objVM.Device(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The simulator template has a single disk on unit 0 of the SCSI controller on bus 0.
+	controllers := devices.SelectByType((*types.VirtualSCSIController)(nil))
+	if len(controllers) != 1 {
+		t.Fatalf("Expected the template to have 1 SCSI controller, got %d", len(controllers))
+	}
+	controllerKey := controllers[0].GetVirtualDevice().Key
+
+	testCases := []struct {
+		name             string
+		dataDisks        []vsphereapi.VSphereDisk
+		expectedStatuses []vsphereapi.VSphereDiskStatus
+		expectedError    error
+	}{
+		{
+			name: "No data disks",
+		},
+		{
+			name: "Assign the first free units of the template disk controller",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "containers", SizeGiB: 100},
+				{Name: "logs", SizeGiB: 10},
+			},
+			expectedStatuses: []vsphereapi.VSphereDiskStatus{
+				{Name: "containers", ControllerBusNumber: 0, UnitNumber: 1},
+				{Name: "logs", ControllerBusNumber: 0, UnitNumber: 2},
+			},
+		},
+		{
+			name: "Skip units requested by previous disks and the controller unit",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "containers", SizeGiB: 100, UnitNumber: pointer.Int32Ptr(1)},
+				{Name: "logs", SizeGiB: 10, ControllerBusNumber: pointer.Int32Ptr(0), UnitNumber: pointer.Int32Ptr(8)},
+			},
+			expectedStatuses: []vsphereapi.VSphereDiskStatus{
+				{Name: "containers", ControllerBusNumber: 0, UnitNumber: 1},
+				{Name: "logs", ControllerBusNumber: 0, UnitNumber: 8},
+			},
+		},
+		{
+			name: "Fail on unit used by the template",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "logs", SizeGiB: 10, UnitNumber: pointer.Int32Ptr(0)},
+			},
+			expectedError: errors.New("data disk \"logs\": unit number 0 is already in use"),
+		},
+		{
+			name: "Fail on unit used by the SCSI controller",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "logs", SizeGiB: 10, UnitNumber: pointer.Int32Ptr(7)},
+			},
+			expectedError: errors.New("data disk \"logs\": unit number 7 is already in use"),
+		},
+		{
+			name: "Fail on unknown controller",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "logs", SizeGiB: 10, ControllerBusNumber: pointer.Int32Ptr(1)},
+			},
+			expectedError: errors.New("data disk \"logs\": template has no SCSI controller with bus number 1"),
+		},
+		{
+			name: "Fail on unknown datastore",
+			dataDisks: []vsphereapi.VSphereDisk{
+				{Name: "logs", SizeGiB: 10, Datastore: "invalid"},
+			},
+			expectedError: errors.New("datastore of data disk not found, specify valid value"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				},
+				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+					DataDisks: tc.dataDisks,
+				},
+				session: session,
+			}
+			diskSpecs, diskStatuses, err := getDataDiskSpecs(machineScope, devices)
+
+			if tc.expectedError != nil {
+				if err == nil {
+					t.Fatal("getDataDiskSpecs was expected to return an error")
+				}
+				if tc.expectedError.Error() != err.Error() {
+					t.Fatalf("Expected error %v , got %v", tc.expectedError, err)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatal(err)
 			}
-			if !tc.expected(networkDevices) {
-				t.Errorf("Got unexpected networkDevices len (%v) or operations (%v)",
-					len(networkDevices),
-					printOperations(networkDevices))
+
+			if !reflect.DeepEqual(diskStatuses, tc.expectedStatuses) {
+				t.Fatalf("Expected disk statuses %+v, got %+v", tc.expectedStatuses, diskStatuses)
+			}
+			if len(diskSpecs) != len(tc.dataDisks) {
+				t.Fatalf("Expected %d disk specs, got %d", len(tc.dataDisks), len(diskSpecs))
+			}
+			for i, diskSpec := range diskSpecs {
+				spec := diskSpec.GetVirtualDeviceConfigSpec()
+				if spec.Operation != types.VirtualDeviceConfigSpecOperationAdd {
+					t.Errorf("Expected operation type to be %s, got %v", types.VirtualDeviceConfigSpecOperationAdd, spec.Operation)
+				}
+				if spec.FileOperation != types.VirtualDeviceConfigSpecFileOperationCreate {
+					t.Errorf("Expected file operation type to be %s, got %v", types.VirtualDeviceConfigSpecFileOperationCreate, spec.FileOperation)
+				}
+				disk := spec.Device.(*types.VirtualDisk)
+				if disk.ControllerKey != controllerKey {
+					t.Errorf("Expected disk on controller %d, got %d", controllerKey, disk.ControllerKey)
+				}
+				if *disk.UnitNumber != tc.expectedStatuses[i].UnitNumber {
+					t.Errorf("Expected disk on unit %d, got %d", tc.expectedStatuses[i].UnitNumber, *disk.UnitNumber)
+				}
+				if expected := int64(tc.dataDisks[i].SizeGiB) * 1024 * 1024; disk.CapacityInKB != expected {
+					t.Errorf("Expected disk capacity to be %v, got %v", expected, disk.CapacityInKB)
+				}
 			}
 		})
 	}
 }
 
-func TestGetDiskSpec(t *testing.T) {
+func TestGetDataDiskSpecsProvisioningType(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
 	defer server.Close()
 
 	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
 	objVM := object.NewVirtualMachine(session.Client.Client, managedObj.Reference())
+	devices, err := objVM.Device(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	testCases := []struct {
-		name                 string
-		expectedError        error
-		devices              func() object.VirtualDeviceList
-		diskSize             int32
-		expectedCapacityInKB int64
+		provisioningType     vsphereapi.ProvisioningType
+		expectedThin         bool
+		expectedEagerlyScrub bool
 	}{
 		{
-			name: "Succefully get disk spec with disk size 1",
-			devices: func() object.VirtualDeviceList {
-				devices, err := objVM.Device(context.TODO())
-				if err != nil {
-					t.Fatal(err)
-				}
-				return devices
-			},
-			diskSize:             1,
-			expectedCapacityInKB: 1048576,
+			provisioningType: "",
+			expectedThin:     true,
 		},
 		{
-			name: "Succefully get disk spec with disk size 3",
-			devices: func() object.VirtualDeviceList {
-				devices, err := objVM.Device(context.TODO())
-				if err != nil {
-					t.Fatal(err)
-				}
-				return devices
-			},
-			diskSize:             3,
-			expectedCapacityInKB: 3145728,
+			provisioningType: vsphereapi.ThinProvisioned,
+			expectedThin:     true,
 		},
 		{
-			name: "Fail on invalid disk count",
-			devices: func() object.VirtualDeviceList {
-				devices, err := objVM.Device(context.TODO())
-				if err != nil {
-					t.Fatal(err)
-				}
-				devices = append(devices, &types.VirtualDisk{})
-				return devices
-			},
-			expectedError:        errors.New("invalid disk count: 2"),
-			diskSize:             1,
-			expectedCapacityInKB: 1048576,
+			provisioningType: vsphereapi.ThickProvisioned,
+		},
+		{
+			provisioningType:     vsphereapi.EagerlyZeroedProvisioned,
+			expectedEagerlyScrub: true,
 		},
 	}
 
 	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
+		t.Run(string(tc.provisioningType), func(t *testing.T) {
 			machineScope := &machineScope{
 				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				},
 				providerSpec: &vsphereapi.VSphereMachineProviderSpec{
-					DiskGiB: tc.diskSize,
+					DataDisks: []vsphereapi.VSphereDisk{
+						{Name: "logs", SizeGiB: 10, Datastore: "LocalDS_0", ProvisioningType: tc.provisioningType},
+					},
 				},
 				session: session,
 			}
-			diskSpec, err := getDiskSpec(machineScope, tc.devices())
-
-			if tc.expectedError == nil {
-				if err != nil {
-					t.Fatal(err)
-				}
-
-				virtualDeviceConfigSpec := diskSpec.(*types.VirtualDeviceConfigSpec)
-				disk := virtualDeviceConfigSpec.Device.(*types.VirtualDisk)
-
-				if disk.CapacityInKB != tc.expectedCapacityInKB {
-					t.Fatalf("Expected disk capacity to be %v, got %v", disk.CapacityInKB, tc.expectedCapacityInKB)
-				}
+			diskSpecs, _, err := getDataDiskSpecs(machineScope, devices)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-				if diskSpec.GetVirtualDeviceConfigSpec().Operation != types.VirtualDeviceConfigSpecOperationEdit {
-					t.Fatalf("Expected operation type to be %s, got %v", types.VirtualDeviceConfigSpecOperationEdit, diskSpec.GetVirtualDeviceConfigSpec().Operation)
-				}
-			} else {
-				if err == nil {
-					t.Fatal("getDiskSpec was expected to return an error")
-				}
-				if tc.expectedError.Error() != err.Error() {
-					t.Fatalf("Expected error %v , got %v", tc.expectedError, err)
-				}
+			disk := diskSpecs[0].GetVirtualDeviceConfigSpec().Device.(*types.VirtualDisk)
+			backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if *backing.ThinProvisioned != tc.expectedThin {
+				t.Errorf("Expected thin provisioning to be %v, got %v", tc.expectedThin, *backing.ThinProvisioned)
+			}
+			if eagerlyScrub := backing.EagerlyScrub != nil && *backing.EagerlyScrub; eagerlyScrub != tc.expectedEagerlyScrub {
+				t.Errorf("Expected eagerly scrub to be %v, got %v", tc.expectedEagerlyScrub, eagerlyScrub)
+			}
+			if backing.FileName != "[LocalDS_0]" {
+				t.Errorf("Expected disk to be created in [LocalDS_0], got %q", backing.FileName)
 			}
 		})
 	}
@@ -824,6 +1719,69 @@ func TestGetNetworkStatusList(t *testing.T) {
 	// TODO: add more cases by adding network devices to the NewVirtualMachine() object
 }
 
+func TestReconcileNetworkStaticIP(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	managedObj.Guest.Net[0].IpAddress = nil
+	managedObjRef := object.NewVirtualMachine(session.Client.Client, managedObj.Reference()).Reference()
+
+	vm := &virtualMachine{
+		Context: context.TODO(),
+		Obj:     object.NewVirtualMachine(session.Client.Client, managedObjRef),
+		Ref:     managedObjRef,
+	}
+
+	vmName, err := vm.Obj.ObjectName(vm.Context)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The static addresses are reported until the guest reports its own
+	expectedAddresses := []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "192.168.1.10",
+		},
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "fd00::10",
+		},
+		{
+			Type:    corev1.NodeInternalDNS,
+			Address: vmName,
+		},
+	}
+	r := &Reconciler{
+		machineScope: &machineScope{
+			Context: context.TODO(),
+			session: session,
+			machine: &machinev1.Machine{
+				Status: machinev1.MachineStatus{},
+			},
+			providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+				Network: vsphereapi.NetworkSpec{
+					Devices: []vsphereapi.NetworkDeviceSpec{
+						{
+							NetworkName: "dummy",
+							IPAddrs:     []string{"192.168.1.10/24", "fd00::10/64"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := r.reconcileNetwork(vm); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expectedAddresses, r.machineScope.machine.Status.Addresses) {
+		t.Errorf("Expected: %v, got: %v", expectedAddresses, r.machineScope.machine.Status.Addresses)
+	}
+}
+
 func TestReconcileNetwork(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -936,7 +1894,7 @@ func TestReconcileTags(t *testing.T) {
 					Name:   "machine",
 					Labels: map[string]string{machinev1.MachineClusterIDLabel: tagName},
 				},
-			})
+			}, nil, nil)
 
 			if tc.expectedError {
 				if err == nil {
@@ -971,6 +1929,111 @@ func TestReconcileTags(t *testing.T) {
 	}
 }
 
+func TestReconcileUserTags(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	managedObjRef := object.NewVirtualMachine(session.Client.Client, managedObj.Reference()).Reference()
+
+	vm := &virtualMachine{
+		Context: context.TODO(),
+		Obj:     object.NewVirtualMachine(session.Client.Client, managedObjRef),
+		Ref:     managedObjRef,
+	}
+
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine",
+		},
+	}
+
+	// an existing category and tag are reused
+	if err := createTagAndCategory(session, "backup", "daily"); err != nil {
+		t.Fatal(err)
+	}
+
+	attachedTags := func() []string {
+		var names []string
+		if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+			m := tags.NewManager(c)
+			attached, err := m.GetAttachedTags(context.TODO(), managedObjRef)
+			if err != nil {
+				return err
+			}
+			for _, tag := range attached {
+				category, err := m.GetCategory(context.TODO(), tag.CategoryID)
+				if err != nil {
+					return err
+				}
+				names = append(names, category.Name+"/"+tag.Name)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	testCases := []struct {
+		name         string
+		userTags     []vsphereapi.VSphereTag
+		previousTags []vsphereapi.VSphereTag
+		expectedTags []string
+	}{
+		{
+			name: "Attach new and existing tags",
+			userTags: []vsphereapi.VSphereTag{
+				{Category: "backup", Name: "daily"},
+				{Category: "chargeback", Name: "team-a"},
+				{Category: "chargeback", Name: "project-b"},
+			},
+			expectedTags: []string{"backup/daily", "chargeback/project-b", "chargeback/team-a"},
+		},
+		{
+			name: "Reconciling again is a no-op",
+			userTags: []vsphereapi.VSphereTag{
+				{Category: "backup", Name: "daily"},
+				{Category: "chargeback", Name: "team-a"},
+				{Category: "chargeback", Name: "project-b"},
+			},
+			previousTags: []vsphereapi.VSphereTag{
+				{Category: "backup", Name: "daily"},
+				{Category: "chargeback", Name: "team-a"},
+				{Category: "chargeback", Name: "project-b"},
+			},
+			expectedTags: []string{"backup/daily", "chargeback/project-b", "chargeback/team-a"},
+		},
+		{
+			name: "Detach removed tags",
+			userTags: []vsphereapi.VSphereTag{
+				{Category: "chargeback", Name: "team-a"},
+			},
+			previousTags: []vsphereapi.VSphereTag{
+				{Category: "backup", Name: "daily"},
+				{Category: "chargeback", Name: "team-a"},
+				{Category: "chargeback", Name: "project-b"},
+				{Category: "deleted", Name: "tag"},
+			},
+			expectedTags: []string{"chargeback/team-a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := vm.reconcileTags(context.TODO(), session, machine, tc.userTags, tc.previousTags); err != nil {
+				t.Fatalf("Not expected error %v", err)
+			}
+
+			if names := attachedTags(); !reflect.DeepEqual(names, tc.expectedTags) {
+				t.Errorf("Expected tags %v attached, got %v", tc.expectedTags, names)
+			}
+		})
+	}
+}
+
 func TestCheckAttachedTag(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -1068,6 +2131,79 @@ func TestCheckAttachedTag(t *testing.T) {
 	}
 }
 
+func TestDetachTags(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	managedObjRef := object.NewVirtualMachine(session.Client.Client, managedObj.Reference()).Reference()
+
+	vm := &virtualMachine{
+		Context: context.TODO(),
+		Obj:     object.NewVirtualMachine(session.Client.Client, managedObjRef),
+		Ref:     managedObjRef,
+	}
+
+	tagName := "CLUSTERID"
+	otherTagName := "other"
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "machine",
+			Labels: map[string]string{machinev1.MachineClusterIDLabel: tagName},
+		},
+	}
+
+	if err := createTagAndCategory(session, "CLUSTERID_CATEGORY", tagName); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTagAndCategory(session, "OTHER_CATEGORY", otherTagName); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.reconcileTags(context.TODO(), session, machine, nil, nil); err != nil {
+		t.Fatalf("Not expected error %v", err)
+	}
+	if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+		return tags.NewManager(c).AttachTag(context.TODO(), otherTagName, vm.Ref)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	attachedTagNames := func() []string {
+		var names []string
+		if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+			attached, err := tags.NewManager(c).GetAttachedTags(context.TODO(), managedObjRef)
+			if err != nil {
+				return err
+			}
+			for _, tag := range attached {
+				names = append(names, tag.Name)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if names := attachedTagNames(); !reflect.DeepEqual(names, []string{tagName, otherTagName}) {
+		t.Fatalf("Expected tags %v attached, got %v", []string{tagName, otherTagName}, names)
+	}
+
+	// detaching twice is a no-op
+	for i := 0; i < 2; i++ {
+		if err := vm.detachTags(context.TODO(), session, machine); err != nil {
+			t.Fatalf("Not expected error %v", err)
+		}
+	}
+
+	// only the cluster tag is detached
+	if names := attachedTagNames(); !reflect.DeepEqual(names, []string{otherTagName}) {
+		t.Errorf("Expected tags %v attached, got %v", []string{otherTagName}, names)
+	}
+}
+
 func TestIgnitionConfig(t *testing.T) {
 	optionsForData := func(data []byte) []types.BaseOptionValue {
 		return []types.BaseOptionValue{
@@ -1129,6 +2265,210 @@ func TestIgnitionConfig(t *testing.T) {
 	}
 }
 
+func TestNetworkConfig(t *testing.T) {
+	testCases := []struct {
+		testCase      string
+		network       vsphereapi.NetworkSpec
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			testCase: "DHCP",
+			network: vsphereapi.NetworkSpec{
+				Devices: []vsphereapi.NetworkDeviceSpec{
+					{NetworkName: "network"},
+				},
+			},
+			expected: map[string]string{},
+		},
+		{
+			testCase: "static IPv4",
+			network: vsphereapi.NetworkSpec{
+				Devices: []vsphereapi.NetworkDeviceSpec{
+					{
+						NetworkName:   "network",
+						IPAddrs:       []string{"192.168.1.10/24"},
+						Gateway4:      "192.168.1.1",
+						Nameservers:   []string{"192.168.1.2", "192.168.1.3"},
+						SearchDomains: []string{"example.com"},
+					},
+				},
+			},
+			expected: map[string]string{
+				GuestInfoNetworkKargs:                     "ip=192.168.1.10::192.168.1.1:255.255.255.0:::none nameserver=192.168.1.2 nameserver=192.168.1.3",
+				"guestinfo.network.device0.ipaddrs":       "192.168.1.10/24",
+				"guestinfo.network.device0.gateway4":      "192.168.1.1",
+				"guestinfo.network.device0.nameservers":   "192.168.1.2,192.168.1.3",
+				"guestinfo.network.device0.searchdomains": "example.com",
+			},
+		},
+		{
+			testCase: "dual stack on a second device",
+			network: vsphereapi.NetworkSpec{
+				Devices: []vsphereapi.NetworkDeviceSpec{
+					{NetworkName: "dhcp", Nameservers: []string{"fd00::2"}},
+					{
+						NetworkName: "network",
+						IPAddrs:     []string{"192.168.1.10/24", "fd00::10/64"},
+						Gateway6:    "fd00::1",
+						Nameservers: []string{"fd00::2"},
+					},
+				},
+			},
+			expected: map[string]string{
+				GuestInfoNetworkKargs:                   "nameserver=fd00::2 ip=192.168.1.10:::255.255.255.0:::none ip=[fd00::10]::[fd00::1]:64:::none",
+				"guestinfo.network.device0.nameservers": "fd00::2",
+				"guestinfo.network.device1.ipaddrs":     "192.168.1.10/24,fd00::10/64",
+				"guestinfo.network.device1.gateway6":    "fd00::1",
+				"guestinfo.network.device1.nameservers": "fd00::2",
+			},
+		},
+		{
+			testCase: "invalid address",
+			network: vsphereapi.NetworkSpec{
+				Devices: []vsphereapi.NetworkDeviceSpec{
+					{NetworkName: "network", IPAddrs: []string{"192.168.1.10"}},
+				},
+			},
+			expectedError: "device 0: invalid CIDR address: 192.168.1.10",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			options, err := NetworkConfig(tc.network)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Fatalf("Expected error %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for _, option := range options {
+				value := option.GetOptionValue()
+				got[value.Key] = value.Value.(string)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolveIPAddressPools(t *testing.T) {
+	namespace := "test"
+	pool := &machinev1.IPAddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pool",
+			Namespace: namespace,
+		},
+		Spec: machinev1.IPAddressPoolSpec{
+			Addresses:   []string{"192.168.1.10-192.168.1.20"},
+			Prefix:      24,
+			Gateway:     "192.168.1.1",
+			Nameservers: []string{"192.168.1.2"},
+		},
+	}
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine",
+			Namespace: namespace,
+			UID:       "machine-uid",
+		},
+	}
+
+	machinev1.AddToScheme(scheme.Scheme)
+	testCases := []struct {
+		testCase        string
+		poolName        string
+		allocate        bool
+		expectedDevice  vsphereapi.NetworkDeviceSpec
+		expectedInvalid bool
+	}{
+		{
+			testCase: "no allocation yet",
+			poolName: "pool",
+			expectedDevice: vsphereapi.NetworkDeviceSpec{
+				NetworkName:   "network",
+				IPAddressPool: &corev1.LocalObjectReference{Name: "pool"},
+			},
+		},
+		{
+			testCase: "allocate an address",
+			poolName: "pool",
+			allocate: true,
+			expectedDevice: vsphereapi.NetworkDeviceSpec{
+				NetworkName:   "network",
+				IPAddressPool: &corev1.LocalObjectReference{Name: "pool"},
+				IPAddrs:       []string{"192.168.1.10/24"},
+				Gateway4:      "192.168.1.1",
+				Nameservers:   []string{"192.168.1.2"},
+			},
+		},
+		{
+			testCase:        "missing pool",
+			poolName:        "missing",
+			allocate:        true,
+			expectedInvalid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			client := fake.NewFakeClientWithScheme(scheme.Scheme, pool)
+			newReconcilerFor := func() *Reconciler {
+				return &Reconciler{
+					machineScope: &machineScope{
+						Context:   context.TODO(),
+						client:    client,
+						apiReader: client,
+						machine:   machine,
+						providerSpec: &vsphereapi.VSphereMachineProviderSpec{
+							Network: vsphereapi.NetworkSpec{
+								Devices: []vsphereapi.NetworkDeviceSpec{
+									{
+										NetworkName:   "network",
+										IPAddressPool: &corev1.LocalObjectReference{Name: tc.poolName},
+									},
+								},
+							},
+						},
+					},
+				}
+			}
+
+			r := newReconcilerFor()
+			err := r.resolveIPAddressPools(tc.allocate)
+			if tc.expectedInvalid {
+				var machineErr *machinecontroller.MachineError
+				if !errors.As(err, &machineErr) || machineErr.Reason != machinev1.InvalidConfigurationMachineError {
+					t.Fatalf("Expected an invalid configuration error, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(r.providerSpec.Network.Devices[0], tc.expectedDevice) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedDevice, r.providerSpec.Network.Devices[0])
+			}
+
+			// The allocation is found again by a later reconcile
+			r = newReconcilerFor()
+			if err := r.resolveIPAddressPools(false); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(r.providerSpec.Network.Devices[0], tc.expectedDevice) {
+				t.Errorf("Expected: %v, got: %v", tc.expectedDevice, r.providerSpec.Network.Devices[0])
+			}
+		})
+	}
+}
+
 func TestReconcileProviderID(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -1310,11 +2650,16 @@ func TestDelete(t *testing.T) {
 				t.Errorf("expected error on the first call to delete")
 			}
 
-			moTask, err := reconciler.session.GetTask(reconciler.Context, reconciler.providerStatus.TaskRef)
+			deleteTask := getTask(reconciler.providerStatus, vsphereapi.DeleteTaskOperation)
+			if deleteTask == nil {
+				t.Fatal("expected the destroy task to be recorded as the delete task")
+			}
+			if getTask(reconciler.providerStatus, vsphereapi.CreateTaskOperation) != nil {
+				t.Errorf("expected no create task, got: %+v", reconciler.providerStatus.Tasks)
+			}
+			moTask, err := reconciler.session.GetTask(reconciler.Context, deleteTask.TaskRef)
 			if err != nil {
-				if !isRetrieveMONotFound(reconciler.providerStatus.TaskRef, err) {
-					t.Fatal(err)
-				}
+				t.Fatal(err)
 			}
 			if moTask.Info.DescriptionId != "VirtualMachine.destroy" {
 				t.Errorf("task description expected: VirtualMachine.destroy, got: %v", moTask.Info.DescriptionId)
@@ -1743,7 +3088,9 @@ func TestExists(t *testing.T) {
 				},
 				session: session,
 				providerStatus: &vsphereapi.VSphereMachineProviderStatus{
-					TaskRef: task.Reference().Value,
+					Tasks: []vsphereapi.VSphereMachineTask{
+						{Operation: vsphereapi.CreateTaskOperation, TaskRef: task.Reference().Value},
+					},
 				},
 				client: fake.NewFakeClientWithScheme(scheme.Scheme, &credentialsSecret),
 			}
@@ -1766,6 +3113,84 @@ func TestExists(t *testing.T) {
 	}
 }
 
+func TestExistsAdoptedVM(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vmObj := object.NewVirtualMachine(session.Client.Client, vm.Reference())
+	providerID, err := convertUUIDToProviderID(vmObj.UUID(context.TODO()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		providerID    string
+		exists        bool
+		expectedError bool
+	}{
+		{
+			name:       "VM referenced by the providerID exists",
+			providerID: providerID,
+			exists:     true,
+		},
+		{
+			name:       "VM referenced by the providerID doesn't exist",
+			providerID: providerIDPrefix + "a5764857-ae35-34dc-8f25-a9c9e73aa898",
+			exists:     false,
+		},
+		{
+			name:          "providerID is not a vSphere providerID",
+			providerID:    "aws:///us-east-1a/i-0123456789",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						// the machine name and UID do not match the adopted VM
+						Name:      "test",
+						Namespace: "test",
+						UID:       apimachinerytypes.UID("a5764857-ae35-34dc-8f25-a9c9e73aa899"),
+						Labels: map[string]string{
+							machinev1.MachineClusterIDLabel: "CLUSTERID",
+						},
+						Annotations: map[string]string{
+							machinecontroller.AdoptInstanceAnnotation: "",
+						},
+					},
+					Spec: machinev1.MachineSpec{
+						ProviderID: &tc.providerID,
+					},
+				},
+				providerSpec:   &vsphereapi.VSphereMachineProviderSpec{},
+				session:        session,
+				providerStatus: &vsphereapi.VSphereMachineProviderStatus{},
+			}
+
+			exists, err := newReconciler(&machineScope).exists()
+			if tc.expectedError {
+				if err == nil {
+					t.Fatal("reconciler was expected to return error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reconciler was not expected to return error: %v", err)
+			}
+			if tc.exists != exists {
+				t.Fatalf("Expected: %v, got %v", tc.exists, exists)
+			}
+		})
+	}
+}
+
 func TestReconcileMachineWithCloudState(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -1825,7 +3250,9 @@ func TestReconcileMachineWithCloudState(t *testing.T) {
 		},
 		session: session,
 		providerStatus: &vsphereapi.VSphereMachineProviderStatus{
-			TaskRef: task.Reference().Value,
+			Tasks: []vsphereapi.VSphereMachineTask{
+				{Operation: vsphereapi.CreateTaskOperation, TaskRef: task.Reference().Value},
+			},
 		},
 		vSphereConfig: &vSphereConfig{
 			Labels: Labels{
@@ -1842,7 +3269,7 @@ func TestReconcileMachineWithCloudState(t *testing.T) {
 	}
 
 	reconciler := newReconciler(&machineScope)
-	if err := reconciler.reconcileMachineWithCloudState(vmWrapper, task.Reference().Value); err != nil {
+	if err := reconciler.reconcileMachineWithCloudState(vmWrapper); err != nil {
 		t.Fatalf("reconciler was not expected to return error: %v", err)
 	}
 
@@ -1903,6 +3330,273 @@ func createTagAndCategory(session *session.Session, categoryName, tagName string
 	return nil
 }
 
+func TestGetResizeSpec(t *testing.T) {
+	config := &types.VirtualMachineConfigInfo{
+		Hardware: types.VirtualHardware{NumCPU: 4, NumCoresPerSocket: 2, MemoryMB: 8192},
+	}
+
+	testCases := []struct {
+		testCase     string
+		providerSpec vsphereapi.VSphereMachineProviderSpec
+		expectedSpec types.VirtualMachineConfigSpec
+		expectDrift  bool
+	}{
+		{
+			testCase:     "unset fields are ignored",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{},
+		},
+		{
+			testCase:     "matching fields",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{NumCPUs: 4, NumCoresPerSocket: 2, MemoryMiB: 8192},
+		},
+		{
+			testCase:     "cores per socket are kept when they divide the CPUs",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{NumCPUs: 8, MemoryMiB: 16384},
+			expectedSpec: types.VirtualMachineConfigSpec{NumCPUs: 8, MemoryMB: 16384},
+			expectDrift:  true,
+		},
+		{
+			testCase:     "cores per socket are changed when they don't divide the CPUs",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{NumCPUs: 5},
+			expectedSpec: types.VirtualMachineConfigSpec{NumCPUs: 5, NumCoresPerSocket: 5},
+			expectDrift:  true,
+		},
+		{
+			testCase:     "cores per socket",
+			providerSpec: vsphereapi.VSphereMachineProviderSpec{NumCoresPerSocket: 4},
+			expectedSpec: types.VirtualMachineConfigSpec{NumCoresPerSocket: 4},
+			expectDrift:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			spec, drift := getResizeSpec(&tc.providerSpec, config)
+			if !reflect.DeepEqual(spec, tc.expectedSpec) {
+				t.Errorf("Expected spec %+v, got: %+v", tc.expectedSpec, spec)
+			}
+			if (len(drift) > 0) != tc.expectDrift {
+				t.Errorf("Expected drift: %v, got: %v", tc.expectDrift, drift)
+			}
+		})
+	}
+}
+
+func TestReconcileResources(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	simulatorVM := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vm := &virtualMachine{
+		Context: context.TODO(),
+		Obj:     object.NewVirtualMachine(session.Client.Client, simulatorVM.Reference()),
+		Ref:     simulatorVM.Reference(),
+	}
+
+	resizeInProgress := vsphereapi.VSphereMachineProviderCondition{
+		Type:   vsphereapi.MachineResize,
+		Status: corev1.ConditionFalse,
+		Reason: vsphereapi.MachineResizeInProgress,
+	}
+
+	testCases := []struct {
+		testCase           string
+		providerSpec       vsphereapi.VSphereMachineProviderSpec
+		hotAdd             bool
+		powerState         types.VirtualMachinePowerState
+		conditions         []vsphereapi.VSphereMachineProviderCondition
+		cordoned           bool
+		expectedNumCPUs    int32
+		expectedMemoryMiB  int32
+		expectedPowerState types.VirtualMachinePowerState
+		expectedReason     vsphereapi.VSphereMachineProviderConditionReason
+	}{
+		{
+			testCase:           "no drift",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 2, MemoryMiB: 2048},
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  2048,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+		},
+		{
+			testCase:           "drift is reported without resize policy",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 4, MemoryMiB: 4096},
+			hotAdd:             true,
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  2048,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeNotAllowed,
+		},
+		{
+			testCase:           "resources are hot added",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 4, MemoryMiB: 4096, ResizePolicy: vsphereapi.HotAddResize},
+			hotAdd:             true,
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			expectedNumCPUs:    4,
+			expectedMemoryMiB:  4096,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeSucceeded,
+		},
+		{
+			testCase:           "drift is reported when hot-add is disabled",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 4, ResizePolicy: vsphereapi.HotAddResize},
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  2048,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeNotAllowed,
+		},
+		{
+			testCase:           "powered off vm is resized",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{MemoryMiB: 1024, ResizePolicy: vsphereapi.HotAddResize},
+			powerState:         types.VirtualMachinePowerStatePoweredOff,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  1024,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOff,
+			expectedReason:     vsphereapi.MachineResizeSucceeded,
+		},
+		{
+			testCase:           "vm is restarted to be resized",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 1, MemoryMiB: 1024, ResizePolicy: vsphereapi.RestartResize},
+			hotAdd:             true,
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			expectedNumCPUs:    1,
+			expectedMemoryMiB:  1024,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeSucceeded,
+		},
+		{
+			testCase:           "vm powered off to be resized is powered on",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 2, MemoryMiB: 2048, ResizePolicy: vsphereapi.RestartResize},
+			powerState:         types.VirtualMachinePowerStatePoweredOff,
+			conditions:         []vsphereapi.VSphereMachineProviderCondition{resizeInProgress},
+			cordoned:           true,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  2048,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeSucceeded,
+		},
+		{
+			testCase:           "vm is powered off when the guest does not shut down in time",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 1, MemoryMiB: 1024, ResizePolicy: vsphereapi.RestartResize},
+			powerState:         types.VirtualMachinePowerStatePoweredOn,
+			conditions:         []vsphereapi.VSphereMachineProviderCondition{resizeInProgress},
+			cordoned:           true,
+			expectedNumCPUs:    1,
+			expectedMemoryMiB:  1024,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeSucceeded,
+		},
+		{
+			testCase:           "resize in progress is finished when the resize policy is switched to none",
+			providerSpec:       vsphereapi.VSphereMachineProviderSpec{NumCPUs: 1, MemoryMiB: 1024, ResizePolicy: vsphereapi.NoResize},
+			powerState:         types.VirtualMachinePowerStatePoweredOff,
+			conditions:         []vsphereapi.VSphereMachineProviderCondition{resizeInProgress},
+			cordoned:           true,
+			expectedNumCPUs:    2,
+			expectedMemoryMiB:  2048,
+			expectedPowerState: types.VirtualMachinePowerStatePoweredOn,
+			expectedReason:     vsphereapi.MachineResizeNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			// Reset the vm
+			if state, _ := vm.getPowerState(); state != types.VirtualMachinePowerStatePoweredOff {
+				if err := waitForVMTask(vm.Context, vm.Obj.PowerOff); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := waitForVMTask(vm.Context, func(ctx context.Context) (*object.Task, error) {
+				return vm.Obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{
+					NumCPUs:             2,
+					NumCoresPerSocket:   1,
+					MemoryMB:            2048,
+					CpuHotAddEnabled:    pointer.BoolPtr(tc.hotAdd),
+					MemoryHotAddEnabled: pointer.BoolPtr(tc.hotAdd),
+				})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if tc.powerState == types.VirtualMachinePowerStatePoweredOn {
+				if err := waitForVMTask(vm.Context, vm.Obj.PowerOn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+			node.Spec.Unschedulable = tc.cordoned
+			kubeClient := kubefake.NewSimpleClientset(node)
+			r := &Reconciler{machineScope: &machineScope{
+				Context:      context.TODO(),
+				session:      session,
+				kubeClient:   kubeClient,
+				providerSpec: &tc.providerSpec,
+				providerStatus: &vsphereapi.VSphereMachineProviderStatus{
+					Conditions: tc.conditions,
+				},
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+					Status: machinev1.MachineStatus{
+						NodeRef: &corev1.ObjectReference{Name: node.Name},
+					},
+				},
+			}}
+
+			// each step of a resize requeues the machine until it is finished
+			for i := 0; ; i++ {
+				err := r.reconcileResources(vm)
+				if err == nil {
+					break
+				}
+				var requeueAfterError *machinecontroller.RequeueAfterError
+				if !errors.As(err, &requeueAfterError) {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if i == 20 {
+					t.Fatalf("Resize did not finish: %v", err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			var moVM mo.VirtualMachine
+			if err := vm.Obj.Properties(vm.Context, vm.Ref, []string{"config.hardware", "runtime.powerState"}, &moVM); err != nil {
+				t.Fatal(err)
+			}
+			if moVM.Config.Hardware.NumCPU != tc.expectedNumCPUs {
+				t.Errorf("Expected %d CPUs, got: %d", tc.expectedNumCPUs, moVM.Config.Hardware.NumCPU)
+			}
+			if moVM.Config.Hardware.MemoryMB != tc.expectedMemoryMiB {
+				t.Errorf("Expected %d MiB of memory, got: %d", tc.expectedMemoryMiB, moVM.Config.Hardware.MemoryMB)
+			}
+			if moVM.Runtime.PowerState != tc.expectedPowerState {
+				t.Errorf("Expected power state %v, got: %v", tc.expectedPowerState, moVM.Runtime.PowerState)
+			}
+
+			condition := findProviderCondition(r.providerStatus.Conditions, vsphereapi.MachineResize)
+			if tc.expectedReason == "" {
+				if condition != nil {
+					t.Errorf("Expected no resize condition, got: %+v", condition)
+				}
+			} else if condition == nil || condition.Reason != tc.expectedReason {
+				t.Errorf("Expected resize condition with reason %v, got: %+v", tc.expectedReason, condition)
+			}
+
+			updatedNode, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updatedNode.Spec.Unschedulable {
+				t.Errorf("Expected node to be schedulable")
+			}
+		})
+	}
+}
+
 func TestReconcilePowerStateAnnontation(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -23,6 +23,8 @@ import (
 	"net/url"
 	"sync"
 
+	"github.com/vmware/govmomi/pbm"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -32,6 +34,7 @@ import (
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/soap"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
@@ -50,6 +53,17 @@ type Session struct {
 
 	username string
 	password string
+
+	// rest is shared by the copies of a cached session, so that the
+	// REST client logged in by WithRestClient is reused across calls.
+	// It is always set by GetOrCreate.
+	rest *restSession
+}
+
+// restSession is a vCenter REST client along with the lock guarding its login.
+type restSession struct {
+	sync.Mutex
+	client *rest.Client
 }
 
 // GetOrCreate gets a cached session or creates a new one if one does not
@@ -87,6 +101,7 @@ func GetOrCreate(
 		Client:   client,
 		username: username,
 		password: password,
+		rest:     &restSession{},
 	}
 
 	session.UserAgent = "machineAPIvSphereProvider"
@@ -126,6 +141,11 @@ func (s *Session) FindRefByInstanceUUID(ctx context.Context, UUID string) (objec
 	return s.findRefByUUID(ctx, UUID, true)
 }
 
+// FindRefByBIOSUUID finds an object by its BIOS UUID, which the providerID of a machine is built from.
+func (s *Session) FindRefByBIOSUUID(ctx context.Context, UUID string) (object.Reference, error) {
+	return s.findRefByUUID(ctx, UUID, false)
+}
+
 func (s *Session) findRefByUUID(ctx context.Context, UUID string, findByInstanceUUID bool) (object.Reference, error) {
 	if s.Client == nil {
 		return nil, errors.New("vSphere client is not initialized")
@@ -178,19 +198,109 @@ func (s *Session) GetTask(ctx context.Context, taskRef string) (*mo.Task, error)
 	return &obj, nil
 }
 
-func (s *Session) WithRestClient(ctx context.Context, f func(c *rest.Client) error) error {
-	c := rest.NewClient(s.Client.Client)
+// GetStoragePolicyID returns the ID of the storage policy with the given name,
+// or an empty string if there is no such policy.
+func (s *Session) GetStoragePolicyID(ctx context.Context, name string) (string, error) {
+	c, err := pbm.NewClient(ctx, s.Client.Client)
+	if err != nil {
+		return "", fmt.Errorf("error setting up new vSphere PBM client: %w", err)
+	}
 
-	user := url.UserPassword(s.username, s.password)
-	if err := c.Login(ctx, user); err != nil {
-		return err
+	resourceType := pbmtypes.PbmProfileResourceType{
+		ResourceType: string(pbmtypes.PbmProfileResourceTypeEnumSTORAGE),
+	}
+	ids, err := c.QueryProfile(ctx, resourceType, string(pbmtypes.PbmProfileCategoryEnumREQUIREMENT))
+	if err != nil {
+		return "", fmt.Errorf("error querying storage policies: %w", err)
+	}
+	if len(ids) == 0 {
+		return "", nil
 	}
 
-	defer func() {
-		if err := c.Logout(ctx); err != nil {
-			klog.Errorf("Failed to logout: %v", err)
+	profiles, err := c.RetrieveContent(ctx, ids)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving storage policies: %w", err)
+	}
+	for _, p := range profiles {
+		profile := p.GetPbmProfile()
+		if profile.Name == name {
+			return profile.ProfileId.UniqueId, nil
 		}
-	}()
+	}
+	return "", nil
+}
+
+// CompatibleDatastores returns the datastores compatible with the storage policy
+// with the given ID, in the order they are given.
+func (s *Session) CompatibleDatastores(ctx context.Context, profileID string, datastores []*object.Datastore) ([]*object.Datastore, error) {
+	if len(datastores) == 0 {
+		return nil, nil
+	}
+
+	c, err := pbm.NewClient(ctx, s.Client.Client)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up new vSphere PBM client: %w", err)
+	}
 
+	hubs := make([]pbmtypes.PbmPlacementHub, 0, len(datastores))
+	for _, ds := range datastores {
+		hubs = append(hubs, pbmtypes.PbmPlacementHub{
+			HubType: ds.Reference().Type,
+			HubId:   ds.Reference().Value,
+		})
+	}
+	requirements := []pbmtypes.BasePbmPlacementRequirement{
+		&pbmtypes.PbmPlacementCapabilityProfileRequirement{
+			ProfileId: pbmtypes.PbmProfileId{UniqueId: profileID},
+		},
+	}
+
+	result, err := c.CheckRequirements(ctx, hubs, nil, requirements)
+	if err != nil {
+		return nil, fmt.Errorf("error checking storage policy requirements: %w", err)
+	}
+
+	compatibleIDs := sets.NewString()
+	for _, hub := range result.CompatibleDatastores() {
+		compatibleIDs.Insert(hub.HubId)
+	}
+
+	var compatible []*object.Datastore
+	for _, ds := range datastores {
+		if compatibleIDs.Has(ds.Reference().Value) {
+			compatible = append(compatible, ds)
+		}
+	}
+	return compatible, nil
+}
+
+// WithRestClient calls f with a vCenter REST client logged in with the credentials of the session.
+// The client is kept logged in and reused by subsequent calls, until its session expires.
+func (s *Session) WithRestClient(ctx context.Context, f func(c *rest.Client) error) error {
+	c, err := s.restClient(ctx)
+	if err != nil {
+		return err
+	}
 	return f(c)
 }
+
+func (s *Session) restClient(ctx context.Context) (*rest.Client, error) {
+	s.rest.Lock()
+	defer s.rest.Unlock()
+
+	if s.rest.client != nil {
+		session, err := s.rest.client.Session(ctx)
+		if err != nil {
+			klog.V(3).Infof("Failed to get vCenter REST session, logging in again: %v", err)
+		} else if session != nil {
+			return s.rest.client, nil
+		}
+	}
+
+	c := rest.NewClient(s.Client.Client)
+	if err := c.Login(ctx, url.UserPassword(s.username, s.password)); err != nil {
+		return nil, err
+	}
+	s.rest.client = c
+	return c, nil
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
 )
 
 func initSimulator(t *testing.T) (*simulator.Model, *Session, *simulator.Server) {
@@ -33,6 +36,7 @@ func initSimulator(t *testing.T) (*simulator.Model, *Session, *simulator.Server)
 		t.Fatal(err)
 	}
 	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterEndpoints = true
 
 	server := model.Service.NewServer()
 	pass, _ := server.URL.User.Password()
@@ -244,3 +248,37 @@ func TestGetTask(t *testing.T) {
 		})
 	}
 }
+
+func TestWithRestClient(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	getClient := func() *rest.Client {
+		var client *rest.Client
+		if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+			client = c
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	first := getClient()
+	if second := getClient(); second != first {
+		t.Errorf("Expected the REST client to be reused")
+	}
+
+	// a new client is logged in once the session of the previous one expires
+	if err := first.Logout(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	third := getClient()
+	if third == first {
+		t.Errorf("Expected a new REST client once the session expired")
+	}
+	if s, err := third.Session(context.TODO()); err != nil || s == nil {
+		t.Errorf("Expected the new REST client to be logged in, got session %v, error %v", s, err)
+	}
+}
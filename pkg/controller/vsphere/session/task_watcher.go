@@ -0,0 +1,96 @@
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// TaskWatcher waits for vSphere tasks to finish and sends a generic event for
+// the object each task was started for, so that the object is reconciled as
+// soon as its task is finished instead of polling the task.
+type TaskWatcher struct {
+	ctx    context.Context
+	events chan event.GenericEvent
+	source *source.Channel
+
+	mu sync.Mutex
+	// watched holds the references of the tasks being waited for
+	watched map[string]bool
+}
+
+// NewTaskWatcher returns a task watcher which stops waiting for tasks once the
+// context is done.
+func NewTaskWatcher(ctx context.Context) *TaskWatcher {
+	events := make(chan event.GenericEvent)
+	return &TaskWatcher{
+		ctx:     ctx,
+		events:  events,
+		source:  &source.Channel{Source: events},
+		watched: map[string]bool{},
+	}
+}
+
+// Source returns the controller-runtime source of the events sent when
+// watched tasks are finished.
+func (w *TaskWatcher) Source() source.Source {
+	return w.source
+}
+
+// Watch waits in the background for the task to finish, then sends an event
+// for the object. A task which is already being waited for is ignored.
+func (w *TaskWatcher) Watch(s *Session, taskRef string, obj client.Object) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watched[taskRef] {
+		return
+	}
+	w.watched[taskRef] = true
+	go w.wait(s, taskRef, obj)
+}
+
+// IsWatching returns true if the task is being waited for.
+func (w *TaskWatcher) IsWatching(taskRef string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.watched[taskRef]
+}
+
+func (w *TaskWatcher) wait(s *Session, taskRef string, obj client.Object) {
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.watched, taskRef)
+	}()
+
+	ref := types.ManagedObjectReference{Type: managedObjectTypeTask, Value: taskRef}
+	err := property.Wait(w.ctx, property.DefaultCollector(s.Client.Client), ref, []string{"info.state"}, func(changes []types.PropertyChange) bool {
+		for _, change := range changes {
+			if state, ok := change.Val.(types.TaskInfoState); ok {
+				return state == types.TaskInfoStateSuccess || state == types.TaskInfoStateError
+			}
+		}
+		return false
+	})
+	if err != nil {
+		// The object is still requeued by the controller when waiting fails,
+		// only later than when the task finishes.
+		if w.ctx.Err() == nil {
+			klog.Errorf("Failed to wait for task %v: %v", taskRef, err)
+		}
+		return
+	}
+
+	klog.V(3).Infof("Task %v finished, enqueuing %v/%v", taskRef, obj.GetNamespace(), obj.GetName())
+	select {
+	case w.events <- event.GenericEvent{Object: obj}:
+	case <-w.ctx.Done():
+	}
+}
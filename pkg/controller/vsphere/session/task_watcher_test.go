@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestTaskWatcher(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+	simulatorVM := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+
+	testCases := []struct {
+		testCase string
+		fault    types.BaseMethodFault
+		// finishedBeforeWatch runs the task to completion before watching it
+		finishedBeforeWatch bool
+	}{
+		{
+			testCase: "task succeeds",
+		},
+		{
+			testCase: "task fails",
+			fault:    new(types.InvalidArgument),
+		},
+		{
+			testCase:            "task already finished",
+			finishedBeforeWatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			watcher := NewTaskWatcher(ctx)
+
+			release := make(chan struct{})
+			task := simulator.CreateTask(simulatorVM, "test", func(*simulator.Task) (types.AnyType, types.BaseMethodFault) {
+				<-release
+				return nil, tc.fault
+			})
+			done := make(chan struct{})
+			go func() {
+				task.Run()
+				close(done)
+			}()
+			if tc.finishedBeforeWatch {
+				close(release)
+				<-done
+			}
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: tc.testCase}}
+			watcher.Watch(session, task.Self.Value, obj)
+			watcher.Watch(session, task.Self.Value, obj)
+			if !watcher.IsWatching(task.Self.Value) {
+				t.Fatalf("Expected task %v to be watched", task.Self.Value)
+			}
+
+			if !tc.finishedBeforeWatch {
+				select {
+				case evt := <-watcher.events:
+					t.Fatalf("Unexpected event for %v before the task finished", evt.Object.GetName())
+				case <-time.After(100 * time.Millisecond):
+				}
+				close(release)
+			}
+
+			var evt event.GenericEvent
+			select {
+			case evt = <-watcher.events:
+			case <-time.After(10 * time.Second):
+				t.Fatal("Timed out waiting for the task event")
+			}
+			if evt.Object != obj {
+				t.Errorf("Expected event for %v, got %v", obj.GetName(), evt.Object.GetName())
+			}
+
+			// The task is watched only once
+			select {
+			case evt := <-watcher.events:
+				t.Errorf("Unexpected second event for %v", evt.Object.GetName())
+			case <-time.After(100 * time.Millisecond):
+			}
+			if watcher.IsWatching(task.Self.Value) {
+				t.Errorf("Expected task %v to no longer be watched", task.Self.Value)
+			}
+		})
+	}
+}
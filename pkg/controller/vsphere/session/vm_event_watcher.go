@@ -0,0 +1,214 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// vmEventTypes are the vCenter events of virtual machines changed out of band
+// which are worth reconciling their objects for.
+var vmEventTypes = []string{
+	"VmRemovedEvent",
+	"VmPoweredOffEvent",
+	"VmSuspendedEvent",
+	"VmGuestShutdownEvent",
+	"VmRenamedEvent",
+	"VmDisconnectedEvent",
+}
+
+// vmEventPageSize is the number of events read at once from vCenter.
+const vmEventPageSize = 100
+
+// ObjectForVM returns the object of the virtual machine with the given name,
+// or nil if the virtual machine has no object.
+type ObjectForVM func(ctx context.Context, vmName string) (client.Object, error)
+
+// VMEventWatcher subscribes to the vCenter events of the virtual machines
+// carrying a cluster tag. It records each event on the object of the virtual
+// machine and sends a generic event for the object, so that it is reconciled
+// as soon as the virtual machine is changed out of band.
+type VMEventWatcher struct {
+	ctx      context.Context
+	recorder record.EventRecorder
+	events   chan ctrlevent.GenericEvent
+	source   *source.Channel
+
+	mu sync.Mutex
+	// subscriptions holds the keys of the running subscriptions
+	subscriptions map[string]bool
+}
+
+// NewVMEventWatcher returns a virtual machine event watcher which ends its
+// subscriptions once the context is done.
+func NewVMEventWatcher(ctx context.Context, recorder record.EventRecorder) *VMEventWatcher {
+	events := make(chan ctrlevent.GenericEvent)
+	return &VMEventWatcher{
+		ctx:           ctx,
+		recorder:      recorder,
+		events:        events,
+		source:        &source.Channel{Source: events},
+		subscriptions: map[string]bool{},
+	}
+}
+
+// Source returns the controller-runtime source of the events sent for the
+// objects of the virtual machines.
+func (w *VMEventWatcher) Source() source.Source {
+	return w.source
+}
+
+// Subscribe watches in the background the events of the virtual machines of
+// the session datacenter carrying the cluster tag, unless they are already
+// watched. The subscription ends when the session is no longer valid, and is
+// then started again by the next call.
+func (w *VMEventWatcher) Subscribe(s *Session, clusterID string, objectForVM ObjectForVM) {
+	key := subscriptionKey(s, clusterID)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.subscriptions[key] {
+		return
+	}
+	w.subscriptions[key] = true
+	go w.subscribe(key, s, clusterID, objectForVM)
+}
+
+// IsSubscribed returns true if the events of the session datacenter are
+// watched for the cluster.
+func (w *VMEventWatcher) IsSubscribed(s *Session, clusterID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.subscriptions[subscriptionKey(s, clusterID)]
+}
+
+func subscriptionKey(s *Session, clusterID string) string {
+	if s.Datacenter == nil {
+		return fmt.Sprintf("%s/%s", s.Client.URL().Host, clusterID)
+	}
+	return fmt.Sprintf("%s/%s/%s", s.Client.URL().Host, s.Datacenter.Reference().Value, clusterID)
+}
+
+func (w *VMEventWatcher) subscribe(key string, s *Session, clusterID string, objectForVM ObjectForVM) {
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscriptions, key)
+	}()
+
+	// The first page of events holds past events, which are skipped.
+	start, err := methods.GetCurrentTime(w.ctx, s.Client.Client)
+	if err != nil {
+		klog.Errorf("Failed to get the vCenter time: %v", err)
+		return
+	}
+
+	root := s.ServiceContent.RootFolder
+	if s.Datacenter != nil {
+		root = s.Datacenter.Reference()
+	}
+
+	// tagged holds the machine names of the virtual machines carrying the
+	// cluster tag, including removed ones whose tags can't be listed anymore.
+	tagged := map[string]string{}
+	if err := w.refreshTagged(s, clusterID, tagged); err != nil {
+		klog.Errorf("Failed to list the virtual machines tagged %s: %v", clusterID, err)
+		return
+	}
+
+	klog.Infof("Watching events of the virtual machines tagged %s", clusterID)
+	err = event.NewManager(s.Client.Client).Events(w.ctx, []types.ManagedObjectReference{root}, vmEventPageSize, true, false,
+		func(_ types.ManagedObjectReference, events []types.BaseEvent) error {
+			if err := w.refreshTagged(s, clusterID, tagged); err != nil {
+				klog.Errorf("Failed to list the virtual machines tagged %s: %v", clusterID, err)
+			}
+			// Events are sorted from the most recent one.
+			for i := len(events) - 1; i >= 0; i-- {
+				if events[i].GetEvent().CreatedTime.Before(*start) {
+					continue
+				}
+				w.handle(events[i], tagged, objectForVM)
+			}
+			return nil
+		}, vmEventTypes...)
+	if err != nil && w.ctx.Err() == nil {
+		klog.Errorf("Failed to watch events of the virtual machines tagged %s: %v", clusterID, err)
+	}
+}
+
+// refreshTagged adds the virtual machines carrying the cluster tag to tagged.
+func (w *VMEventWatcher) refreshTagged(s *Session, clusterID string, tagged map[string]string) error {
+	return s.WithRestClient(w.ctx, func(c *rest.Client) error {
+		refs, err := tags.NewManager(c).ListAttachedObjects(w.ctx, clusterID)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if _, ok := tagged[ref.Reference().Value]; !ok {
+				tagged[ref.Reference().Value] = ""
+			}
+		}
+		return nil
+	})
+}
+
+// handle records the event on the object of its virtual machine, if tagged,
+// and enqueues the object.
+func (w *VMEventWatcher) handle(e types.BaseEvent, tagged map[string]string, objectForVM ObjectForVM) {
+	vm := e.GetEvent().Vm
+	if vm == nil {
+		return
+	}
+	name, ok := tagged[vm.Vm.Value]
+	if !ok {
+		return
+	}
+	// The object keeps the name the virtual machine had when it was first seen.
+	if name == "" {
+		name = vm.Name
+		if renamed, ok := e.(*types.VmRenamedEvent); ok {
+			name = renamed.OldName
+		}
+		tagged[vm.Vm.Value] = name
+	}
+	if _, ok := e.(*types.VmRemovedEvent); ok {
+		delete(tagged, vm.Vm.Value)
+	}
+
+	obj, err := objectForVM(w.ctx, name)
+	if err != nil {
+		klog.Errorf("Failed to get the object of vm %v: %v", name, err)
+		return
+	}
+	if obj == nil {
+		return
+	}
+
+	reason := strings.TrimSuffix(reflect.TypeOf(e).Elem().Name(), "Event")
+	message := e.GetEvent().FullFormattedMessage
+	if message == "" {
+		message = fmt.Sprintf("%s: %s", reason, vm.Name)
+	}
+	klog.Infof("%v: vCenter event: %v", name, message)
+	w.recorder.Event(obj, corev1.EventTypeNormal, reason, message)
+
+	select {
+	case w.events <- ctrlevent.GenericEvent{Object: obj}:
+	case <-w.ctx.Done():
+	}
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestVMEventWatcher(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	simulatorVMs := simulator.Map.All("VirtualMachine")
+	taggedVM := object.NewVirtualMachine(session.Client.Client, simulatorVMs[0].Reference())
+	untaggedVM := object.NewVirtualMachine(session.Client.Client, simulatorVMs[1].Reference())
+	taggedVMName, err := taggedVM.ObjectName(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := session.WithRestClient(context.TODO(), func(c *rest.Client) error {
+		m := tags.NewManager(c)
+		categoryID, err := m.CreateCategory(context.TODO(), &tags.Category{
+			AssociableTypes: []string{"VirtualMachine"},
+			Cardinality:     "SINGLE",
+			Name:            "CLUSTERID",
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := m.CreateTag(context.TODO(), &tags.Tag{CategoryID: categoryID, Name: "CLUSTERID"}); err != nil {
+			return err
+		}
+		return m.AttachTag(context.TODO(), "CLUSTERID", taggedVM.Reference())
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder := record.NewFakeRecorder(10)
+	watcher := NewVMEventWatcher(ctx, recorder)
+	defer func() {
+		cancel()
+		// The simulator can only be stopped once the subscription ended
+		for watcher.IsSubscribed(session, "CLUSTERID") {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	objectForVM := func(_ context.Context, vmName string) (client.Object, error) {
+		if vmName != taggedVMName {
+			return nil, nil
+		}
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: vmName}}, nil
+	}
+	watcher.Subscribe(session, "CLUSTERID", objectForVM)
+	if !watcher.IsSubscribed(session, "CLUSTERID") {
+		t.Fatal("Expected the cluster events to be watched")
+	}
+	// Let the subscription skip the past events
+	time.Sleep(time.Second)
+
+	for _, vm := range []*object.VirtualMachine{untaggedVM, taggedVM} {
+		task, err := vm.PowerOff(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := task.Wait(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectEvent := func(reason string) {
+		t.Helper()
+		select {
+		case evt := <-watcher.events:
+			if evt.Object.GetName() != taggedVMName {
+				t.Errorf("Expected an event for %v, got: %v", taggedVMName, evt.Object.GetName())
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for the %v event", reason)
+		}
+		select {
+		case recorded := <-recorder.Events:
+			if want := "Normal " + reason + " "; len(recorded) < len(want) || recorded[:len(want)] != want {
+				t.Errorf("Expected a recorded %q event, got: %q", reason, recorded)
+			}
+		default:
+			t.Errorf("Expected a recorded %q event", reason)
+		}
+	}
+	expectEvent("VmPoweredOff")
+
+	// The object keeps the name of the virtual machine before it was renamed
+	task, err := taggedVM.Rename(context.TODO(), "renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	task, err = taggedVM.Destroy(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent("VmRemoved")
+
+	select {
+	case evt := <-watcher.events:
+		t.Errorf("Unexpected event for %v", evt.Object.GetName())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -179,3 +179,14 @@ func getInsecureFlagFromConfig(config *vSphereConfig) bool {
 	}
 	return false
 }
+
+// writer passes the output of a node drainer to a klog function.
+type writer struct {
+	logFunc func(args ...interface{})
+}
+
+// Write passes string(p) into writer's logFunc and always returns len(p)
+func (w writer) Write(p []byte) (n int, err error) {
+	w.logFunc(string(p))
+	return len(p), nil
+}
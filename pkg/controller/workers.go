@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// MaxConcurrentReconcilesFlag is the flag setting the number of workers of a controller
+	MaxConcurrentReconcilesFlag = "max-concurrent-reconciles"
+	// RateLimiterBaseDelayFlag is the flag setting the first per item requeue delay after a failure
+	RateLimiterBaseDelayFlag = "rate-limiter-base-delay"
+	// RateLimiterMaxDelayFlag is the flag setting the maximum per item requeue delay after failures
+	RateLimiterMaxDelayFlag = "rate-limiter-max-delay"
+	// RateLimiterQPSFlag is the flag setting the overall rate of requeues per second
+	RateLimiterQPSFlag = "rate-limiter-qps"
+	// RateLimiterBurstFlag is the flag setting the overall burst of requeues
+	RateLimiterBurstFlag = "rate-limiter-burst"
+)
+
+// WorkerOptions configures the number of workers of a controller and the rate limiter of its workqueue.
+// The rate limiter delays each failing item exponentially and caps the overall rate of requeues,
+// the same way as the default controller-runtime rate limiter.
+type WorkerOptions struct {
+	MaxConcurrentReconciles int
+	RateLimiterBaseDelay    time.Duration
+	RateLimiterMaxDelay     time.Duration
+	RateLimiterQPS          int
+	RateLimiterBurst        int
+}
+
+// DefaultWorkerOptions returns the controller-runtime defaults: a single worker
+// and the default controller rate limiter.
+func DefaultWorkerOptions() WorkerOptions {
+	return WorkerOptions{
+		MaxConcurrentReconciles: 1,
+		RateLimiterBaseDelay:    5 * time.Millisecond,
+		RateLimiterMaxDelay:     1000 * time.Second,
+		RateLimiterQPS:          10,
+		RateLimiterBurst:        100,
+	}
+}
+
+// AddFlags binds the options to flags in the given flag set, defaulting to the current values
+func (o *WorkerOptions) AddFlags(fs *flag.FlagSet) {
+	fs.IntVar(&o.MaxConcurrentReconciles, MaxConcurrentReconcilesFlag, o.MaxConcurrentReconciles,
+		"Maximum number of objects reconciled concurrently by the controller.")
+	fs.DurationVar(&o.RateLimiterBaseDelay, RateLimiterBaseDelayFlag, o.RateLimiterBaseDelay,
+		"Delay before an object is reconciled again after its first failure. It doubles on every consecutive failure.")
+	fs.DurationVar(&o.RateLimiterMaxDelay, RateLimiterMaxDelayFlag, o.RateLimiterMaxDelay,
+		"Maximum delay before an object is reconciled again after consecutive failures.")
+	fs.IntVar(&o.RateLimiterQPS, RateLimiterQPSFlag, o.RateLimiterQPS,
+		"Maximum number of objects requeued per second across the controller.")
+	fs.IntVar(&o.RateLimiterBurst, RateLimiterBurstFlag, o.RateLimiterBurst,
+		"Maximum burst of objects requeued across the controller.")
+}
+
+// Validate returns an error if the options can not be used to build a controller
+func (o WorkerOptions) Validate() error {
+	if o.MaxConcurrentReconciles < 1 {
+		return fmt.Errorf("--%s must be at least 1, got %d", MaxConcurrentReconcilesFlag, o.MaxConcurrentReconciles)
+	}
+	if o.RateLimiterBaseDelay <= 0 {
+		return fmt.Errorf("--%s must be positive, got %v", RateLimiterBaseDelayFlag, o.RateLimiterBaseDelay)
+	}
+	if o.RateLimiterMaxDelay < o.RateLimiterBaseDelay {
+		return fmt.Errorf("--%s (%v) must not be lower than --%s (%v)", RateLimiterMaxDelayFlag, o.RateLimiterMaxDelay, RateLimiterBaseDelayFlag, o.RateLimiterBaseDelay)
+	}
+	if o.RateLimiterQPS < 1 {
+		return fmt.Errorf("--%s must be at least 1, got %d", RateLimiterQPSFlag, o.RateLimiterQPS)
+	}
+	if o.RateLimiterBurst < 1 {
+		return fmt.Errorf("--%s must be at least 1, got %d", RateLimiterBurstFlag, o.RateLimiterBurst)
+	}
+	return nil
+}
+
+// RateLimiter returns the workqueue rate limiter described by the options
+func (o WorkerOptions) RateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(o.RateLimiterBaseDelay, o.RateLimiterMaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(o.RateLimiterQPS), o.RateLimiterBurst)},
+	)
+}
+
+// ControllerOptions returns the options to build a controller running the reconciler with these workers.
+// Unset options fall back to their defaults.
+func (o WorkerOptions) ControllerOptions(r reconcile.Reconciler) controller.Options {
+	o = o.WithDefaults()
+	return controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
+		RateLimiter:             o.RateLimiter(),
+	}
+}
+
+// Args returns the command line arguments setting the options which differ from their zero value,
+// so that controllers not configured explicitly keep their own defaults.
+func (o WorkerOptions) Args() []string {
+	var args []string
+	if o.MaxConcurrentReconciles != 0 {
+		args = append(args, fmt.Sprintf("--%s=%d", MaxConcurrentReconcilesFlag, o.MaxConcurrentReconciles))
+	}
+	if o.RateLimiterBaseDelay != 0 {
+		args = append(args, fmt.Sprintf("--%s=%v", RateLimiterBaseDelayFlag, o.RateLimiterBaseDelay))
+	}
+	if o.RateLimiterMaxDelay != 0 {
+		args = append(args, fmt.Sprintf("--%s=%v", RateLimiterMaxDelayFlag, o.RateLimiterMaxDelay))
+	}
+	if o.RateLimiterQPS != 0 {
+		args = append(args, fmt.Sprintf("--%s=%d", RateLimiterQPSFlag, o.RateLimiterQPS))
+	}
+	if o.RateLimiterBurst != 0 {
+		args = append(args, fmt.Sprintf("--%s=%d", RateLimiterBurstFlag, o.RateLimiterBurst))
+	}
+	return args
+}
+
+// WithDefaults returns a copy of the options with unset options set to their defaults
+func (o WorkerOptions) WithDefaults() WorkerOptions {
+	defaults := DefaultWorkerOptions()
+	if o.MaxConcurrentReconciles == 0 {
+		o.MaxConcurrentReconciles = defaults.MaxConcurrentReconciles
+	}
+	if o.RateLimiterBaseDelay == 0 {
+		o.RateLimiterBaseDelay = defaults.RateLimiterBaseDelay
+	}
+	if o.RateLimiterMaxDelay == 0 {
+		o.RateLimiterMaxDelay = defaults.RateLimiterMaxDelay
+	}
+	if o.RateLimiterQPS == 0 {
+		o.RateLimiterQPS = defaults.RateLimiterQPS
+	}
+	if o.RateLimiterBurst == 0 {
+		o.RateLimiterBurst = defaults.RateLimiterBurst
+	}
+	return o
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWorkerOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mutate      func(o *WorkerOptions)
+		expectError bool
+	}{
+		{
+			name:   "defaults are valid",
+			mutate: func(o *WorkerOptions) {},
+		},
+		{
+			name:   "many workers are valid",
+			mutate: func(o *WorkerOptions) { o.MaxConcurrentReconciles = 50 },
+		},
+		{
+			name:        "no workers",
+			mutate:      func(o *WorkerOptions) { o.MaxConcurrentReconciles = 0 },
+			expectError: true,
+		},
+		{
+			name:        "negative base delay",
+			mutate:      func(o *WorkerOptions) { o.RateLimiterBaseDelay = -time.Second },
+			expectError: true,
+		},
+		{
+			name:        "max delay lower than base delay",
+			mutate:      func(o *WorkerOptions) { o.RateLimiterMaxDelay = time.Millisecond },
+			expectError: true,
+		},
+		{
+			name:        "no qps",
+			mutate:      func(o *WorkerOptions) { o.RateLimiterQPS = 0 },
+			expectError: true,
+		},
+		{
+			name:        "no burst",
+			mutate:      func(o *WorkerOptions) { o.RateLimiterBurst = 0 },
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultWorkerOptions()
+			tc.mutate(&o)
+			if err := o.Validate(); (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v, got: %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestWorkerOptionsArgs(t *testing.T) {
+	if args := (WorkerOptions{}).Args(); len(args) != 0 {
+		t.Errorf("expected no args for unset options, got %v", args)
+	}
+
+	expected := WorkerOptions{
+		MaxConcurrentReconciles: 10,
+		RateLimiterBaseDelay:    time.Second,
+		RateLimiterMaxDelay:     10 * time.Minute,
+		RateLimiterQPS:          20,
+		RateLimiterBurst:        200,
+	}
+
+	// the args must round trip through the flags of the controllers
+	got := DefaultWorkerOptions()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	got.AddFlags(fs)
+	if err := fs.Parse(expected.Args()); err != nil {
+		t.Fatalf("unexpected error parsing args: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestWorkerOptionsControllerOptions(t *testing.T) {
+	opts := (WorkerOptions{RateLimiterQPS: 1, RateLimiterBurst: 1}).ControllerOptions(nil)
+	if opts.MaxConcurrentReconciles != 1 {
+		t.Errorf("expected unset workers to default to 1, got %d", opts.MaxConcurrentReconciles)
+	}
+
+	// the base delay defaults to 5ms and doubles on every failure
+	limiter := opts.RateLimiter
+	if delay := limiter.When("item"); delay != 5*time.Millisecond {
+		t.Errorf("expected first delay of 5ms, got %v", delay)
+	}
+	if delay := limiter.When("item"); delay < 10*time.Millisecond {
+		t.Errorf("expected second delay of at least 10ms, got %v", delay)
+	}
+	limiter.Forget("item")
+	if failures := limiter.NumRequeues("item"); failures != 0 {
+		t.Errorf("expected no requeues after forgetting the item, got %d", failures)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2019 Red Hat, Inc.
+ *
+ */
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeIPAddressPools implements IPAddressPoolInterface
+type FakeIPAddressPools struct {
+	Fake *FakeMachineV1beta1
+	ns   string
+}
+
+var ipaddresspoolsResource = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "ipaddresspools"}
+
+var ipaddresspoolsKind = schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "IPAddressPool"}
+
+// Get takes name of the iPAddressPool, and returns the corresponding iPAddressPool object, and an error if there is any.
+func (c *FakeIPAddressPools) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.IPAddressPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(ipaddresspoolsResource, c.ns, name), &v1beta1.IPAddressPool{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.IPAddressPool), err
+}
+
+// List takes label and field selectors, and returns the list of IPAddressPools that match those selectors.
+func (c *FakeIPAddressPools) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.IPAddressPoolList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(ipaddresspoolsResource, ipaddresspoolsKind, c.ns, opts), &v1beta1.IPAddressPoolList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.IPAddressPoolList{ListMeta: obj.(*v1beta1.IPAddressPoolList).ListMeta}
+	for _, item := range obj.(*v1beta1.IPAddressPoolList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested iPAddressPools.
+func (c *FakeIPAddressPools) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(ipaddresspoolsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a iPAddressPool and creates it.  Returns the server's representation of the iPAddressPool, and an error, if there is any.
+func (c *FakeIPAddressPools) Create(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.CreateOptions) (result *v1beta1.IPAddressPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(ipaddresspoolsResource, c.ns, iPAddressPool), &v1beta1.IPAddressPool{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.IPAddressPool), err
+}
+
+// Update takes the representation of a iPAddressPool and updates it. Returns the server's representation of the iPAddressPool, and an error, if there is any.
+func (c *FakeIPAddressPools) Update(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.UpdateOptions) (result *v1beta1.IPAddressPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(ipaddresspoolsResource, c.ns, iPAddressPool), &v1beta1.IPAddressPool{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.IPAddressPool), err
+}
+
+// Delete takes name of the iPAddressPool and deletes it. Returns an error if one occurs.
+func (c *FakeIPAddressPools) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(ipaddresspoolsResource, c.ns, name), &v1beta1.IPAddressPool{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeIPAddressPools) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(ipaddresspoolsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.IPAddressPoolList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched iPAddressPool.
+func (c *FakeIPAddressPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IPAddressPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(ipaddresspoolsResource, c.ns, name, pt, data, subresources...), &v1beta1.IPAddressPool{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.IPAddressPool), err
+}
@@ -28,6 +28,14 @@ type FakeMachineV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeMachineV1beta1) IPAddressClaims(namespace string) v1beta1.IPAddressClaimInterface {
+	return &FakeIPAddressClaims{c, namespace}
+}
+
+func (c *FakeMachineV1beta1) IPAddressPools(namespace string) v1beta1.IPAddressPoolInterface {
+	return &FakeIPAddressPools{c, namespace}
+}
+
 func (c *FakeMachineV1beta1) Machines(namespace string) v1beta1.MachineInterface {
 	return &FakeMachines{c, namespace}
 }
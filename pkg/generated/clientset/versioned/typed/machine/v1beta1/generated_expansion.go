@@ -18,6 +18,10 @@
 
 package v1beta1
 
+type IPAddressClaimExpansion interface{}
+
+type IPAddressPoolExpansion interface{}
+
 type MachineExpansion interface{}
 
 type MachineHealthCheckExpansion interface{}
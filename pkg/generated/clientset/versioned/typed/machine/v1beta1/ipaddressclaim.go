@@ -0,0 +1,178 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2019 Red Hat, Inc.
+ *
+ */
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	scheme "github.com/openshift/machine-api-operator/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// IPAddressClaimsGetter has a method to return a IPAddressClaimInterface.
+// A group's client should implement this interface.
+type IPAddressClaimsGetter interface {
+	IPAddressClaims(namespace string) IPAddressClaimInterface
+}
+
+// IPAddressClaimInterface has methods to work with IPAddressClaim resources.
+type IPAddressClaimInterface interface {
+	Create(ctx context.Context, iPAddressClaim *v1beta1.IPAddressClaim, opts v1.CreateOptions) (*v1beta1.IPAddressClaim, error)
+	Update(ctx context.Context, iPAddressClaim *v1beta1.IPAddressClaim, opts v1.UpdateOptions) (*v1beta1.IPAddressClaim, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.IPAddressClaim, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.IPAddressClaimList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IPAddressClaim, err error)
+	IPAddressClaimExpansion
+}
+
+// iPAddressClaims implements IPAddressClaimInterface
+type iPAddressClaims struct {
+	client rest.Interface
+	ns     string
+}
+
+// newIPAddressClaims returns a IPAddressClaims
+func newIPAddressClaims(c *MachineV1beta1Client, namespace string) *iPAddressClaims {
+	return &iPAddressClaims{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the iPAddressClaim, and returns the corresponding iPAddressClaim object, and an error if there is any.
+func (c *iPAddressClaims) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.IPAddressClaim, err error) {
+	result = &v1beta1.IPAddressClaim{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of IPAddressClaims that match those selectors.
+func (c *iPAddressClaims) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.IPAddressClaimList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.IPAddressClaimList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested iPAddressClaims.
+func (c *iPAddressClaims) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a iPAddressClaim and creates it.  Returns the server's representation of the iPAddressClaim, and an error, if there is any.
+func (c *iPAddressClaims) Create(ctx context.Context, iPAddressClaim *v1beta1.IPAddressClaim, opts v1.CreateOptions) (result *v1beta1.IPAddressClaim, err error) {
+	result = &v1beta1.IPAddressClaim{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(iPAddressClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a iPAddressClaim and updates it. Returns the server's representation of the iPAddressClaim, and an error, if there is any.
+func (c *iPAddressClaims) Update(ctx context.Context, iPAddressClaim *v1beta1.IPAddressClaim, opts v1.UpdateOptions) (result *v1beta1.IPAddressClaim, err error) {
+	result = &v1beta1.IPAddressClaim{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		Name(iPAddressClaim.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(iPAddressClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the iPAddressClaim and deletes it. Returns an error if one occurs.
+func (c *iPAddressClaims) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *iPAddressClaims) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched iPAddressClaim.
+func (c *iPAddressClaims) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IPAddressClaim, err error) {
+	result = &v1beta1.IPAddressClaim{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("ipaddressclaims").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
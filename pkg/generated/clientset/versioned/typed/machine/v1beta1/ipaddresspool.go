@@ -0,0 +1,178 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2019 Red Hat, Inc.
+ *
+ */
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	scheme "github.com/openshift/machine-api-operator/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// IPAddressPoolsGetter has a method to return a IPAddressPoolInterface.
+// A group's client should implement this interface.
+type IPAddressPoolsGetter interface {
+	IPAddressPools(namespace string) IPAddressPoolInterface
+}
+
+// IPAddressPoolInterface has methods to work with IPAddressPool resources.
+type IPAddressPoolInterface interface {
+	Create(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.CreateOptions) (*v1beta1.IPAddressPool, error)
+	Update(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.UpdateOptions) (*v1beta1.IPAddressPool, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.IPAddressPool, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.IPAddressPoolList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IPAddressPool, err error)
+	IPAddressPoolExpansion
+}
+
+// iPAddressPools implements IPAddressPoolInterface
+type iPAddressPools struct {
+	client rest.Interface
+	ns     string
+}
+
+// newIPAddressPools returns a IPAddressPools
+func newIPAddressPools(c *MachineV1beta1Client, namespace string) *iPAddressPools {
+	return &iPAddressPools{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the iPAddressPool, and returns the corresponding iPAddressPool object, and an error if there is any.
+func (c *iPAddressPools) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.IPAddressPool, err error) {
+	result = &v1beta1.IPAddressPool{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of IPAddressPools that match those selectors.
+func (c *iPAddressPools) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.IPAddressPoolList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.IPAddressPoolList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested iPAddressPools.
+func (c *iPAddressPools) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a iPAddressPool and creates it.  Returns the server's representation of the iPAddressPool, and an error, if there is any.
+func (c *iPAddressPools) Create(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.CreateOptions) (result *v1beta1.IPAddressPool, err error) {
+	result = &v1beta1.IPAddressPool{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(iPAddressPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a iPAddressPool and updates it. Returns the server's representation of the iPAddressPool, and an error, if there is any.
+func (c *iPAddressPools) Update(ctx context.Context, iPAddressPool *v1beta1.IPAddressPool, opts v1.UpdateOptions) (result *v1beta1.IPAddressPool, err error) {
+	result = &v1beta1.IPAddressPool{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		Name(iPAddressPool.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(iPAddressPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the iPAddressPool and deletes it. Returns an error if one occurs.
+func (c *iPAddressPools) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *iPAddressPools) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched iPAddressPool.
+func (c *iPAddressPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IPAddressPool, err error) {
+	result = &v1beta1.IPAddressPool{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("ipaddresspools").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -26,6 +26,8 @@ import (
 
 type MachineV1beta1Interface interface {
 	RESTClient() rest.Interface
+	IPAddressClaimsGetter
+	IPAddressPoolsGetter
 	MachinesGetter
 	MachineHealthChecksGetter
 	MachineSetsGetter
@@ -36,6 +38,14 @@ type MachineV1beta1Client struct {
 	restClient rest.Interface
 }
 
+func (c *MachineV1beta1Client) IPAddressClaims(namespace string) IPAddressClaimInterface {
+	return newIPAddressClaims(c, namespace)
+}
+
+func (c *MachineV1beta1Client) IPAddressPools(namespace string) IPAddressPoolInterface {
+	return newIPAddressPools(c, namespace)
+}
+
 func (c *MachineV1beta1Client) Machines(namespace string) MachineInterface {
 	return newMachines(c, namespace)
 }
@@ -53,6 +53,10 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=machine.openshift.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("ipaddressclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machine().V1beta1().IPAddressClaims().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("ipaddresspools"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machine().V1beta1().IPAddressPools().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("machines"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machine().V1beta1().Machines().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("machinehealthchecks"):
@@ -24,6 +24,10 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// IPAddressClaims returns a IPAddressClaimInformer.
+	IPAddressClaims() IPAddressClaimInformer
+	// IPAddressPools returns a IPAddressPoolInformer.
+	IPAddressPools() IPAddressPoolInformer
 	// Machines returns a MachineInformer.
 	Machines() MachineInformer
 	// MachineHealthChecks returns a MachineHealthCheckInformer.
@@ -43,6 +47,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// IPAddressClaims returns a IPAddressClaimInformer.
+func (v *version) IPAddressClaims() IPAddressClaimInformer {
+	return &iPAddressClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// IPAddressPools returns a IPAddressPoolInformer.
+func (v *version) IPAddressPools() IPAddressPoolInformer {
+	return &iPAddressPoolInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Machines returns a MachineInformer.
 func (v *version) Machines() MachineInformer {
 	return &machineInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
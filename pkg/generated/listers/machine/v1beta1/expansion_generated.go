@@ -18,6 +18,22 @@
 
 package v1beta1
 
+// IPAddressClaimListerExpansion allows custom methods to be added to
+// IPAddressClaimLister.
+type IPAddressClaimListerExpansion interface{}
+
+// IPAddressClaimNamespaceListerExpansion allows custom methods to be added to
+// IPAddressClaimNamespaceLister.
+type IPAddressClaimNamespaceListerExpansion interface{}
+
+// IPAddressPoolListerExpansion allows custom methods to be added to
+// IPAddressPoolLister.
+type IPAddressPoolListerExpansion interface{}
+
+// IPAddressPoolNamespaceListerExpansion allows custom methods to be added to
+// IPAddressPoolNamespaceLister.
+type IPAddressPoolNamespaceListerExpansion interface{}
+
 // MachineListerExpansion allows custom methods to be added to
 // MachineLister.
 type MachineListerExpansion interface{}
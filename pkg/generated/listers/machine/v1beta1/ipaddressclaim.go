@@ -0,0 +1,99 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2019 Red Hat, Inc.
+ *
+ */
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IPAddressClaimLister helps list IPAddressClaims.
+// All objects returned here must be treated as read-only.
+type IPAddressClaimLister interface {
+	// List lists all IPAddressClaims in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.IPAddressClaim, err error)
+	// IPAddressClaims returns an object that can list and get IPAddressClaims.
+	IPAddressClaims(namespace string) IPAddressClaimNamespaceLister
+	IPAddressClaimListerExpansion
+}
+
+// iPAddressClaimLister implements the IPAddressClaimLister interface.
+type iPAddressClaimLister struct {
+	indexer cache.Indexer
+}
+
+// NewIPAddressClaimLister returns a new IPAddressClaimLister.
+func NewIPAddressClaimLister(indexer cache.Indexer) IPAddressClaimLister {
+	return &iPAddressClaimLister{indexer: indexer}
+}
+
+// List lists all IPAddressClaims in the indexer.
+func (s *iPAddressClaimLister) List(selector labels.Selector) (ret []*v1beta1.IPAddressClaim, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.IPAddressClaim))
+	})
+	return ret, err
+}
+
+// IPAddressClaims returns an object that can list and get IPAddressClaims.
+func (s *iPAddressClaimLister) IPAddressClaims(namespace string) IPAddressClaimNamespaceLister {
+	return iPAddressClaimNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// IPAddressClaimNamespaceLister helps list and get IPAddressClaims.
+// All objects returned here must be treated as read-only.
+type IPAddressClaimNamespaceLister interface {
+	// List lists all IPAddressClaims in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.IPAddressClaim, err error)
+	// Get retrieves the IPAddressClaim from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1beta1.IPAddressClaim, error)
+	IPAddressClaimNamespaceListerExpansion
+}
+
+// iPAddressClaimNamespaceLister implements the IPAddressClaimNamespaceLister
+// interface.
+type iPAddressClaimNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all IPAddressClaims in the indexer for a given namespace.
+func (s iPAddressClaimNamespaceLister) List(selector labels.Selector) (ret []*v1beta1.IPAddressClaim, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.IPAddressClaim))
+	})
+	return ret, err
+}
+
+// Get retrieves the IPAddressClaim from the indexer for a given namespace and name.
+func (s iPAddressClaimNamespaceLister) Get(name string) (*v1beta1.IPAddressClaim, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta1.Resource("ipaddressclaim"), name)
+	}
+	return obj.(*v1beta1.IPAddressClaim), nil
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2019 Red Hat, Inc.
+ *
+ */
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IPAddressPoolLister helps list IPAddressPools.
+// All objects returned here must be treated as read-only.
+type IPAddressPoolLister interface {
+	// List lists all IPAddressPools in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.IPAddressPool, err error)
+	// IPAddressPools returns an object that can list and get IPAddressPools.
+	IPAddressPools(namespace string) IPAddressPoolNamespaceLister
+	IPAddressPoolListerExpansion
+}
+
+// iPAddressPoolLister implements the IPAddressPoolLister interface.
+type iPAddressPoolLister struct {
+	indexer cache.Indexer
+}
+
+// NewIPAddressPoolLister returns a new IPAddressPoolLister.
+func NewIPAddressPoolLister(indexer cache.Indexer) IPAddressPoolLister {
+	return &iPAddressPoolLister{indexer: indexer}
+}
+
+// List lists all IPAddressPools in the indexer.
+func (s *iPAddressPoolLister) List(selector labels.Selector) (ret []*v1beta1.IPAddressPool, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.IPAddressPool))
+	})
+	return ret, err
+}
+
+// IPAddressPools returns an object that can list and get IPAddressPools.
+func (s *iPAddressPoolLister) IPAddressPools(namespace string) IPAddressPoolNamespaceLister {
+	return iPAddressPoolNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// IPAddressPoolNamespaceLister helps list and get IPAddressPools.
+// All objects returned here must be treated as read-only.
+type IPAddressPoolNamespaceLister interface {
+	// List lists all IPAddressPools in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.IPAddressPool, err error)
+	// Get retrieves the IPAddressPool from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1beta1.IPAddressPool, error)
+	IPAddressPoolNamespaceListerExpansion
+}
+
+// iPAddressPoolNamespaceLister implements the IPAddressPoolNamespaceLister
+// interface.
+type iPAddressPoolNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all IPAddressPools in the indexer for a given namespace.
+func (s iPAddressPoolNamespaceLister) List(selector labels.Selector) (ret []*v1beta1.IPAddressPool, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.IPAddressPool))
+	})
+	return ret, err
+}
+
+// Get retrieves the IPAddressPool from the indexer for a given namespace and name.
+func (s iPAddressPoolNamespaceLister) Get(name string) (*v1beta1.IPAddressPool, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta1.Resource("ipaddresspool"), name)
+	}
+	return obj.(*v1beta1.IPAddressPool), nil
+}
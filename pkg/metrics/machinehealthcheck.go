@@ -16,6 +16,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -48,6 +50,22 @@ var (
 			Help: "Short circuit status for MachineHealthCheck (0=no, 1=yes)",
 		}, []string{"name", "namespace"},
 	)
+
+	// MachineHealthCheckOverlappingMachines is a Prometheus metric, which reports the number of machines covered by the named MachineHealthCheck which are also covered by another MachineHealthCheck
+	MachineHealthCheckOverlappingMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machinehealthcheck_overlapping_machines",
+			Help: "Number of machines covered by a MachineHealthCheck which are also covered by another MachineHealthCheck",
+		}, []string{"name", "namespace"},
+	)
+
+	// MachineHealthCheckRemediationWindowWaitSeconds is a Prometheus metric, which reports for how long unhealthy machines covered by the named MachineHealthCheck have been waiting for a remediation window to open
+	MachineHealthCheckRemediationWindowWaitSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machinehealthcheck_remediation_window_wait_seconds",
+			Help: "Time in seconds unhealthy machines covered by a MachineHealthCheck have been waiting for a remediation window to open",
+		}, []string{"name", "namespace"},
+	)
 )
 
 func InitializeMachineHealthCheckMetrics() {
@@ -55,6 +73,8 @@ func InitializeMachineHealthCheckMetrics() {
 		MachineHealthCheckNodesCovered,
 		MachineHealthCheckRemediationSuccessTotal,
 		MachineHealthCheckShortCircuit,
+		MachineHealthCheckOverlappingMachines,
+		MachineHealthCheckRemediationWindowWaitSeconds,
 	)
 }
 
@@ -92,3 +112,31 @@ func ObserveMachineHealthCheckShortCircuitEnabled(name string, namespace string)
 		"namespace": namespace,
 	}).Set(1)
 }
+
+func DeleteMachineHealthCheckOverlappingMachines(name string, namespace string) {
+	MachineHealthCheckOverlappingMachines.Delete(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+func ObserveMachineHealthCheckOverlappingMachines(name string, namespace string, count int) {
+	MachineHealthCheckOverlappingMachines.With(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	}).Set(float64(count))
+}
+
+func DeleteMachineHealthCheckRemediationWindowWait(name string, namespace string) {
+	MachineHealthCheckRemediationWindowWaitSeconds.Delete(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+func ObserveMachineHealthCheckRemediationWindowWait(name string, namespace string, wait time.Duration) {
+	MachineHealthCheckRemediationWindowWaitSeconds.With(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	}).Set(wait.Seconds())
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	DefaultNodeLinkMetricsAddress = ":8084"
+)
+
+var (
+	// NodeLinkUnresolvedConflicts is a Prometheus metric, which reports the number of nodes claimed by more than one machine
+	NodeLinkUnresolvedConflicts = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mapi_nodelink_unresolved_conflicts",
+			Help: "Number of nodes which can not be linked because they are claimed by more than one machine",
+		},
+	)
+)
+
+func InitializeNodeLinkMetrics() {
+	metrics.Registry.MustRegister(
+		NodeLinkUnresolvedConflicts,
+	)
+}
+
+func ObserveNodeLinkUnresolvedConflicts(count int) {
+	NodeLinkUnresolvedConflicts.Set(float64(count))
+}
@@ -2,11 +2,15 @@ package operator
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
 
 	configv1 "github.com/openshift/api/config/v1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -14,6 +18,11 @@ const (
 	clusterAPIControllerKubemark = "docker.io/gofed/kubemark-machine-controllers:v1.0"
 	clusterAPIControllerNoOp     = "no-op"
 	kubemarkPlatform             = configv1.PlatformType("kubemark")
+
+	// workersConfigMapName is the ConfigMap in the operator namespace the admin can create to configure the
+	// workers of the machine API controllers. Its keys are the controller worker flags without dashes,
+	// e.g. "max-concurrent-reconciles". It is not part of the release payload, so edits are not reverted.
+	workersConfigMapName = "machine-api-controllers-workers"
 )
 
 type Provider string
@@ -21,8 +30,12 @@ type Provider string
 // OperatorConfig contains configuration for MAO
 type OperatorConfig struct {
 	TargetNamespace string `json:"targetNamespace"`
+	Platform        configv1.PlatformType
 	Controllers     Controllers
 	Proxy           *configv1.Proxy
+	// Workers configures the workers of the machineset, machine, nodelink and machine health check controllers,
+	// as read from the workers ConfigMap. Unset options are not passed on, so the controllers keep their defaults.
+	Workers mapicontroller.WorkerOptions
 }
 
 type Controllers struct {
@@ -96,6 +109,39 @@ func getProviderControllerFromImages(platform configv1.PlatformType, images Imag
 	}
 }
 
+// providerSupportsWorkerFlags returns true if the machine controller of the platform
+// accepts the worker flags, only the vSphere machine controller is built from this repository.
+func providerSupportsWorkerFlags(platform configv1.PlatformType) bool {
+	return platform == configv1.VSpherePlatformType
+}
+
+// getWorkersFromConfigMap parses the worker options set in the workers ConfigMap.
+// Unknown keys and invalid values return an error.
+func getWorkersFromConfigMap(cm *corev1.ConfigMap) (mapicontroller.WorkerOptions, error) {
+	workers := mapicontroller.WorkerOptions{}
+	fs := flag.NewFlagSet(workersConfigMapName, flag.ContinueOnError)
+	workers.AddFlags(fs)
+
+	keys := make([]string, 0, len(cm.Data))
+	for key := range cm.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if fs.Lookup(key) == nil {
+			return mapicontroller.WorkerOptions{}, fmt.Errorf("unknown key %q in configmap %s", key, workersConfigMapName)
+		}
+		if err := fs.Set(key, cm.Data[key]); err != nil {
+			return mapicontroller.WorkerOptions{}, fmt.Errorf("invalid value for key %q in configmap %s: %v", key, workersConfigMapName, err)
+		}
+	}
+
+	if err := workers.WithDefaults().Validate(); err != nil {
+		return mapicontroller.WorkerOptions{}, fmt.Errorf("invalid configmap %s: %v", workersConfigMapName, err)
+	}
+	return workers, nil
+}
+
 // getTerminationHandlerFromImages returns the image to use for the Termination Handler DaemonSet
 // based on the platform provided.
 // Defaults to NoOp if not supported by the platform.
@@ -2,8 +2,11 @@ package operator
 
 import (
 	"testing"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var (
@@ -299,3 +302,57 @@ func TestGetKubeRBACProxyFromImages(t *testing.T) {
 		t.Errorf("failed getKubeRBACProxyFromImages. Expected: %s, got: %s", expectedKubeRBACProxyImage, res)
 	}
 }
+
+func TestGetWorkersFromConfigMap(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          map[string]string
+		expected      mapicontroller.WorkerOptions
+		expectedError bool
+	}{{
+		name:     "empty",
+		data:     nil,
+		expected: mapicontroller.WorkerOptions{},
+	}, {
+		name: "all options",
+		data: map[string]string{
+			"max-concurrent-reconciles": "5",
+			"rate-limiter-base-delay":   "10ms",
+			"rate-limiter-max-delay":    "5m",
+			"rate-limiter-qps":          "20",
+			"rate-limiter-burst":        "200",
+		},
+		expected: mapicontroller.WorkerOptions{
+			MaxConcurrentReconciles: 5,
+			RateLimiterBaseDelay:    10 * time.Millisecond,
+			RateLimiterMaxDelay:     5 * time.Minute,
+			RateLimiterQPS:          20,
+			RateLimiterBurst:        200,
+		},
+	}, {
+		name:          "unknown key",
+		data:          map[string]string{"workers": "5"},
+		expectedError: true,
+	}, {
+		name:          "malformed value",
+		data:          map[string]string{"rate-limiter-max-delay": "five minutes"},
+		expectedError: true,
+	}, {
+		name:          "invalid options",
+		data:          map[string]string{"rate-limiter-max-delay": "1ms"},
+		expectedError: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{Data: test.data}
+			res, err := getWorkersFromConfigMap(cm)
+			if test.expectedError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if res != test.expected {
+				t.Errorf("failed getWorkersFromConfigMap. Expected: %+v, got: %+v", test.expected, res)
+			}
+		})
+	}
+}
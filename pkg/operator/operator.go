@@ -11,16 +11,21 @@ import (
 	osclientset "github.com/openshift/client-go/config/clientset/versioned"
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
 	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	admissioninformersv1 "k8s.io/client-go/informers/admissionregistration/v1"
 	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	admissionlisterv1 "k8s.io/client-go/listers/admissionregistration/v1"
 	appslisterv1 "k8s.io/client-go/listers/apps/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -69,6 +74,9 @@ type Operator struct {
 	featureGateLister      configlistersv1.FeatureGateLister
 	featureGateCacheSynced cache.InformerSynced
 
+	configMapLister       corelisterv1.ConfigMapLister
+	configMapListerSynced cache.InformerSynced
+
 	// queue only ever has one item, but it has nice error handling backoff/retry semantics
 	queue           workqueue.RateLimitingInterface
 	operandVersions []osconfigv1.OperandVersion
@@ -89,6 +97,7 @@ func New(
 	validatingWebhookInformer admissioninformersv1.ValidatingWebhookConfigurationInformer,
 	mutatingWebhookInformer admissioninformersv1.MutatingWebhookConfigurationInformer,
 	proxyInformer configinformersv1.ProxyInformer,
+	configMapInformer coreinformersv1.ConfigMapInformer,
 	kubeClient kubernetes.Interface,
 	osClient osclientset.Interface,
 	dynamicClient dynamic.Interface,
@@ -119,6 +128,7 @@ func New(
 	validatingWebhookInformer.Informer().AddEventHandler(optr.eventHandlerSingleton(isMachineWebhook))
 	mutatingWebhookInformer.Informer().AddEventHandler(optr.eventHandlerSingleton(isMachineWebhook))
 	featureGateInformer.Informer().AddEventHandler(optr.eventHandler())
+	configMapInformer.Informer().AddEventHandler(optr.eventHandlerSingleton(isWorkersConfigMap))
 
 	optr.config = config
 	optr.syncHandler = optr.sync
@@ -141,6 +151,9 @@ func New(
 	optr.featureGateLister = featureGateInformer.Lister()
 	optr.featureGateCacheSynced = featureGateInformer.Informer().HasSynced
 
+	optr.configMapLister = configMapInformer.Lister()
+	optr.configMapListerSynced = configMapInformer.Informer().HasSynced
+
 	return optr
 }
 
@@ -158,7 +171,8 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 		optr.deployListerSynced,
 		optr.daemonsetListerSynced,
 		optr.proxyListerSynced,
-		optr.featureGateCacheSynced) {
+		optr.featureGateCacheSynced,
+		optr.configMapListerSynced) {
 		klog.Error("Failed to sync caches")
 		return
 	}
@@ -271,6 +285,11 @@ func isMachineWebhook(obj interface{}) bool {
 	return false
 }
 
+func isWorkersConfigMap(obj interface{}) bool {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	return ok && configMap.Name == workersConfigMapName
+}
+
 func (optr *Operator) worker() {
 	for optr.processNextWorkItem() {
 	}
@@ -365,7 +384,9 @@ func (optr *Operator) maoConfigFromInfrastructure() (*OperatorConfig, error) {
 
 	return &OperatorConfig{
 		TargetNamespace: optr.namespace,
+		Platform:        provider,
 		Proxy:           clusterWideProxy,
+		Workers:         optr.getWorkers(),
 		Controllers: Controllers{
 			Provider:           providerControllerImage,
 			MachineSet:         machineAPIOperatorImage,
@@ -376,3 +397,22 @@ func (optr *Operator) maoConfigFromInfrastructure() (*OperatorConfig, error) {
 		},
 	}, nil
 }
+
+// getWorkers returns the worker options set in the workers ConfigMap.
+// A missing or invalid ConfigMap leaves the controllers with their defaults.
+func (optr *Operator) getWorkers() mapicontroller.WorkerOptions {
+	cm, err := optr.configMapLister.ConfigMaps(optr.namespace).Get(workersConfigMapName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed getting configmap %s: %v", workersConfigMapName, err)
+		}
+		return mapicontroller.WorkerOptions{}
+	}
+
+	workers, err := getWorkersFromConfigMap(cm)
+	if err != nil {
+		klog.Errorf("Ignoring workers configuration: %v", err)
+		return mapicontroller.WorkerOptions{}
+	}
+	return workers
+}
@@ -15,8 +15,10 @@ import (
 	openshiftv1 "github.com/openshift/api/config/v1"
 	fakeos "github.com/openshift/client-go/config/clientset/versioned/fake"
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -26,6 +28,7 @@ import (
 	"k8s.io/client-go/informers"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -49,6 +52,7 @@ func newFakeOperator(kubeObjects []runtime.Object, osObjects []runtime.Object, s
 	daemonsetInformer := kubeNamespacedSharedInformer.Apps().V1().DaemonSets()
 	mutatingWebhookInformer := kubeNamespacedSharedInformer.Admissionregistration().V1().MutatingWebhookConfigurations()
 	validatingWebhookInformer := kubeNamespacedSharedInformer.Admissionregistration().V1().ValidatingWebhookConfigurations()
+	configMapInformer := kubeNamespacedSharedInformer.Core().V1().ConfigMaps()
 
 	optr := &Operator{
 		kubeClient:                    kubeClient,
@@ -60,6 +64,7 @@ func newFakeOperator(kubeObjects []runtime.Object, osObjects []runtime.Object, s
 		daemonsetLister:               daemonsetInformer.Lister(),
 		mutatingWebhookLister:         mutatingWebhookInformer.Lister(),
 		validatingWebhookLister:       validatingWebhookInformer.Lister(),
+		configMapLister:               configMapInformer.Lister(),
 		imagesFile:                    "fixtures/images.json",
 		namespace:                     targetNamespace,
 		eventRecorder:                 record.NewFakeRecorder(50),
@@ -70,6 +75,7 @@ func newFakeOperator(kubeObjects []runtime.Object, osObjects []runtime.Object, s
 		featureGateCacheSynced:        featureGateInformer.Informer().HasSynced,
 		mutatingWebhookListerSynced:   mutatingWebhookInformer.Informer().HasSynced,
 		validatingWebhookListerSynced: validatingWebhookInformer.Informer().HasSynced,
+		configMapListerSynced:         configMapInformer.Informer().HasSynced,
 	}
 
 	configSharedInformer.Start(stopCh)
@@ -288,6 +294,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 		platform       openshiftv1.PlatformType
 		infra          *openshiftv1.Infrastructure
 		proxy          *openshiftv1.Proxy
+		configMap      *corev1.ConfigMap
 		imagesFile     string
 		expectedConfig *OperatorConfig
 		expectedError  error
@@ -299,6 +306,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.AWSPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerAWS,
@@ -310,6 +318,67 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "workers-configmap",
+			platform: openshiftv1.VSpherePlatformType,
+			infra:    infra,
+			proxy:    proxy,
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workersConfigMapName,
+					Namespace: targetNamespace,
+				},
+				Data: map[string]string{
+					"max-concurrent-reconciles": "5",
+					"rate-limiter-max-delay":    "5m",
+				},
+			},
+			expectedConfig: &OperatorConfig{
+				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.VSpherePlatformType,
+				Proxy:           proxy,
+				Workers: mapicontroller.WorkerOptions{
+					MaxConcurrentReconciles: 5,
+					RateLimiterMaxDelay:     5 * time.Minute,
+				},
+				Controllers: Controllers{
+					Provider:           images.ClusterAPIControllerVSphere,
+					MachineSet:         images.MachineAPIOperator,
+					NodeLink:           images.MachineAPIOperator,
+					MachineHealthCheck: images.MachineAPIOperator,
+					TerminationHandler: clusterAPIControllerNoOp,
+					KubeRBACProxy:      images.KubeRBACProxy,
+				},
+			},
+		},
+		{
+			name:     "invalid-workers-configmap",
+			platform: openshiftv1.VSpherePlatformType,
+			infra:    infra,
+			proxy:    proxy,
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workersConfigMapName,
+					Namespace: targetNamespace,
+				},
+				Data: map[string]string{
+					"max-concurrent-reconciles": "-1",
+				},
+			},
+			expectedConfig: &OperatorConfig{
+				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.VSpherePlatformType,
+				Proxy:           proxy,
+				Controllers: Controllers{
+					Provider:           images.ClusterAPIControllerVSphere,
+					MachineSet:         images.MachineAPIOperator,
+					NodeLink:           images.MachineAPIOperator,
+					MachineHealthCheck: images.MachineAPIOperator,
+					TerminationHandler: clusterAPIControllerNoOp,
+					KubeRBACProxy:      images.KubeRBACProxy,
+				},
+			},
+		},
 		{
 			name:     string(openshiftv1.LibvirtPlatformType),
 			platform: openshiftv1.LibvirtPlatformType,
@@ -317,6 +386,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.LibvirtPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerLibvirt,
@@ -335,6 +405,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.OpenStackPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerOpenStack,
@@ -353,6 +424,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.AzurePlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerAzure,
@@ -371,6 +443,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.BareMetalPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerBareMetal,
@@ -389,6 +462,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.GCPPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerGCP,
@@ -407,6 +481,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        kubemarkPlatform,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           clusterAPIControllerKubemark,
@@ -425,6 +500,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.VSpherePlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerVSphere,
@@ -443,6 +519,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.OvirtPlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           images.ClusterAPIControllerOvirt,
@@ -461,6 +538,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        openshiftv1.NonePlatformType,
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           clusterAPIControllerNoOp,
@@ -479,6 +557,7 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 			proxy:    proxy,
 			expectedConfig: &OperatorConfig{
 				TargetNamespace: targetNamespace,
+				Platform:        "bad-platform",
 				Proxy:           proxy,
 				Controllers: Controllers{
 					Provider:           clusterAPIControllerNoOp,
@@ -540,9 +619,14 @@ func TestMAOConfigFromInfrastructure(t *testing.T) {
 				proxy := tc.proxy.DeepCopy()
 				objects = append(objects, proxy)
 			}
+			kubeObjects := []runtime.Object{}
+			if tc.configMap != nil {
+				kubeObjects = append(kubeObjects, tc.configMap.DeepCopy())
+			}
 			stopCh := make(<-chan struct{})
-			optr := newFakeOperator(nil, objects, stopCh)
+			optr := newFakeOperator(kubeObjects, objects, stopCh)
 			optr.queue.Add("trigger")
+			g.Expect(cache.WaitForCacheSync(stopCh, optr.configMapListerSynced)).To(BeTrue())
 
 			if tc.imagesFile != "" {
 				optr.imagesFile = tc.imagesFile
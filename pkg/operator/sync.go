@@ -34,6 +34,7 @@ const (
 	machineExposeMetricsPort            = 8441
 	machineSetExposeMetricsPort         = 8442
 	machineHealthCheckExposeMetricsPort = 8444
+	nodeLinkExposeMetricsPort           = 8445
 	defaultMachineHealthPort            = 9440
 	defaultMachineSetHealthPort         = 9441
 	defaultMachineHealthCheckHealthPort = 9442
@@ -480,6 +481,14 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 		fmt.Sprintf("--namespace=%s", config.TargetNamespace),
 	}
 
+	// Only the controllers built from this repository define the worker flags,
+	// out of tree provider controllers would refuse to start with them.
+	controllerArgs := append(append([]string{}, args...), config.Workers.Args()...)
+	providerArgs := args
+	if providerSupportsWorkerFlags(config.Platform) {
+		providerArgs = controllerArgs
+	}
+
 	proxyEnvArgs := getProxyArgs(config)
 
 	containers := []corev1.Container{
@@ -487,7 +496,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "machineset-controller",
 			Image:     config.Controllers.MachineSet,
 			Command:   []string{"/machineset-controller"},
-			Args:      args,
+			Args:      controllerArgs,
 			Resources: resources,
 			Env:       proxyEnvArgs,
 			Ports: []corev1.ContainerPort{
@@ -528,7 +537,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "machine-controller",
 			Image:     config.Controllers.Provider,
 			Command:   []string{"/machine-controller-manager"},
-			Args:      args,
+			Args:      providerArgs,
 			Resources: resources,
 			Env: append(proxyEnvArgs, corev1.EnvVar{
 				Name: "NODE_NAME",
@@ -575,7 +584,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "nodelink-controller",
 			Image:     config.Controllers.NodeLink,
 			Command:   []string{"/nodelink-controller"},
-			Args:      args,
+			Args:      controllerArgs,
 			Env:       proxyEnvArgs,
 			Resources: resources,
 		},
@@ -583,7 +592,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "machine-healthcheck-controller",
 			Image:     config.Controllers.MachineHealthCheck,
 			Command:   []string{"/machine-healthcheck"},
-			Args:      args,
+			Args:      controllerArgs,
 			Env:       proxyEnvArgs,
 			Resources: resources,
 			Ports: []corev1.ContainerPort{
@@ -618,6 +627,7 @@ func newKubeProxyContainers(image string) []corev1.Container {
 		newKubeProxyContainer(image, "machineset-mtrc", metrics.DefaultMachineSetMetricsAddress, machineSetExposeMetricsPort),
 		newKubeProxyContainer(image, "machine-mtrc", metrics.DefaultMachineMetricsAddress, machineExposeMetricsPort),
 		newKubeProxyContainer(image, "mhc-mtrc", metrics.DefaultHealthCheckMetricsAddress, machineHealthCheckExposeMetricsPort),
+		newKubeProxyContainer(image, "nodelink-mtrc", metrics.DefaultNodeLinkMetricsAddress, nodeLinkExposeMetricsPort),
 	}
 }
 
@@ -2,9 +2,13 @@ package operator
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	configv1 "github.com/openshift/api/config/v1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -241,3 +245,77 @@ func Test_ensureDependecyAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func TestNewContainersWorkerArgs(t *testing.T) {
+	workers := mapicontroller.WorkerOptions{
+		MaxConcurrentReconciles: 5,
+		RateLimiterMaxDelay:     5 * time.Minute,
+	}
+	workerArgs := []string{"--max-concurrent-reconciles=5", "--rate-limiter-max-delay=5m0s"}
+
+	testCases := []struct {
+		name         string
+		platform     configv1.PlatformType
+		workers      mapicontroller.WorkerOptions
+		expectedArgs map[string][]string
+	}{
+		{
+			name:     "unset workers are not passed on",
+			platform: configv1.VSpherePlatformType,
+			workers:  mapicontroller.WorkerOptions{},
+			expectedArgs: map[string][]string{
+				"machineset-controller":          nil,
+				"machine-controller":             nil,
+				"nodelink-controller":            nil,
+				"machine-healthcheck-controller": nil,
+			},
+		},
+		{
+			name:     "set workers are passed on",
+			platform: configv1.VSpherePlatformType,
+			workers:  workers,
+			expectedArgs: map[string][]string{
+				"machineset-controller":          workerArgs,
+				"machine-controller":             workerArgs,
+				"nodelink-controller":            workerArgs,
+				"machine-healthcheck-controller": workerArgs,
+			},
+		},
+		{
+			name:     "set workers are not passed on to out of tree machine controllers",
+			platform: configv1.AWSPlatformType,
+			workers:  workers,
+			expectedArgs: map[string][]string{
+				"machineset-controller":          workerArgs,
+				"machine-controller":             nil,
+				"nodelink-controller":            workerArgs,
+				"machine-healthcheck-controller": workerArgs,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &OperatorConfig{
+				TargetNamespace: targetNamespace,
+				Platform:        tc.platform,
+				Workers:         tc.workers,
+			}
+			for _, container := range newContainers(config, nil) {
+				expectedArgs, ok := tc.expectedArgs[container.Name]
+				if !ok {
+					continue
+				}
+				var gotArgs []string
+				for _, arg := range container.Args {
+					if strings.HasPrefix(arg, "--max-concurrent-reconciles") || strings.HasPrefix(arg, "--rate-limiter") {
+						gotArgs = append(gotArgs, arg)
+					}
+				}
+				if !reflect.DeepEqual(gotArgs, expectedArgs) {
+					t.Errorf("%s: expected worker args %v, got %v", container.Name, expectedArgs, gotArgs)
+				}
+			}
+		})
+	}
+}
@@ -45,6 +45,30 @@ func TestGet(t *testing.T) {
 	g.Expect(Get(mhc, "conditionBaz")).To(haveSameStateOf(TrueCondition("conditionBaz")))
 }
 
+func TestIsTrue(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &mapiv1.Machine{}
+	g.Expect(IsTrue(machine, "true1")).To(BeFalse())
+
+	machine.SetConditions(conditionList(true1, unknown1, falseInfo1))
+	g.Expect(IsTrue(machine, "true1")).To(BeTrue())
+	g.Expect(IsTrue(machine, "unknown1")).To(BeFalse())
+	g.Expect(IsTrue(machine, "falseInfo1")).To(BeFalse())
+}
+
+func TestIsFalse(t *testing.T) {
+	g := NewWithT(t)
+
+	mhc := &mapiv1.MachineHealthCheck{}
+	g.Expect(IsFalse(mhc, "falseInfo1")).To(BeFalse())
+
+	mhc.SetConditions(conditionList(true1, unknown1, falseInfo1))
+	g.Expect(IsFalse(mhc, "true1")).To(BeFalse())
+	g.Expect(IsFalse(mhc, "unknown1")).To(BeFalse())
+	g.Expect(IsFalse(mhc, "falseInfo1")).To(BeTrue())
+}
+
 func conditionList(conditions ...*mapiv1.Condition) mapiv1.Conditions {
 	cs := mapiv1.Conditions{}
 	for _, x := range conditions {
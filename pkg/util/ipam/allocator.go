@@ -0,0 +1,232 @@
+package ipam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrInvalidPool is returned when an IPAddressPool does not exist or its spec can't be parsed
+	ErrInvalidPool = errors.New("invalid IP address pool")
+	// ErrPoolExhausted is returned when all the addresses of an IPAddressPool are claimed
+	ErrPoolExhausted = errors.New("no free address left in IP address pool")
+)
+
+// Allocator allocates the addresses of IPAddressPools to the network devices of Machines.
+// Allocations are recorded by IPAddressClaims owned by the Machines, so they survive
+// controller restarts. Claims are named after their pool and address, so the API server
+// rejects a second claim of the same address.
+type Allocator struct {
+	client client.Client
+	// reader bypasses the cache, so that the claims created by a previous
+	// reconcile are always found
+	reader client.Reader
+}
+
+// Allocation is an address allocated to a network device, along with the pool describing its network
+type Allocation struct {
+	Claim *machinev1.IPAddressClaim
+	Pool  *machinev1.IPAddressPool
+}
+
+// CIDR returns the allocated address with the prefix length of its pool
+func (a *Allocation) CIDR() string {
+	return fmt.Sprintf("%s/%d", a.Claim.Spec.Address, a.Pool.Spec.Prefix)
+}
+
+// NewAllocator returns an allocator writing claims with the client and looking them up with the reader
+func NewAllocator(c client.Client, reader client.Reader) *Allocator {
+	return &Allocator{
+		client: c,
+		reader: reader,
+	}
+}
+
+// Claim returns the address allocated to the network device of the machine,
+// allocating a free address of the pool if the device has none yet.
+func (a *Allocator) Claim(ctx context.Context, machine *machinev1.Machine, device int, poolName string) (*Allocation, error) {
+	pool, err := a.getPool(ctx, machine.Namespace, poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := a.listMachineClaims(ctx, machine, client.MatchingLabels{
+		machinev1.IPAddressClaimDeviceLabel: strconv.Itoa(device),
+		machinev1.IPAddressPoolLabel:        poolName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) > 0 {
+		return &Allocation{Claim: &claims[0], Pool: pool}, nil
+	}
+
+	ranges, err := parsePool(pool)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidPool, poolName, err)
+	}
+
+	claims, err = a.listClaims(ctx, machine.Namespace, client.MatchingLabels{machinev1.IPAddressPoolLabel: poolName})
+	if err != nil {
+		return nil, err
+	}
+	claimed := sets.NewString()
+	for _, claim := range claims {
+		claimed.Insert(claim.Spec.Address)
+	}
+
+	for _, r := range ranges {
+		for ip, last := r.first, false; !last; ip = nextIP(ip) {
+			last = ip.Equal(r.last)
+			address := ip.String()
+			if claimed.Has(address) || address == pool.Spec.Gateway {
+				continue
+			}
+
+			claim := newClaim(machine, device, pool, address)
+			if err := a.client.Create(ctx, claim); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					// Claimed concurrently, try the next address
+					continue
+				}
+				return nil, fmt.Errorf("failed to claim address %s of IP address pool %s: %w", address, poolName, err)
+			}
+			klog.Infof("%s: claimed address %s of IP address pool %s for device %d", machine.Name, address, poolName, device)
+			return &Allocation{Claim: claim, Pool: pool}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w %s", ErrPoolExhausted, poolName)
+}
+
+// Get returns the address allocated to the network device of the machine, or nil if there is none
+func (a *Allocator) Get(ctx context.Context, machine *machinev1.Machine, device int) (*Allocation, error) {
+	claims, err := a.listMachineClaims(ctx, machine, client.MatchingLabels{
+		machinev1.IPAddressClaimDeviceLabel: strconv.Itoa(device),
+	})
+	if err != nil || len(claims) == 0 {
+		return nil, err
+	}
+
+	pool, err := a.getPool(ctx, machine.Namespace, claims[0].Spec.Pool.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &Allocation{Claim: &claims[0], Pool: pool}, nil
+}
+
+// Release deletes the claims of the machine, returning their addresses to their pools
+func (a *Allocator) Release(ctx context.Context, machine *machinev1.Machine) error {
+	claims, err := a.listMachineClaims(ctx, machine, client.MatchingLabels{})
+	if err != nil {
+		return err
+	}
+
+	for i := range claims {
+		if err := a.client.Delete(ctx, &claims[i]); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to release address %s of IP address pool %s: %w", claims[i].Spec.Address, claims[i].Spec.Pool.Name, err)
+		}
+		klog.Infof("%s: released address %s of IP address pool %s", machine.Name, claims[i].Spec.Address, claims[i].Spec.Pool.Name)
+	}
+	return nil
+}
+
+// Orphan removes the machine from the owners of its claims, so that their
+// addresses stay allocated once the machine is deleted while its instance keeps running.
+// The machine label is removed too, so that a new machine with the same name
+// doesn't take over the addresses of the orphaned instance.
+func (a *Allocator) Orphan(ctx context.Context, machine *machinev1.Machine) error {
+	claims, err := a.listMachineClaims(ctx, machine, client.MatchingLabels{})
+	if err != nil {
+		return err
+	}
+
+	for i := range claims {
+		claim := &claims[i]
+		var owners []metav1.OwnerReference
+		for _, owner := range claim.OwnerReferences {
+			if owner.UID != machine.UID {
+				owners = append(owners, owner)
+			}
+		}
+
+		patch := client.MergeFrom(claim.DeepCopy())
+		claim.OwnerReferences = owners
+		delete(claim.Labels, machinev1.IPAddressClaimMachineLabel)
+		if err := a.client.Patch(ctx, claim, patch); err != nil {
+			return fmt.Errorf("failed to orphan address %s of IP address pool %s: %w", claim.Spec.Address, claim.Spec.Pool.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a *Allocator) getPool(ctx context.Context, namespace, name string) (*machinev1.IPAddressPool, error) {
+	pool := &machinev1.IPAddressPool{}
+	if err := a.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w %s: not found", ErrInvalidPool, name)
+		}
+		return nil, fmt.Errorf("failed to get IP address pool %s: %w", name, err)
+	}
+	return pool, nil
+}
+
+func (a *Allocator) listClaims(ctx context.Context, namespace string, labels client.MatchingLabels) ([]machinev1.IPAddressClaim, error) {
+	claims := &machinev1.IPAddressClaimList{}
+	if err := a.reader.List(ctx, claims, client.InNamespace(namespace), labels); err != nil {
+		return nil, fmt.Errorf("failed to list IP address claims: %w", err)
+	}
+	return claims.Items, nil
+}
+
+// listMachineClaims returns the claims matching the labels which are
+// controlled by the machine. Claims are labeled with the name of their
+// machine, which a machine created later with the same name shares.
+func (a *Allocator) listMachineClaims(ctx context.Context, machine *machinev1.Machine, labels client.MatchingLabels) ([]machinev1.IPAddressClaim, error) {
+	labels[machinev1.IPAddressClaimMachineLabel] = machine.Name
+	claims, err := a.listClaims(ctx, machine.Namespace, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []machinev1.IPAddressClaim
+	for i := range claims {
+		if metav1.IsControlledBy(&claims[i], machine) {
+			owned = append(owned, claims[i])
+		}
+	}
+	return owned, nil
+}
+
+func newClaim(machine *machinev1.Machine, device int, pool *machinev1.IPAddressPool, address string) *machinev1.IPAddressClaim {
+	return &machinev1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			// IPv6 addresses contain colons, which are not valid in names
+			Name:      fmt.Sprintf("%s-%s", pool.Name, strings.ReplaceAll(address, ":", "-")),
+			Namespace: machine.Namespace,
+			Labels: map[string]string{
+				machinev1.IPAddressPoolLabel:         pool.Name,
+				machinev1.IPAddressClaimMachineLabel: machine.Name,
+				machinev1.IPAddressClaimDeviceLabel:  strconv.Itoa(device),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(machine, machinev1.SchemeGroupVersion.WithKind("Machine")),
+			},
+		},
+		Spec: machinev1.IPAddressClaimSpec{
+			Pool:    corev1.LocalObjectReference{Name: pool.Name},
+			Address: address,
+		},
+	}
+}
@@ -0,0 +1,208 @@
+package ipam
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "openshift-machine-api"
+
+func newTestMachine(name string) *machinev1.Machine {
+	return &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			UID:       types.UID("uid-" + name),
+		},
+	}
+}
+
+func newTestPool(addresses ...string) *machinev1.IPAddressPool {
+	return &machinev1.IPAddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pool",
+			Namespace: testNamespace,
+		},
+		Spec: machinev1.IPAddressPoolSpec{
+			Addresses: addresses,
+			Prefix:    24,
+			Gateway:   "192.168.1.1",
+		},
+	}
+}
+
+func newTestAllocator(t *testing.T, objs ...runtime.Object) (*Allocator, client.Client) {
+	scheme := runtime.NewScheme()
+	if err := machinev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	return NewAllocator(c, c), c
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool("192.168.1.0/30", "192.168.1.10")
+	taken := newClaim(newTestMachine("other"), 0, pool, "192.168.1.2")
+	allocator, _ := newTestAllocator(t, pool, taken)
+
+	machine := newTestMachine("machine")
+	// 192.168.1.1 is the gateway and 192.168.1.2 is already claimed
+	allocation, err := allocator.Claim(ctx, machine, 0, pool.Name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation.CIDR() != "192.168.1.10/24" {
+		t.Errorf("Expected address 192.168.1.10/24, got: %s", allocation.CIDR())
+	}
+	if len(allocation.Claim.OwnerReferences) != 1 || allocation.Claim.OwnerReferences[0].UID != machine.UID {
+		t.Errorf("Expected the claim to be owned by the machine, got owners: %v", allocation.Claim.OwnerReferences)
+	}
+
+	// Claiming again returns the existing allocation
+	allocation, err = allocator.Claim(ctx, machine, 0, pool.Name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation.Claim.Spec.Address != "192.168.1.10" {
+		t.Errorf("Expected address 192.168.1.10, got: %s", allocation.Claim.Spec.Address)
+	}
+
+	allocation, err = allocator.Get(ctx, machine, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation == nil || allocation.Claim.Spec.Address != "192.168.1.10" {
+		t.Errorf("Expected address 192.168.1.10, got: %v", allocation)
+	}
+
+	if _, err := allocator.Claim(ctx, machine, 1, pool.Name); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("Expected error %v, got: %v", ErrPoolExhausted, err)
+	}
+}
+
+func TestClaimInvalidPool(t *testing.T) {
+	ctx := context.Background()
+	allocator, _ := newTestAllocator(t, newTestPool("192.168.1.20-192.168.1.10"))
+	machine := newTestMachine("machine")
+
+	if _, err := allocator.Claim(ctx, machine, 0, "pool"); !errors.Is(err, ErrInvalidPool) {
+		t.Errorf("Expected error %v, got: %v", ErrInvalidPool, err)
+	}
+	if _, err := allocator.Claim(ctx, machine, 0, "missing"); !errors.Is(err, ErrInvalidPool) {
+		t.Errorf("Expected error %v, got: %v", ErrInvalidPool, err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool("192.168.1.10-192.168.1.20")
+	machine := newTestMachine("machine")
+	other := newTestMachine("other")
+	allocator, c := newTestAllocator(t, pool,
+		newClaim(machine, 0, pool, "192.168.1.10"),
+		newClaim(machine, 1, pool, "192.168.1.11"),
+		newClaim(other, 0, pool, "192.168.1.12"),
+	)
+
+	if err := allocator.Release(ctx, machine); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	claims := &machinev1.IPAddressClaimList{}
+	if err := c.List(ctx, claims); err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Items) != 1 || claims.Items[0].Spec.Address != "192.168.1.12" {
+		t.Errorf("Expected only the claim of the other machine to be left, got: %v", claims.Items)
+	}
+}
+
+func TestOrphan(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool("192.168.1.10-192.168.1.20")
+	machine := newTestMachine("machine")
+	claim := newClaim(machine, 0, pool, "192.168.1.10")
+	allocator, c := newTestAllocator(t, pool, claim)
+
+	if err := allocator.Orphan(ctx, machine); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := &machinev1.IPAddressClaim{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Name}, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.OwnerReferences) != 0 {
+		t.Errorf("Expected the claim to have no owner, got: %v", got.OwnerReferences)
+	}
+	if got.Spec.Pool != (corev1.LocalObjectReference{Name: pool.Name}) {
+		t.Errorf("Expected the claim to keep its pool, got: %v", got.Spec.Pool)
+	}
+	if _, ok := got.Labels[machinev1.IPAddressClaimMachineLabel]; ok {
+		t.Errorf("Expected the claim to have no machine label, got: %v", got.Labels)
+	}
+
+	// A new machine with the same name doesn't take over the orphaned address
+	replacement := newTestMachine("machine")
+	replacement.UID = "uid-replacement"
+	allocation, err := allocator.Get(ctx, replacement, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation != nil {
+		t.Errorf("Expected no address, got: %v", allocation.Claim.Spec.Address)
+	}
+	allocation, err = allocator.Claim(ctx, replacement, 0, pool.Name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation.Claim.Spec.Address != "192.168.1.11" {
+		t.Errorf("Expected address 192.168.1.11, got: %s", allocation.Claim.Spec.Address)
+	}
+	if err := allocator.Release(ctx, replacement); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: claim.Namespace, Name: claim.Name}, got); err != nil {
+		t.Errorf("Expected the orphaned claim to be kept, got: %v", err)
+	}
+}
+
+func TestClaimIgnoresClaimsOfPreviousMachine(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool("192.168.1.10-192.168.1.20")
+	previous := newTestMachine("machine")
+	// the claim is left behind by a deleted machine with the same name
+	claim := newClaim(previous, 0, pool, "192.168.1.10")
+	allocator, c := newTestAllocator(t, pool, claim)
+
+	machine := newTestMachine("machine")
+	machine.UID = "uid-new"
+	allocation, err := allocator.Claim(ctx, machine, 0, pool.Name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if allocation.Claim.Spec.Address != "192.168.1.11" {
+		t.Errorf("Expected address 192.168.1.11, got: %s", allocation.Claim.Spec.Address)
+	}
+
+	if err := allocator.Release(ctx, machine); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	claims := &machinev1.IPAddressClaimList{}
+	if err := c.List(ctx, claims); err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Items) != 1 || claims.Items[0].Spec.Address != "192.168.1.10" {
+		t.Errorf("Expected only the claim of the previous machine to be left, got: %v", claims.Items)
+	}
+}
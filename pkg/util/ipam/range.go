@@ -0,0 +1,113 @@
+package ipam
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+)
+
+// ipRange is an inclusive range of addresses of a single IP family.
+// IPv4 addresses are stored in their 4 bytes form, so that the length of an
+// address tells its family.
+type ipRange struct {
+	first net.IP
+	last  net.IP
+}
+
+// parsePool returns the ranges of addresses of the pool, checking they all belong to the same IP family
+func parsePool(pool *machinev1.IPAddressPool) ([]ipRange, error) {
+	if len(pool.Spec.Addresses) == 0 {
+		return nil, errors.New("no addresses")
+	}
+
+	var ranges []ipRange
+	for _, addresses := range pool.Spec.Addresses {
+		r, err := parseRange(addresses)
+		if err != nil {
+			return nil, err
+		}
+		if len(ranges) > 0 && len(r.first) != len(ranges[0].first) {
+			return nil, fmt.Errorf("%s: all the addresses must belong to the same IP family", addresses)
+		}
+		ranges = append(ranges, r)
+	}
+
+	maxPrefix := int32(len(ranges[0].first) * 8)
+	if pool.Spec.Prefix < 0 || pool.Spec.Prefix > maxPrefix {
+		return nil, fmt.Errorf("prefix %d is out of range [0, %d]", pool.Spec.Prefix, maxPrefix)
+	}
+	return ranges, nil
+}
+
+// parseRange parses a single address, a range of addresses (first-last) or a CIDR.
+// The network and broadcast addresses of IPv4 CIDRs are excluded.
+func parseRange(addresses string) (ipRange, error) {
+	if strings.Contains(addresses, "/") {
+		_, ipNet, err := net.ParseCIDR(addresses)
+		if err != nil {
+			return ipRange{}, err
+		}
+		first := normalizeIP(ipNet.IP)
+		last := make(net.IP, len(first))
+		for i := range first {
+			last[i] = first[i] | ^ipNet.Mask[i]
+		}
+		if ones, bits := ipNet.Mask.Size(); bits == 8*net.IPv4len && ones < 31 {
+			first, last = nextIP(first), prevIP(last)
+		}
+		return ipRange{first: first, last: last}, nil
+	}
+
+	bounds := strings.SplitN(addresses, "-", 2)
+	first := normalizeIP(net.ParseIP(strings.TrimSpace(bounds[0])))
+	last := first
+	if len(bounds) == 2 {
+		last = normalizeIP(net.ParseIP(strings.TrimSpace(bounds[1])))
+	}
+	if first == nil || last == nil {
+		return ipRange{}, fmt.Errorf("%s: invalid address or range of addresses", addresses)
+	}
+	if len(first) != len(last) {
+		return ipRange{}, fmt.Errorf("%s: the bounds of the range must belong to the same IP family", addresses)
+	}
+	if bytes.Compare(first, last) > 0 {
+		return ipRange{}, fmt.Errorf("%s: the first address of the range is after the last one", addresses)
+	}
+	return ipRange{first: first, last: last}, nil
+}
+
+func normalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// nextIP returns the address following the given one
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+func prevIP(ip net.IP) net.IP {
+	prev := make(net.IP, len(ip))
+	copy(prev, ip)
+	for i := len(prev) - 1; i >= 0; i-- {
+		prev[i]--
+		if prev[i] != 0xff {
+			break
+		}
+	}
+	return prev
+}
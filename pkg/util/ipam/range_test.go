@@ -0,0 +1,88 @@
+package ipam
+
+import (
+	"testing"
+
+	machinev1 "github.com/openshift/machine-api-operator/pkg/apis/machine/v1beta1"
+)
+
+func TestParseRange(t *testing.T) {
+	testCases := []struct {
+		addresses     string
+		expectedFirst string
+		expectedLast  string
+		expectedError string
+	}{
+		{addresses: "192.168.1.10", expectedFirst: "192.168.1.10", expectedLast: "192.168.1.10"},
+		{addresses: "192.168.1.10-192.168.1.20", expectedFirst: "192.168.1.10", expectedLast: "192.168.1.20"},
+		{addresses: "192.168.1.0/28", expectedFirst: "192.168.1.1", expectedLast: "192.168.1.14"},
+		{addresses: "192.168.1.4/31", expectedFirst: "192.168.1.4", expectedLast: "192.168.1.5"},
+		{addresses: "fd00::/126", expectedFirst: "fd00::", expectedLast: "fd00::3"},
+		{addresses: "fd00::1-fd00::ff", expectedFirst: "fd00::1", expectedLast: "fd00::ff"},
+		{addresses: "192.168.1.300", expectedError: "192.168.1.300: invalid address or range of addresses"},
+		{addresses: "192.168.1.10-fd00::1", expectedError: "192.168.1.10-fd00::1: the bounds of the range must belong to the same IP family"},
+		{addresses: "192.168.1.20-192.168.1.10", expectedError: "192.168.1.20-192.168.1.10: the first address of the range is after the last one"},
+		{addresses: "192.168.1.0/33", expectedError: "invalid CIDR address: 192.168.1.0/33"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.addresses, func(t *testing.T) {
+			r, err := parseRange(tc.addresses)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("Expected error: %q, got: %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if r.first.String() != tc.expectedFirst || r.last.String() != tc.expectedLast {
+				t.Errorf("Expected range %s-%s, got: %s-%s", tc.expectedFirst, tc.expectedLast, r.first, r.last)
+			}
+		})
+	}
+}
+
+func TestParsePool(t *testing.T) {
+	testCases := []struct {
+		name          string
+		spec          machinev1.IPAddressPoolSpec
+		expectedError string
+	}{
+		{
+			name: "valid pool",
+			spec: machinev1.IPAddressPoolSpec{Addresses: []string{"192.168.1.10", "192.168.1.20-192.168.1.30"}, Prefix: 24},
+		},
+		{
+			name:          "no addresses",
+			spec:          machinev1.IPAddressPoolSpec{Prefix: 24},
+			expectedError: "no addresses",
+		},
+		{
+			name:          "mixed IP families",
+			spec:          machinev1.IPAddressPoolSpec{Addresses: []string{"192.168.1.10", "fd00::10"}, Prefix: 24},
+			expectedError: "fd00::10: all the addresses must belong to the same IP family",
+		},
+		{
+			name:          "IPv4 prefix out of range",
+			spec:          machinev1.IPAddressPoolSpec{Addresses: []string{"192.168.1.10"}, Prefix: 64},
+			expectedError: "prefix 64 is out of range [0, 32]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parsePool(&machinev1.IPAddressPool{Spec: tc.spec})
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("Expected error: %q, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}